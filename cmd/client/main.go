@@ -11,24 +11,39 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
+	"mini-spark/internal/common"
+	"mini-spark/internal/jobtemplate"
+	"mini-spark/internal/utils"
+	"mini-spark/pkg/minispark"
 	"os"
+	"strconv"
+	"strings"
 )
 
-// URL base del API REST del nodo Master
-const baseURL = "http://localhost:8080/api/v1/jobs"
+// client - Cliente SDK apuntando al Master (MASTER_URL o localhost:8080)
+var client = minispark.NewClient(utils.GetEnv("MASTER_URL", minispark.DefaultMasterURL))
 
 // main - Punto de entrada del cliente CLI
-// Entrada: argumentos de linea de comandos (submit|status|results)
+// Entrada: argumentos de linea de comandos (submit|jobs|status|events|cancel|queue|workers|decommission|scaling|results|sql|tables)
 // Salida: ninguna (void), termina con exit code
 // Descripcion: Parsea comandos CLI y delega a funciones especificas:
 //   - submit: envia job definition al Master
+//   - jobs: lista los jobs (opcionalmente filtrados por estado)
 //   - status: consulta progreso y metricas de job
-//   - results: descarga archivos de salida finales
+//   - events: muestra el historial de eventos de un job (linea de tiempo)
+//   - cancel: cancela un job en ejecucion
+//   - queue: muestra la cola de tareas pendientes del Master
+//   - workers: lista los workers o muestra el detalle de uno
+//   - decommission: retira un worker de forma ordenada
+//   - scaling: muestra las señales y el estado del escalado de workers
+//   - results: descarga archivos de salida finales (o el contenido de un nodo)
+//   - sql: compila una consulta SQL y la envia como job
+//   - tables: lista o registra tablas para SQL
 func main() {
 	// Validar que se proporciono al menos un comando
 	if len(os.Args) < 2 {
@@ -43,19 +58,68 @@ func main() {
 	switch command {
 	case "submit":
 		if len(os.Args) < 3 {
-			log.Fatal("Uso: submit <archivo_job.json>")
+			log.Fatal("Uso: submit <archivo_job.json> [--param clave=valor ...]")
 		}
-		submitJob(os.Args[2])
+		submitJob(os.Args[2], parseParams(os.Args[3:]))
+	case "jobs":
+		status := ""
+		if len(os.Args) >= 3 {
+			status = strings.ToUpper(os.Args[2])
+		}
+		listJobs(status)
 	case "status":
 		if len(os.Args) < 3 {
 			log.Fatal("Uso: status <job_id>")
 		}
 		getJobStatus(os.Args[2])
+	case "events":
+		if len(os.Args) < 3 {
+			log.Fatal("Uso: events <job_id>")
+		}
+		showJobEvents(os.Args[2])
+	case "cancel":
+		if len(os.Args) < 3 {
+			log.Fatal("Uso: cancel <job_id>")
+		}
+		cancelJob(os.Args[2])
+	case "queue":
+		showQueue()
+	case "workers":
+		if len(os.Args) >= 3 {
+			showWorker(os.Args[2])
+		} else {
+			listWorkers()
+		}
+	case "decommission":
+		if len(os.Args) < 3 {
+			log.Fatal("Uso: decommission <worker_id>")
+		}
+		decommissionWorker(os.Args[2])
+	case "scaling":
+		showScaling()
 	case "results":
 		if len(os.Args) < 3 {
-			log.Fatal("Uso: results <job_id>")
+			log.Fatal("Uso: results <job_id> [nodo]")
+		}
+		if len(os.Args) >= 4 {
+			streamNodeOutput(os.Args[2], os.Args[3])
+		} else {
+			getJobResults(os.Args[2])
+		}
+	case "sql":
+		if len(os.Args) < 3 {
+			log.Fatal("Uso: sql \"<consulta>\" [nombre] [paralelismo]")
+		}
+		submitSQL(os.Args[2], os.Args[3:])
+	case "tables":
+		if len(os.Args) >= 3 && os.Args[2] == "add" {
+			if len(os.Args) < 6 {
+				log.Fatal("Uso: tables add <nombre> <ruta> <col1,col2,...> [csv|jsonl]")
+			}
+			registerTable(os.Args[3], os.Args[4], os.Args[5], os.Args[6:])
+		} else {
+			listTables()
 		}
-		getJobResults(os.Args[2])
 	default:
 		printHelp()
 	}
@@ -70,17 +134,88 @@ func main() {
 func printHelp() {
 	fmt.Println("Uso de Mini-Spark CLI:")
 	fmt.Println("  go run cmd/client/main.go submit <archivo.json>   -> Enviar nuevo trabajo")
+	fmt.Println("  go run cmd/client/main.go submit <plantilla.json> --param input=data/x.csv --param parallelism=8 -> Enviar plantilla")
+	fmt.Println("  go run cmd/client/main.go jobs [estado]           -> Listar jobs (RUNNING, COMPLETED, FAILED, CANCELLED)")
 	fmt.Println("  go run cmd/client/main.go status <job_id>         -> Ver estado y métricas")
+	fmt.Println("  go run cmd/client/main.go events <job_id>         -> Ver historial de eventos del job")
+	fmt.Println("  go run cmd/client/main.go cancel <job_id>         -> Cancelar un job en ejecución")
+	fmt.Println("  go run cmd/client/main.go queue                   -> Ver tareas pendientes del Master")
+	fmt.Println("  go run cmd/client/main.go workers                 -> Listar workers registrados")
+	fmt.Println("  go run cmd/client/main.go workers <worker_id>     -> Ver tareas y métricas de un worker")
+	fmt.Println("  go run cmd/client/main.go decommission <worker_id> -> Retirar un worker de forma ordenada")
+	fmt.Println("  go run cmd/client/main.go scaling                 -> Ver señales de escalado de workers")
 	fmt.Println("  go run cmd/client/main.go results <job_id>        -> Ver archivos de salida")
+	fmt.Println("  go run cmd/client/main.go results <job_id> <nodo> -> Ver contenido de la salida de un nodo")
+	fmt.Println("  go run cmd/client/main.go sql \"SELECT ...\" [nombre] [paralelismo] -> Enviar consulta SQL")
+	fmt.Println("  go run cmd/client/main.go tables                  -> Listar tablas registradas")
+	fmt.Println("  go run cmd/client/main.go tables add <nombre> <ruta> <col1,col2> [csv|jsonl] -> Registrar tabla")
+}
+
+// exitOnError - Termina el CLI con un mensaje segun el tipo de error del SDK
+// Entrada: action - descripcion de la operacion, err - error retornado
+// Salida: ninguna (void), termina con exit code 1 si err != nil
+func exitOnError(action string, err error) {
+	if err == nil {
+		return
+	}
+	var apiErr *minispark.APIError
+	var valErr *minispark.ValidationError
+	switch {
+	case errors.Is(err, minispark.ErrJobNotFound):
+		log.Fatalf("%s: job no encontrado", action)
+	case errors.As(err, &valErr):
+		log.Fatalf("%s: %v", action, valErr)
+	case errors.As(err, &apiErr):
+		log.Fatalf("%s: Error del Master (%d): %s", action, apiErr.StatusCode, apiErr.Message)
+	default:
+		log.Fatalf("%s: %v", action, err)
+	}
+}
+
+// printJSON - Imprime un valor como JSON indentado con un titulo
+func printJSON(title string, v interface{}) {
+	data, _ := json.Marshal(v)
+	var prettyJSON bytes.Buffer
+	json.Indent(&prettyJSON, data, "", "  ")
+	fmt.Printf("[CLI] %s:\n%s\n", title, prettyJSON.String())
+}
+
+// parseParams - Extrae los parametros --param clave=valor de la linea de comandos
+// Entrada: args - argumentos restantes despues del archivo
+// Salida: mapa clave -> valor (string; el Master verifica el tipo declarado)
+func parseParams(args []string) map[string]interface{} {
+	params := make(map[string]interface{})
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--param" && i+1 < len(args):
+			i++
+			arg = args[i]
+		case strings.HasPrefix(arg, "--param="):
+			arg = strings.TrimPrefix(arg, "--param=")
+		default:
+			log.Fatalf("Argumento desconocido: %s", arg)
+		}
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || key == "" {
+			log.Fatalf("Parámetro inválido (se espera clave=valor): %s", arg)
+		}
+		params[key] = value
+	}
+	return params
 }
 
 // submitJob - Envia definicion de job al Master para ejecucion
-// Entrada: filePath - ruta al archivo JSON con la definicion del job
+// Entrada: filePath - ruta al archivo JSON con la definicion del job o plantilla,
+//
+//	params - valores para los placeholders ${param} de la plantilla
+//
 // Salida: ninguna (void), imprime respuesta del Master
 // Descripcion: Lee archivo JSON, lo envia via POST al endpoint /api/v1/jobs,
 //
-//	y muestra la respuesta formateada con el ID del job asignado.
-func submitJob(filePath string) {
+//	y muestra la respuesta formateada con el ID del job asignado. Si el
+//	archivo es una plantilla (o se pasan --param) se envia con sus parametros.
+func submitJob(filePath string, params map[string]interface{}) {
 	// Leer contenido del archivo JSON
 	jsonData, err := os.ReadFile(filePath)
 	if err != nil {
@@ -88,23 +223,14 @@ func submitJob(filePath string) {
 	}
 
 	fmt.Printf("[CLI] Enviando job desde %s...\n", filePath)
-	// Enviar peticion HTTP POST al Master
-	resp, err := http.Post(baseURL, "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		log.Fatalf("Error conectando con Master: %v", err)
-	}
-	defer resp.Body.Close()
-
-	// Leer respuesta del servidor
-	body, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode != http.StatusOK {
-		log.Fatalf("Error del Master (%d): %s", resp.StatusCode, string(body))
+	var jobID string
+	if len(params) > 0 || jobtemplate.IsTemplate(jsonData) {
+		jobID, err = client.SubmitTemplate(context.Background(), jsonData, params)
+	} else {
+		jobID, err = client.SubmitJSON(context.Background(), jsonData)
 	}
-
-	// Formatear JSON de respuesta para mejor legibilidad
-	var prettyJSON bytes.Buffer
-	json.Indent(&prettyJSON, body, "", "  ")
-	fmt.Printf("[CLI] Respuesta:\n%s\n", prettyJSON.String())
+	exitOnError("Error enviando job", err)
+	printJSON("Respuesta", map[string]string{"job_id": jobID, "status": "ACCEPTED"})
 }
 
 // getJobStatus - Consulta estado actual y metricas de un job
@@ -114,23 +240,89 @@ func submitJob(filePath string) {
 //
 //	estado, progreso porcentual, nodos completados, fallos.
 func getJobStatus(jobID string) {
-	// Construir URL con ID del job
-	url := fmt.Sprintf("%s/%s", baseURL, jobID)
-	// Realizar consulta HTTP GET
-	resp, err := http.Get(url)
-	if err != nil {
-		log.Fatalf("Error consultando estado: %v", err)
-	}
-	defer resp.Body.Close()
+	st, err := client.Status(context.Background(), jobID)
+	exitOnError("Error consultando estado", err)
+	printJSON("Estado del Job "+jobID, st)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		log.Fatalf("Job no encontrado o error del servidor")
+// listJobs - Lista los jobs del Master
+// Entrada: status - estado a filtrar ("" = todos)
+// Salida: ninguna (void), imprime los jobs como JSON
+func listJobs(status string) {
+	jobs, err := client.Jobs(context.Background(), status)
+	exitOnError("Error consultando jobs", err)
+	printJSON(fmt.Sprintf("Jobs (%d)", len(jobs)), jobs)
+}
+
+// showJobEvents - Muestra el historial de eventos de un job
+// Entrada: jobID - UUID del job
+// Salida: ninguna (void), imprime los eventos en orden como JSON
+func showJobEvents(jobID string) {
+	ev, err := client.Events(context.Background(), jobID, 0)
+	exitOnError("Error consultando eventos", err)
+	printJSON(fmt.Sprintf("Eventos del Job %s (%d)", jobID, len(ev.Events)), ev)
+}
+
+// cancelJob - Cancela un job en ejecucion
+// Entrada: jobID - UUID del job
+// Salida: ninguna (void), imprime tareas quitadas/canceladas
+func cancelJob(jobID string) {
+	res, err := client.Cancel(context.Background(), jobID)
+	exitOnError("Error cancelando job", err)
+	printJSON("Job cancelado", res)
+}
+
+// listWorkers - Lista los workers registrados en el Master
+// Entrada: ninguna
+// Salida: ninguna (void), imprime estado, metricas, tareas asignadas y fallos
+func listWorkers() {
+	workers, err := client.Workers(context.Background())
+	exitOnError("Error consultando workers", err)
+	printJSON(fmt.Sprintf("Workers registrados (%d)", len(workers)), workers)
+}
+
+// showWorker - Muestra el detalle de un worker
+// Entrada: workerID - UUID del worker
+// Salida: ninguna (void), imprime tareas asignadas e historial de metricas
+func showWorker(workerID string) {
+	ws, err := client.Worker(context.Background(), workerID)
+	exitOnWorkerError("Error consultando worker", err)
+	printJSON("Worker "+workerID, ws)
+}
+
+// exitOnWorkerError - Como exitOnError, pero un 404 indica un worker inexistente
+func exitOnWorkerError(action string, err error) {
+	if errors.Is(err, minispark.ErrWorkerNotFound) {
+		log.Fatalf("%s: worker no encontrado", action)
 	}
+	exitOnError(action, err)
+}
 
-	body, _ := io.ReadAll(resp.Body)
-	var prettyJSON bytes.Buffer
-	json.Indent(&prettyJSON, body, "", "  ")
-	fmt.Printf("[CLI] Estado del Job %s:\n%s\n", jobID, prettyJSON.String())
+// decommissionWorker - Inicia el retiro ordenado de un worker
+// Entrada: workerID - UUID del worker
+// Salida: ninguna (void), imprime el estado del worker (DRAINING)
+func decommissionWorker(workerID string) {
+	ws, err := client.Decommission(context.Background(), workerID)
+	exitOnWorkerError("Error en decommission", err)
+	printJSON("Worker en decommission", ws)
+}
+
+// showQueue - Muestra la cola de tareas pendientes del Master
+// Entrada: ninguna
+// Salida: ninguna (void), imprime la cola como JSON
+func showQueue() {
+	q, err := client.Queue(context.Background())
+	exitOnError("Error consultando cola", err)
+	printJSON(fmt.Sprintf("Cola de tareas (%d pendientes)", q.Length), q)
+}
+
+// showScaling - Muestra las señales y la ultima accion del escalado dinamico
+// Entrada: ninguna
+// Salida: ninguna (void), imprime el estado como JSON
+func showScaling() {
+	st, err := client.Scaling(context.Background())
+	exitOnError("Error consultando escalado", err)
+	printJSON("Escalado de workers (scaler: "+st.Scaler+")", st)
 }
 
 // getJobResults - Descarga rutas de archivos de salida de un job
@@ -140,17 +332,67 @@ func getJobStatus(jobID string) {
 //
 //	mapa de nodos finales (sink) y sus archivos de salida.
 func getJobResults(jobID string) {
-	// Construir URL para resultados
-	url := fmt.Sprintf("%s/%s/results", baseURL, jobID)
-	// Consultar resultados via GET
-	resp, err := http.Get(url)
-	if err != nil {
-		log.Fatalf("Error consultando resultados: %v", err)
+	res, err := client.Results(context.Background(), jobID)
+	exitOnError("Error consultando resultados", err)
+	printJSON("Resultados finales del Job "+jobID, res)
+}
+
+// streamNodeOutput - Imprime el contenido de la salida de un nodo
+// Entrada: jobID - identificador del job, nodeID - nodo a leer
+// Salida: ninguna (void), escribe las lineas en stdout
+func streamNodeOutput(jobID, nodeID string) {
+	err := client.StreamResults(context.Background(), jobID, nodeID, func(line string) error {
+		_, err := fmt.Println(line)
+		return err
+	})
+	exitOnError("Error leyendo salida", err)
+}
+
+// submitSQL - Envia una consulta SQL al Master para compilarla y ejecutarla
+// Entrada: sql - consulta SELECT, extra - [nombre] [paralelismo] opcionales
+// Salida: ninguna (void), imprime job_id
+// Descripcion: POST a /api/v1/sql; el Master compila la consulta a un DAG
+//
+//	sobre las tablas registradas y lo ejecuta como un job normal.
+func submitSQL(sql string, extra []string) {
+	req := common.SQLRequest{Query: sql}
+	if len(extra) > 0 {
+		req.Name = extra[0]
+	}
+	if len(extra) > 1 {
+		p, err := strconv.Atoi(extra[1])
+		if err != nil {
+			log.Fatalf("Paralelismo inválido: %s", extra[1])
+		}
+		req.Parallelism = p
 	}
-	defer resp.Body.Close()
 
-	body, _ := io.ReadAll(resp.Body)
-	var prettyJSON bytes.Buffer
-	json.Indent(&prettyJSON, body, "", "  ")
-	fmt.Printf("[CLI] Resultados finales del Job %s:\n%s\n", jobID, prettyJSON.String())
+	jobID, err := client.SubmitSQL(context.Background(), req)
+	exitOnError("Error enviando consulta", err)
+	printJSON("Consulta enviada", map[string]string{"job_id": jobID, "status": "ACCEPTED"})
+}
+
+// registerTable - Registra una tabla CSV/JSONL para consultas SQL
+// Entrada: name - nombre de tabla, path - archivo, cols - columnas separadas por coma,
+//
+//	extra - formato opcional (csv|jsonl)
+//
+// Salida: ninguna (void), imprime la tabla registrada
+func registerTable(name, path, cols string, extra []string) {
+	def := common.TableDef{Name: name, Path: path, Columns: strings.Split(cols, ",")}
+	if len(extra) > 0 {
+		def.Format = extra[0]
+	}
+	out, err := client.RegisterTable(context.Background(), def)
+	exitOnError("Error registrando tabla", err)
+	printJSON("Tabla registrada", out)
+}
+
+// listTables - Lista las tablas registradas en el Master
+// Entrada: ninguna
+// Salida: ninguna (void), imprime tablas en formato JSON
+func listTables() {
+	tables, err := client.Tables(context.Background())
+	exitOnError("Error consultando tablas", err)
+	printJSON("Tablas registradas", tables)
 }
@@ -10,35 +10,113 @@ Descripcion: Nodo coordinador principal del sistema Mini-Spark.
 package main
 
 import (
+	"flag"
 	"log"
 	"mini-spark/internal/master"
+	"mini-spark/internal/tracing"
 	"mini-spark/internal/utils"
 	"net/http"
+	"time"
 )
 
 // main - Punto de entrada del nodo Master
-// Entrada: ninguna
+// Entrada: flags --placement (politica de colocacion), --max-worker-mem (MB),
+//
+//	--locality-wait (espera de localidad de datos), --speculation,
+//	--speculation-multiplier (ejecucion especulativa), --task-timeout,
+//	--blacklist-failures, --blacklist-job-failures, --blacklist-cooldown,
+//	--pools (pools del scheduler con peso), --scaler, --scaler-worker-bin,
+//	--scaler-worker-slots, --min-workers, --max-workers, --scale-backlog,
+//	--scale-idle, --scale-cooldown (escalado dinamico de workers),
+//	--trace-export (destino de las trazas distribuidas),
+//	--max-finished-jobs (retencion de jobs terminados)
+//
 // Salida: ninguna (void), servidor HTTP bloqueante
 // Descripcion: Inicializa Master, registra endpoints HTTP, lanza
 //
 //	goroutines de background (health checks, scheduler)
 //	y arranca servidor en puerto 8080.
 func main() {
+	// Parsear politica de colocacion de tareas
+	placement := flag.String("placement", "resource", "Politica de colocacion: resource | round_robin")
+	maxWorkerMem := flag.Uint64("max-worker-mem", 0, "Memoria residente (MB) a partir de la cual un worker no recibe tareas (0 = sin limite)")
+	localityWait := flag.Duration("locality-wait", master.DefaultLocalityWait, "Espera por el worker con los datos de entrada antes de usar otro")
+	speculation := flag.Bool("speculation", true, "Lanzar intentos duplicados de tareas rezagadas")
+	specMultiplier := flag.Float64("speculation-multiplier", 1.5, "Rezagada si dura mas que N veces la mediana de su etapa")
+	taskTimeout := flag.Duration("task-timeout", master.DefaultTaskTimeout, "Timeout por defecto de cada tarea (0 = sin limite)")
+	blacklistFailures := flag.Int("blacklist-failures", 4, "Fallos de un worker antes de excluirlo del cluster (0 = nunca)")
+	blacklistJobFailures := flag.Int("blacklist-job-failures", 2, "Fallos de un worker en un job antes de excluirlo de ese job (0 = nunca)")
+	blacklistCooldown := flag.Duration("blacklist-cooldown", 5*time.Minute, "Duracion de la exclusion de un worker")
+	pools := flag.String("pools", "", "Pools del scheduler con peso, ej: interactive=3,batch=1 (siempre existe default=1)")
+	scaler := flag.String("scaler", "none", "Escalado dinamico de workers: none | local (procesos en esta maquina)")
+	scalerWorkerBin := flag.String("scaler-worker-bin", "bin/worker", "Ejecutable del worker para el scaler local")
+	scalerWorkerSlots := flag.Int("scaler-worker-slots", 0, "Slots de los workers lanzados por el scaler (0 = por defecto)")
+	minWorkers := flag.Int("min-workers", 1, "Minimo de workers UP con escalado dinamico")
+	maxWorkers := flag.Int("max-workers", 8, "Maximo de workers UP con escalado dinamico")
+	scaleBacklog := flag.Duration("scale-backlog", 30*time.Second, "Agregar workers si vaciar la cola tomaria mas que esto")
+	scaleIdle := flag.Duration("scale-idle", time.Minute, "Retirar workers sin tareas durante este tiempo")
+	scaleCooldown := flag.Duration("scale-cooldown", 30*time.Second, "Espera minima entre acciones de escalado")
+	traceExport := flag.String("trace-export", utils.GetEnv("TRACE_EXPORT", ""), "Destino de las trazas OTLP/JSON: archivo (file:<ruta>) o collector (http://host:4318); vacio = sin trazas")
+	maxFinishedJobs := flag.Int("max-finished-jobs", 0, "Jobs terminados que se conservan; los mas antiguos se olvidan con su estado, salidas e historial (0 = todos)")
+	flag.Parse()
+
 	// Crear instancia de Master con archivo de persistencia
 	m := master.NewMaster("master_state.json")
+	policy, err := master.NewPlacementPolicy(*placement, *maxWorkerMem*1024*1024)
+	if err != nil {
+		log.Fatal(err)
+	}
+	m.Placement = policy
+	if m.Pools, err = master.ParsePools(*pools); err != nil {
+		log.Fatal(err)
+	}
+	m.LocalityWait = *localityWait
+	m.Speculation.Enabled = *speculation
+	m.Speculation.Multiplier = *specMultiplier
+	m.TaskTimeout = *taskTimeout
+	m.Blacklist.MaxFailures = *blacklistFailures
+	m.Blacklist.MaxPerJob = *blacklistJobFailures
+	m.Blacklist.Cooldown = *blacklistCooldown
+	if m.Scaler, err = master.NewScaler(*scaler, *scalerWorkerBin, "http://localhost:8080", "logs", *scalerWorkerSlots); err != nil {
+		log.Fatal(err)
+	}
+	m.Scaling.MinWorkers = *minWorkers
+	m.Scaling.MaxWorkers = *maxWorkers
+	m.Scaling.TargetBacklog = *scaleBacklog
+	m.Scaling.IdleTimeout = *scaleIdle
+	m.Scaling.Cooldown = *scaleCooldown
+	exporter, err := tracing.NewExporter(*traceExport)
+	if err != nil {
+		log.Fatal(err)
+	}
+	m.Tracer = tracing.NewTracer("mini-spark-master", exporter)
+	m.MaxFinishedJobs = *maxFinishedJobs
 	// Recuperar estado previo (jobs completados, outputs)
 	m.LoadState()
 
 	// Registrar endpoints de API REST
 	http.HandleFunc("/register", m.RegisterHandler)          // Registro de workers
 	http.HandleFunc("/heartbeat", m.HeartbeatHandler)        // Heartbeats de workers
+	http.HandleFunc("/deregister", m.DeregisterHandler)      // Baja de workers drenados
+	http.HandleFunc("/api/v1/workers", m.ListWorkersHandler) // Estado y exclusiones de workers
+	http.HandleFunc("/api/v1/workers/", m.WorkerHandler)     // Detalle y decommission de un worker
 	http.HandleFunc("/api/v1/jobs", m.SubmitJobHandler)      // Envio de jobs
 	http.HandleFunc("/api/v1/jobs/", m.GetJobStatusHandler)  // Status/resultados
 	http.HandleFunc("/task/complete", m.CompleteTaskHandler) // Completado de tareas
+	http.HandleFunc("/api/v1/tables", m.TablesHandler)       // Registro de tablas SQL
+	http.HandleFunc("/api/v1/sql", m.SQLHandler)             // Consultas SQL -> DAG
+	http.HandleFunc("/api/v1/queue", m.QueueHandler)         // Cola de tareas pendientes
+	http.HandleFunc("/api/v1/scaling", m.ScalingHandler)     // Señales de escalado
+	http.Handle("/metrics", m.Metrics)                       // Metricas Prometheus
+	http.Handle("/ui/", master.UIHandler())                  // Interfaz web
 
 	// Lanzar loops de fondo en goroutines separadas
-	go m.HealthCheckLoop() // Monitoreo de workers caidos
-	go m.SchedulerLoop()   // Asignacion de tareas a workers
+	go m.HealthCheckLoop()                 // Monitoreo de workers caidos
+	go m.SchedulerLoop()                   // Asignacion de tareas a workers
+	go m.SpeculationLoop()                 // Intentos duplicados de tareas rezagadas
+	go m.TimeoutLoop()                     // Cancelacion de tareas colgadas
+	go m.ScalingLoop()                     // Escalado dinamico de workers
+	go m.Tracer.FlushLoop(2 * time.Second) // Exportacion de spans
 
 	utils.LogJSON("INFO", "Master iniciado", map[string]interface{}{"port": 8080})
 	log.Fatal(http.ListenAndServe(":8080", nil))
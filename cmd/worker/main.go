@@ -11,22 +11,47 @@ package main
 
 import (
 	"flag"
+	"fmt"
+	"log"
+	"mini-spark/internal/common"
+	"mini-spark/internal/tracing"
 	"mini-spark/internal/utils"
 	"mini-spark/internal/worker"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 // main - Punto de entrada del nodo Worker
-// Entrada: flags --port (puerto HTTP del worker)
+// Entrada: flags --port (puerto HTTP del worker), --slots (tareas concurrentes),
+//
+//	--advertise (direccion anunciada al Master), --labels (etiquetas),
+//	--trace-export (destino de las trazas distribuidas)
+//
 // Salida: ninguna (void), servidor HTTP bloqueante
 // Descripcion: Inicializa worker, se registra en Master,
 //
 //	arranca servidor HTTP para recibir tareas,
 //	y envia heartbeats periodicos con metricas.
+//	SIGTERM/SIGINT inician el retiro ordenado (decommission): el
+//	proceso termina cuando el worker drena y se desregistra.
 func main() {
 	// Parsear puerto del worker desde CLI
 	port := flag.Int("port", 9001, "Puerto del worker")
+	slots := flag.Int("slots", common.DefaultWorkerSlots, "Maximo de tareas concurrentes (se anuncia al Master)")
+	advertise := flag.String("advertise", utils.GetEnv("WORKER_ADDRESS", ""), "Direccion anunciada al Master (host:port); por defecto la IP de origen")
+	labelSpec := flag.String("labels", "", "Etiquetas del worker, ej: zone=a,disk=ssd")
+	traceExport := flag.String("trace-export", utils.GetEnv("TRACE_EXPORT", ""), "Destino de las trazas OTLP/JSON: archivo (file:<ruta>) o collector (http://host:4318); vacio = sin trazas")
 	flag.Parse()
+	labels, err := common.ParseLabels(*labelSpec)
+	if err != nil {
+		log.Fatal(err)
+	}
+	exporter, err := tracing.NewExporter(*traceExport)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	// Obtener URL del Master desde variable de entorno
 	masterURL := utils.GetEnv("MASTER_URL", "http://localhost:8080")
@@ -36,5 +61,31 @@ func main() {
 	os.MkdirAll(outputDir, 0755)
 
 	w := worker.NewWorker(*port, masterURL, outputDir)
-	w.Start()
+	w.SetSlots(*slots)
+	w.Address = *advertise
+	w.Labels = labels
+	w.Tracer = tracing.NewTracer("mini-spark-worker", exporter)
+	go w.Tracer.FlushLoop(2 * time.Second)
+	go w.Start()
+
+	// Apagado ordenado: misma ruta que el decommission pedido al Master
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGTERM, syscall.SIGINT)
+	select {
+	case <-sig:
+		fmt.Println("[WORKER] Señal de apagado recibida, iniciando decommission")
+		w.RequestDecommission()
+	case <-w.Drained():
+		// Decommission ordenado por el Master
+		w.Tracer.Flush()
+		return
+	}
+	// Una segunda señal fuerza la salida sin esperar a las tareas
+	select {
+	case <-w.Drained():
+		w.Tracer.Flush()
+	case <-sig:
+		fmt.Println("[WORKER] Segunda señal, saliendo sin drenar")
+		os.Exit(1)
+	}
 }
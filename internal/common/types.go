@@ -9,21 +9,146 @@ Descripcion: Definiciones de tipos y estructuras compartidas entre
 
 package common
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // Numero maximo de reintentos para tareas fallidas
 const (
 	MaxRetries = 3
+	// Slots de ejecucion asumidos para workers que no los reportan
+	DefaultWorkerSlots = 10
+	// Pool del scheduler para jobs que no indican uno
+	DefaultPool = "default"
+	// Version del protocolo Master-Worker; el Master rechaza workers con otra
+	ProtocolVersion = 2
+)
+
+// Version version del build (se fija con -ldflags "-X mini-spark/internal/common.Version=...")
+var Version = "dev"
+
+// Clasificacion de fallos reportada por el Worker en TaskResult.ErrorClass
+const (
+	ErrorClassRetryable = "RETRYABLE" // Fallo transitorio (I/O, red): se reintenta con backoff
+	ErrorClassFatal     = "FATAL"     // Tarea mal definida o entrada inexistente: el job falla de inmediato
 )
 
+// RetryPolicy politica de reintentos de tareas (por job o por nodo)
+// Los campos en 0 toman el valor de DefaultRetryPolicy
+type RetryPolicy struct {
+	MaxAttempts  int     `json:"max_attempts,omitempty"`   // Intentos totales (incluye el primero)
+	BackoffMs    int     `json:"backoff_ms,omitempty"`     // Espera antes del 2do intento; se duplica en cada reintento
+	MaxBackoffMs int     `json:"max_backoff_ms,omitempty"` // Tope de la espera
+	Jitter       float64 `json:"jitter,omitempty"`         // Variacion aleatoria relativa de la espera (0-1)
+}
+
+// DefaultRetryPolicy politica usada cuando el job/nodo no la define
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: MaxRetries, BackoffMs: 500, MaxBackoffMs: 30000, Jitter: 0.2}
+
+// Merge - Completa los campos vacios de p con los de base
+func (p RetryPolicy) Merge(base RetryPolicy) RetryPolicy {
+	if p.MaxAttempts == 0 {
+		p.MaxAttempts = base.MaxAttempts
+	}
+	if p.BackoffMs == 0 {
+		p.BackoffMs = base.BackoffMs
+	}
+	if p.MaxBackoffMs == 0 {
+		p.MaxBackoffMs = base.MaxBackoffMs
+	}
+	if p.Jitter == 0 {
+		p.Jitter = base.Jitter
+	}
+	return p
+}
+
 // --- Métricas y Observabilidad ---
 
 // SystemMetrics contiene datos de rendimiento del nodo
 // Usado para monitoreo de salud y scheduling inteligente
 type SystemMetrics struct {
-	CPUPercent  float64 `json:"cpu_usage"`    // Uso de CPU simulado o real (0-100)
-	MemoryUsage uint64  `json:"memory_usage"` // Bytes en uso (Alloc del runtime)
+	CPUPercent  float64 `json:"cpu_usage"`    // CPU del proceso worker (0-100, sobre todos los nucleos)
+	MemoryUsage uint64  `json:"memory_usage"` // Memoria residente (RSS) del proceso; heap de Go si no hay /proc
 	ActiveTasks int     `json:"active_tasks"` // Número de tareas ejecutando concurrentemente
+
+	HostCPUPercent float64   `json:"host_cpu_usage"`            // CPU de la maquina (0-100)
+	LoadAvg        []float64 `json:"load_avg,omitempty"`        // Carga media de 1, 5 y 15 minutos
+	DiskFreeBytes  uint64    `json:"disk_free_bytes,omitempty"` // Espacio libre en el directorio de salida
+	HeapBytes      uint64    `json:"heap_bytes"`                // Heap de Go en uso (Alloc)
+	Goroutines     int       `json:"goroutines"`                // Goroutines del proceso
+	NumCPU         int       `json:"num_cpu"`                   // Nucleos disponibles
+}
+
+// TaskUsage recursos consumidos por una tarea en el worker
+type TaskUsage struct {
+	WallSecs    float64 `json:"wall_secs"`    // Duracion del operador
+	CPUSecs     float64 `json:"cpu_secs"`     // CPU del hilo que ejecuto el operador (0 si no hay /proc)
+	InputBytes  int64   `json:"input_bytes"`  // Tamaño de los archivos de entrada
+	OutputBytes int64   `json:"output_bytes"` // Tamaño del archivo de salida
+	RSSBytes    uint64  `json:"rss_bytes"`    // RSS del proceso al terminar la tarea
+	RecordsIn   int64   `json:"records_in"`   // Registros (lineas) leidos
+	RecordsOut  int64   `json:"records_out"`  // Registros (lineas) escritos
+	SpillCount  int     `json:"spill_count"`  // Archivos de spill o runs ordenados escritos a disco
+}
+
+// PartitionMetrics metricas del intento ganador de una particion
+type PartitionMetrics struct {
+	Partition int    `json:"partition"`           // ID de particion
+	Attempt   int    `json:"attempt"`             // Intento que produjo la salida (1 = primero)
+	WorkerID  string `json:"worker_id,omitempty"` // Worker que ejecuto el intento
+	TaskUsage
+}
+
+// MetricsTotals sumas de las metricas de las tareas completadas
+type MetricsTotals struct {
+	Tasks       int     `json:"tasks"`        // Particiones con metricas
+	WallSecs    float64 `json:"wall_secs"`    // Suma de duraciones
+	CPUSecs     float64 `json:"cpu_secs"`     // Suma de CPU
+	RecordsIn   int64   `json:"records_in"`   // Registros leidos
+	RecordsOut  int64   `json:"records_out"`  // Registros escritos
+	InputBytes  int64   `json:"input_bytes"`  // Bytes leidos
+	OutputBytes int64   `json:"output_bytes"` // Bytes escritos
+	SpillCount  int     `json:"spill_count"`  // Spills a disco
+	Retries     int     `json:"retries"`      // Intentos previos descartados (Attempt - 1)
+}
+
+// Add - Suma las metricas de una particion
+func (t *MetricsTotals) Add(p PartitionMetrics) {
+	t.Tasks++
+	t.WallSecs += p.WallSecs
+	t.CPUSecs += p.CPUSecs
+	t.RecordsIn += p.RecordsIn
+	t.RecordsOut += p.RecordsOut
+	t.InputBytes += p.InputBytes
+	t.OutputBytes += p.OutputBytes
+	t.SpillCount += p.SpillCount
+	if p.Attempt > 1 {
+		t.Retries += p.Attempt - 1
+	}
+}
+
+// NodeMetrics metricas agregadas de una etapa (nodo del DAG)
+// Skew: cociente maximo/mediana entre particiones (1 = reparto uniforme)
+type NodeMetrics struct {
+	MetricsTotals
+	MaxInputBytes    int64              `json:"max_input_bytes"`    // Entrada de la particion mas grande
+	MedianInputBytes int64              `json:"median_input_bytes"` // Mediana de la entrada por particion
+	SizeSkew         float64            `json:"size_skew"`          // MaxInputBytes / MedianInputBytes
+	MaxWallSecs      float64            `json:"max_wall_secs"`      // Duracion de la particion mas lenta
+	MedianWallSecs   float64            `json:"median_wall_secs"`   // Mediana de la duracion por particion
+	TimeSkew         float64            `json:"time_skew"`          // MaxWallSecs / MedianWallSecs
+	LargestPartition int                `json:"largest_partition"`  // Particion con mas bytes de entrada
+	SlowestPartition int                `json:"slowest_partition"`  // Particion mas lenta
+	Partitions       []PartitionMetrics `json:"partitions"`         // Detalle por particion
+}
+
+// JobMetrics metricas agregadas de un job
+type JobMetrics struct {
+	MetricsTotals
+	MaxSizeSkew float64                `json:"max_size_skew"`         // Mayor SizeSkew entre etapas
+	SkewedNode  string                 `json:"skewed_node,omitempty"` // Etapa con el mayor SizeSkew
+	Nodes       map[string]NodeMetrics `json:"nodes"`                 // Metricas por nodo
 }
 
 // --- Estructuras de Coordinación ---
@@ -34,15 +159,80 @@ type WorkerInfo struct {
 	ID            string        `json:"id"`             // UUID del worker
 	URL           string        `json:"url"`            // Endpoint HTTP (http://host:port)
 	LastHeartbeat time.Time     `json:"last_heartbeat"` // Timestamp del ultimo heartbeat
-	Status        string        `json:"status"`         // "UP" o "DOWN"
+	Status        string        `json:"status"`         // "UP", "DRAINING" (decommission) o "DOWN"
 	Metrics       SystemMetrics `json:"metrics"`        // Metricas actuales del worker
+	Slots         int           `json:"slots"`          // Tareas concurrentes que acepta el worker
+
+	Version   string            `json:"version,omitempty"`   // Version del build (registro)
+	Operators []string          `json:"operators,omitempty"` // Operadores soportados (registro)
+	UDFs      []string          `json:"udfs,omitempty"`      // UDFs registradas (registro)
+	Labels    map[string]string `json:"labels,omitempty"`    // Etiquetas (registro)
+}
+
+// WorkerStatusResponse estado de un worker visto por el Master
+// Devuelto por GET /api/v1/workers; incluye fallos y exclusiones (blacklist)
+type WorkerStatusResponse struct {
+	WorkerInfo
+	Failures         int        `json:"failures"`                    // Fallos acumulados desde la ultima exclusion
+	BlacklistedUntil *time.Time `json:"blacklisted_until,omitempty"` // Excluido de todo el cluster hasta esta fecha
+	BlacklistedJobs  []string   `json:"blacklisted_jobs,omitempty"`  // Jobs de los que esta excluido
+	BusyUntil        *time.Time `json:"busy_until,omitempty"`        // Rechazo tareas por falta de slots: sin envios hasta esta fecha
+	AssignedTasks    int        `json:"assigned_tasks"`              // Tareas asignadas por el Master aun sin reportar
+}
+
+// WorkerDetailResponse detalle de un worker
+// Devuelto por GET /api/v1/workers/{id}
+type WorkerDetailResponse struct {
+	WorkerStatusResponse
+	Tasks          []WorkerTask    `json:"tasks"`           // Tareas asignadas (TaskAssignments) ordenadas por inicio
+	MetricsHistory []MetricsSample `json:"metrics_history"` // Metricas de los ultimos heartbeats (mas antigua primero)
+}
+
+// WorkerTask tarea asignada a un worker
+type WorkerTask struct {
+	TaskID      string  `json:"task_id"`
+	JobID       string  `json:"job_id"`
+	NodeID      string  `json:"node_id"`
+	PartitionID int     `json:"partition_id"`
+	Attempt     int     `json:"attempt"`
+	Speculative bool    `json:"speculative,omitempty"`
+	RunningSecs float64 `json:"running_secs"` // Tiempo desde la asignacion
+}
+
+// MetricsSample metricas de un heartbeat con su momento de recepcion
+type MetricsSample struct {
+	Time time.Time `json:"time"`
+	SystemMetrics
 }
 
 // RegisterRequest es el JSON que envía el worker al iniciar
 // para registrarse en el cluster
 type RegisterRequest struct {
-	ID   string `json:"id"`   // UUID autogenerado del worker
-	Port int    `json:"port"` // Puerto donde escucha el worker
+	ID    string `json:"id"`              // UUID autogenerado del worker
+	Port  int    `json:"port"`            // Puerto donde escucha el worker
+	Slots int    `json:"slots,omitempty"` // Maximo de tareas concurrentes (0 = DefaultWorkerSlots)
+
+	Address         string            `json:"address,omitempty"`   // Direccion anunciada (host:port o URL); vacio = IP de origen + Port
+	Version         string            `json:"version,omitempty"`   // Version del build del worker
+	ProtocolVersion int               `json:"protocol_version"`    // Debe coincidir con ProtocolVersion del Master
+	Operators       []string          `json:"operators,omitempty"` // Operadores que sabe ejecutar (nil = los del protocolo)
+	UDFs            []string          `json:"udfs,omitempty"`      // UDFs registradas "tipo:nombre" (nil = las del protocolo)
+	Labels          map[string]string `json:"labels,omitempty"`    // Etiquetas libres (zona, disco, ...)
+}
+
+// DeregisterRequest baja de un worker que termino de drenar (decommission)
+// Enviado a POST /deregister del Master antes de apagarse
+type DeregisterRequest struct {
+	ID string `json:"id"` // UUID del worker
+}
+
+// BusyResponse respuesta de un worker sin slots libres
+// Enviada con HTTP 429 por POST /task; el Master reencola la tarea en otro worker
+type BusyResponse struct {
+	WorkerID     string `json:"worker_id"`      // UUID del worker
+	Slots        int    `json:"slots"`          // Tareas concurrentes que acepta
+	ActiveTasks  int    `json:"active_tasks"`   // Tareas en ejecucion al rechazar
+	RetryAfterMs int    `json:"retry_after_ms"` // Espera sugerida antes de enviarle otra tarea
 }
 
 // HeartbeatRequest señal de vida con métricas
@@ -52,6 +242,13 @@ type HeartbeatRequest struct {
 	Metrics SystemMetrics `json:"metrics"` // Metricas actuales (CPU, memoria, tareas)
 }
 
+// CancelRequest orden del Master para abortar una tarea en un worker
+// Enviado a POST /task/cancel del worker (ej: tarea con timeout vencido)
+type CancelRequest struct {
+	TaskID string `json:"task_id"` // Tarea a cancelar
+	Reason string `json:"reason"`  // Motivo (timeout, intento perdedor)
+}
+
 // --- Estructuras de Jobs y Tareas ---
 
 // JobRequest mapea el JSON enviado por el cliente al submitir un job
@@ -59,6 +256,12 @@ type JobRequest struct {
 	Name        string `json:"name"`        // Nombre descriptivo del job
 	DAG         DAG    `json:"dag"`         // Grafo dirigido aciclico de operaciones
 	Parallelism int    `json:"parallelism"` // Nivel de paralelismo deseado 
+
+	TaskTimeoutSecs int          `json:"task_timeout_secs,omitempty"` // Timeout por tarea del job (0 = default del Master)
+	Retry           *RetryPolicy `json:"retry,omitempty"`             // Politica de reintentos del job
+
+	Pool     string `json:"pool,omitempty"`     // Pool del scheduler (vacio = DefaultPool)
+	Priority int    `json:"priority,omitempty"` // Prioridad dentro del pool (mayor se planifica primero)
 }
 
 // DAG representa el grafo de ejecucion del job
@@ -75,42 +278,173 @@ type DAGNode struct {
 	Path       string `json:"path,omitempty"`       // Ruta de archivo (para read_csv)
 	Partitions int    `json:"partitions,omitempty"` // Numero de particiones (no usado actualmente)
 	Key        string `json:"key,omitempty"`        // Columna clave (para join)
+
+	Columns    []string `json:"columns,omitempty"`    // Esquema opcional: nombres de columnas de las filas de entrada
+	GroupBy    []string `json:"group_by,omitempty"`   // Columnas de agrupacion (group_by_key, aggregate_by_key)
+	Aggregates []string `json:"aggregates,omitempty"` // Agregados: sum(col), count(*), min(col), max(col), avg(col)
+
+	PartitionBy []string `json:"partition_by,omitempty"` // Columnas de particion (top_n_by_key, window)
+	OrderBy     []string `json:"order_by,omitempty"`     // Orden dentro de la particion: "col" o "col desc"
+	Limit       int      `json:"limit,omitempty"`        // N filas por clave (top_n_by_key) o rango maximo (window)
+
+	Where  []string `json:"where,omitempty"`  // Condiciones AND para filter ("amount > 10", "region = 'norte'")
+	Select []string `json:"select,omitempty"` // Columnas a proyectar en map (en lugar de fn)
+
+	TimeoutSecs int          `json:"timeout_secs,omitempty"` // Timeout de las tareas de este nodo (prioridad sobre el del job)
+	Retry       *RetryPolicy `json:"retry,omitempty"`        // Politica de reintentos del nodo (prioridad sobre la del job)
+
+	Constraints *PlacementConstraints `json:"constraints,omitempty"` // Etiquetas de worker requeridas/preferidas para sus tareas
+}
+
+// PlacementConstraints restricciones de colocacion de las tareas de un nodo
+// Se comparan con las etiquetas (labels) que cada worker anuncia al registrarse
+type PlacementConstraints struct {
+	Required  map[string]string `json:"required,omitempty"`  // El worker debe tener todas (clave=valor)
+	Preferred map[string]string `json:"preferred,omitempty"` // Se prefieren los workers que tienen mas de estas
+}
+
+// Allows - true si las etiquetas cumplen todas las requeridas (nil no restringe)
+func (c *PlacementConstraints) Allows(labels map[string]string) bool {
+	if c == nil {
+		return true
+	}
+	for k, v := range c.Required {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Preference - Numero de etiquetas preferidas que cumplen las etiquetas dadas
+func (c *PlacementConstraints) Preference(labels map[string]string) int {
+	if c == nil {
+		return 0
+	}
+	n := 0
+	for k, v := range c.Preferred {
+		if labels[k] == v {
+			n++
+		}
+	}
+	return n
 }
 
 // Job representa un trabajo distribuido en ejecucion
 type Job struct {
-	ID        string    `json:"id"`                     // UUID del job
-	Name      string    `json:"name"`                   // Nombre descriptivo
-	Status    string    `json:"status"`                 // RUNNING | COMPLETED | FAILED
-	Graph     DAG       `json:"dag"`                    // DAG de operaciones
-	Submitted time.Time `json:"submitted_at"`           // Timestamp de envio
-	Completed time.Time `json:"completed_at,omitempty"` // Timestamp de finalizacion
-	Parallelism int       `json:"parallelism"`
+	ID              string       `json:"id"`                     // UUID del job
+	Name            string       `json:"name"`                   // Nombre descriptivo
+	Status          string       `json:"status"`                 // RUNNING | COMPLETED | FAILED | CANCELLED
+	Graph           DAG          `json:"dag"`                    // DAG de operaciones
+	Submitted       time.Time    `json:"submitted_at"`           // Timestamp de envio
+	Completed       time.Time    `json:"completed_at,omitempty"` // Timestamp de finalizacion
+	Parallelism     int          `json:"parallelism"`
+	TaskTimeoutSecs int          `json:"task_timeout_secs,omitempty"` // Timeout por tarea del job (0 = default del Master)
+	Retry           *RetryPolicy `json:"retry,omitempty"`             // Politica de reintentos del job
+	Error           string       `json:"error,omitempty"`             // Causa raiz si el job fallo
+	Pool            string       `json:"pool"`                        // Pool del scheduler
+	Priority        int          `json:"priority"`                    // Prioridad dentro del pool
+
+	Locality    LocalityStats          `json:"locality"`           // Aciertos de localidad de datos del scheduler
+	Speculation SpeculationStats       `json:"speculation"`        // Intentos especulativos lanzados/ganados
+	Params      map[string]interface{} `json:"params,omitempty"`   // Parametros usados si el job viene de una plantilla
+	Rendered    json.RawMessage        `json:"rendered,omitempty"` // JobRequest renderizado desde la plantilla
+	Trace       string                 `json:"trace,omitempty"`    // Contexto W3C (traceparent) del span raiz del job
+}
+
+// LocalityStats aciertos/fallos de localidad de un job
+// Solo cuentan tareas con particiones de entrada en un worker conocido
+type LocalityStats struct {
+	Hits    int     `json:"hits"`               // Tareas ejecutadas en el worker con sus entradas
+	Misses  int     `json:"misses"`             // Tareas ejecutadas en otro worker (espera agotada)
+	HitRate float64 `json:"hit_rate,omitempty"` // Hits / (Hits + Misses), calculado al consultar
+}
+
+// SpeculationStats intentos especulativos de un job
+type SpeculationStats struct {
+	Launched int `json:"launched"` // Copias lanzadas para tareas rezagadas
+	Won      int `json:"won"`      // Copias que terminaron antes que el original
 }
 
 // Task representa una unidad de trabajo asignada a un worker
 type Task struct {
-	ID         string   `json:"id"`          // UUID de la tarea
-	JobID      string   `json:"job_id"`      // Job al que pertenece
-	NodeID     string   `json:"node_id"`     // Nodo del DAG correspondiente
-	Op         string   `json:"op"`          // Operacion a ejecutar
-	Fn         string   `json:"fn"`          // Funcion UDF (si aplica)
-	Args       []string `json:"args"`        // Argumentos (ej: path de archivo)
-	InputFiles []string `json:"input_files"` // Archivos de entrada (outputs de nodos padre)
-	PartitionID     int      `json:"partition_id"`	// ID de particion 
+	ID              string   `json:"id"`               // UUID de la tarea
+	JobID           string   `json:"job_id"`           // Job al que pertenece
+	NodeID          string   `json:"node_id"`          // Nodo del DAG correspondiente
+	Op              string   `json:"op"`               // Operacion a ejecutar
+	Fn              string   `json:"fn"`               // Funcion UDF (si aplica)
+	Args            []string `json:"args"`             // Argumentos (ej: path de archivo)
+	InputFiles      []string `json:"input_files"`      // Archivos de entrada (outputs de nodos padre)
+	PartitionID     int      `json:"partition_id"`     // ID de particion
 	TotalPartitions int      `json:"total_partitions"` // Total particiones
-	Attempt    int      `json:"attempt"`     // Contador de reintentos (1-3)
+	Attempt         int      `json:"attempt"`          // Contador de reintentos (1-3)
+
+	PreferredWorkers []string              `json:"preferred_workers,omitempty"` // Workers con las particiones de entrada en disco local
+	QueuedAt         time.Time             `json:"queued_at"`                   // Momento en que se encolo (para la espera de localidad)
+	Speculative      bool                  `json:"speculative,omitempty"`       // Intento duplicado de una tarea rezagada
+	ExcludedWorkers  []string              `json:"excluded_workers,omitempty"`  // Workers donde no debe colocarse (ej: el del intento original)
+	TimeoutSecs      int                   `json:"timeout_secs,omitempty"`      // Timeout efectivo (nodo > job; 0 = default del Master)
+	Retry            RetryPolicy           `json:"retry"`                       // Politica de reintentos efectiva (nodo > job > default)
+	Constraints      *PlacementConstraints `json:"constraints,omitempty"`       // Etiquetas requeridas/preferidas (del nodo)
+	TraceParent      string                `json:"traceparent,omitempty"`       // Contexto W3C del span de la tarea (trazas distribuidas)
+
+	Columns    []string `json:"columns,omitempty"`    // Esquema de columnas del nodo (si aplica)
+	GroupBy    []string `json:"group_by,omitempty"`   // Columnas de agrupacion
+	Aggregates []string `json:"aggregates,omitempty"` // Expresiones de agregacion
+
+	PartitionBy []string `json:"partition_by,omitempty"` // Columnas de particion (ventanas)
+	OrderBy     []string `json:"order_by,omitempty"`     // Orden dentro de la particion
+	Limit       int      `json:"limit,omitempty"`        // Limite por clave
+
+	Where  []string `json:"where,omitempty"`  // Condiciones de filtro declarativas
+	Select []string `json:"select,omitempty"` // Proyeccion de columnas
 }
 
 // TaskResult mensaje enviado por worker al completar/fallar una tarea
 type TaskResult struct {
-	ID       string `json:"id"`                  // UUID de la tarea
-	JobID    string `json:"job_id"`              // Job al que pertenece
-	NodeID   string `json:"node_id"`             // Nodo del DAG
-	PartitionID int    `json:"partition_id"`				// ID de particion
-	Status   string `json:"status"`              // COMPLETED | FAILED
-	Result   string `json:"result"`              // Ruta del archivo de salida
-	ErrorMsg string `json:"error_msg,omitempty"` // Mensaje de error si fallo
+	ID          string     `json:"id"`                    // UUID de la tarea
+	JobID       string     `json:"job_id"`                // Job al que pertenece
+	NodeID      string     `json:"node_id"`               // Nodo del DAG
+	PartitionID int        `json:"partition_id"`          // ID de particion
+	Status      string     `json:"status"`                // COMPLETED | FAILED
+	Result      string     `json:"result"`                // Ruta del archivo de salida
+	ErrorMsg    string     `json:"error_msg,omitempty"`   // Mensaje de error si fallo
+	WorkerID    string     `json:"worker_id,omitempty"`   // Worker que ejecuto la tarea (dueño de la salida)
+	ErrorClass  string     `json:"error_class,omitempty"` // RETRYABLE | FATAL (si fallo)
+	Usage       *TaskUsage `json:"usage,omitempty"`       // Recursos consumidos por la tarea
+	Attempt     int        `json:"attempt,omitempty"`     // Numero de intento de la tarea (1 = primero)
+}
+
+// --- Front-end SQL ---
+
+// TableDef tabla registrada para consultas SQL
+// Registrada via POST /api/v1/tables
+type TableDef struct {
+	Name    string   `json:"name"`             // Nombre usado en FROM/JOIN
+	Path    string   `json:"path"`             // Ruta del archivo fuente
+	Format  string   `json:"format,omitempty"` // csv (default) | jsonl
+	Columns []string `json:"columns"`          // Esquema: nombres de columnas en orden
+}
+
+// TemplateParam declaracion tipada de un parametro de plantilla de job
+// Usado en el campo "params" de las plantillas (jobs/templates/*.json)
+type TemplateParam struct {
+	Type    string      `json:"type"`              // string | int | float | bool
+	Default interface{} `json:"default,omitempty"` // Valor por defecto (sin default es obligatorio)
+}
+
+// TemplateSubmitRequest envio de un job a partir de una plantilla
+// Enviado a POST /api/v1/jobs en lugar de un JobRequest
+type TemplateSubmitRequest struct {
+	Template json.RawMessage        `json:"template"` // Job con placeholders ${param} y declaracion "params"
+	Params   map[string]interface{} `json:"params"`   // Valores de los parametros
+}
+
+// SQLRequest consulta enviada a POST /api/v1/sql
+type SQLRequest struct {
+	Query       string `json:"query"`                 // Consulta SELECT
+	Name        string `json:"name,omitempty"`        // Nombre del job generado
+	Parallelism int    `json:"parallelism,omitempty"` // Paralelismo del job generado (solo 1: no hay shuffle)
 }
 
 // --- Respuestas de API ---
@@ -126,6 +460,117 @@ type JobStatusResponse struct {
 	Progress     float64           `json:"progress_percent"` // Porcentaje de avance (0-100)
 	NodeStatus   map[string]string `json:"node_status"`      // Estado por nodo: PENDING|SCHEDULED|COMPLETED
 	Failures     int               `json:"failure_count"`    // Contador total de fallos
+	Error        string            `json:"error,omitempty"`  // Causa raiz si el job fallo
+	Pool         string            `json:"pool"`             // Pool del scheduler
+	Priority     int               `json:"priority"`         // Prioridad dentro del pool
+
+	Params map[string]interface{} `json:"params,omitempty"` // Parametros de la plantilla (si aplica)
+
+	Locality    *LocalityStats    `json:"locality,omitempty"`    // Tasa de aciertos de localidad de datos
+	Speculation *SpeculationStats `json:"speculation,omitempty"` // Ejecucion especulativa (si hubo)
+	Metrics     *JobMetrics       `json:"metrics,omitempty"`     // Metricas de las tareas completadas por nodo
+	TraceID     string            `json:"trace_id,omitempty"`    // Traza del job en el exporter OTLP
+
+	DAG        *DAG                `json:"dag,omitempty"`        // Grafo del job (solo consulta de un job)
+	Partitions map[string][]string `json:"partitions,omitempty"` // Estado por particion de cada nodo (indice = particion)
+}
+
+// JobListResponse jobs del Master, mas recientes primero
+// Devuelto por GET /api/v1/jobs
+type JobListResponse struct {
+	Jobs []JobStatusResponse `json:"jobs"`
+}
+
+// Tipos de evento del historial de un job
+const (
+	EventJobSubmitted    = "JOB_SUBMITTED"    // Job aceptado por el Master
+	EventJobCompleted    = "JOB_COMPLETED"    // Todas las particiones completadas
+	EventJobFailed       = "JOB_FAILED"       // Fallo no recuperable (ver Detail)
+	EventJobCancelled    = "JOB_CANCELLED"    // Cancelado por el usuario
+	EventTaskQueued      = "TASK_QUEUED"      // Particion lista, en la cola del scheduler
+	EventTaskAssigned    = "TASK_ASSIGNED"    // Scheduler eligio un worker
+	EventTaskStarted     = "TASK_STARTED"     // Worker acepto la tarea y la ejecuta
+	EventTaskRejected    = "TASK_REJECTED"    // Worker sin slots o drenando: vuelve a la cola
+	EventTaskCompleted   = "TASK_COMPLETED"   // Resultado aceptado
+	EventTaskFailed      = "TASK_FAILED"      // Intento fallido (ver Detail)
+	EventTaskTimedOut    = "TASK_TIMED_OUT"   // Intento cancelado por timeout
+	EventTaskRetried     = "TASK_RETRIED"     // Nuevo intento programado tras un fallo
+	EventTaskRescheduled = "TASK_RESCHEDULED" // Reencolada porque su worker murio
+	EventTaskSpeculated  = "TASK_SPECULATED"  // Copia especulativa de una tarea rezagada
+)
+
+// JobEvent evento del historial de un job (linea de tiempo)
+type JobEvent struct {
+	Seq       int       `json:"seq"`                 // Orden de registro dentro del job (desde 1)
+	Time      time.Time `json:"time"`                // Momento del evento
+	Type      string    `json:"type"`                // Tipo (Event*)
+	NodeID    string    `json:"node_id,omitempty"`   // Nodo del DAG (eventos de tarea)
+	Partition int       `json:"partition"`           // Particion (eventos de tarea)
+	TaskID    string    `json:"task_id,omitempty"`   // Intento de la tarea
+	Attempt   int       `json:"attempt,omitempty"`   // Numero de intento
+	WorkerID  string    `json:"worker_id,omitempty"` // Worker involucrado
+	Detail    string    `json:"detail,omitempty"`    // Error, motivo o dato adicional
+}
+
+// JobEventsResponse historial de un job
+// Devuelto por GET /api/v1/jobs/{id}/events
+type JobEventsResponse struct {
+	JobID  string     `json:"job_id"` // UUID del job
+	Status string     `json:"status"` // Estado actual del job
+	Events []JobEvent `json:"events"` // Eventos en orden de registro
+}
+
+// CancelJobResponse resultado de POST /api/v1/jobs/{id}/cancel
+type CancelJobResponse struct {
+	JobID            string `json:"job_id"`            // UUID del job
+	Status           string `json:"status"`            // CANCELLED
+	RemovedPending   int    `json:"removed_pending"`   // Tareas quitadas de la cola
+	CancelledRunning int    `json:"cancelled_running"` // Tareas en ejecucion canceladas en sus workers
+}
+
+// QueuedTask tarea a la espera de un worker
+type QueuedTask struct {
+	TaskID      string  `json:"task_id"`
+	JobID       string  `json:"job_id"`
+	NodeID      string  `json:"node_id"`
+	PartitionID int     `json:"partition_id"`
+	Attempt     int     `json:"attempt"`
+	Pool        string  `json:"pool"`
+	Priority    int     `json:"priority"`
+	Speculative bool    `json:"speculative,omitempty"`
+	WaitSecs    float64 `json:"wait_secs"` // Tiempo desde que la tarea fue encolada
+}
+
+// QueueStatusResponse vista de la cola de tareas pendientes
+// Devuelto por GET /api/v1/queue (en orden de la cola)
+type QueueStatusResponse struct {
+	Length int            `json:"length"` // Tareas pendientes
+	ByJob  map[string]int `json:"by_job"` // Tareas pendientes por job
+	Tasks  []QueuedTask   `json:"tasks"`  // Detalle de cada tarea
+}
+
+// ScalingSignals señales de escalado calculadas por el Master
+// Base de las decisiones del scaler (ver master.Scaler)
+type ScalingSignals struct {
+	PendingTasks int      `json:"pending_tasks"`          // Tareas en cola a la espera de un worker
+	RunningTasks int      `json:"running_tasks"`          // Tareas asignadas aun sin reportar
+	Workers      int      `json:"workers"`                // Workers UP
+	TotalSlots   int      `json:"total_slots"`            // Slots de los workers UP
+	FreeSlots    int      `json:"free_slots"`             // Slots sin tarea asignada
+	IdleWorkers  []string `json:"idle_workers"`           // Workers UP sin tareas durante al menos el idle timeout
+	AvgTaskSecs  float64  `json:"avg_task_secs"`          // Duracion media de las tareas terminadas
+	BacklogSecs  float64  `json:"estimated_backlog_secs"` // Tiempo estimado para vaciar la cola con los slots actuales
+}
+
+// ScalingStatusResponse estado del escalado dinamico de workers
+// Devuelto por GET /api/v1/scaling
+type ScalingStatusResponse struct {
+	Scaler       string         `json:"scaler"`                   // Implementacion activa ("none" si no hay)
+	MinWorkers   int            `json:"min_workers"`              // Minimo de workers UP
+	MaxWorkers   int            `json:"max_workers"`              // Maximo de workers UP
+	Signals      ScalingSignals `json:"signals"`                  // Señales actuales
+	LastAction   string         `json:"last_action,omitempty"`    // Ultima accion (ej: "scale_up 2")
+	LastActionAt *time.Time     `json:"last_action_at,omitempty"` // Momento de la ultima accion
 }
 
 // JobResultsResponse para la descarga de resultados finales
@@ -0,0 +1,212 @@
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: validate.go
+Descripcion: Validacion estructural de JobRequests y DAGs.
+             Verifica IDs unicos, operadores soportados, aristas hacia
+             nodos existentes, ausencia de ciclos y aridad de cada
+             operador antes de aceptar un job en el Master o en el SDK.
+*/
+
+package common
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SupportedOps operadores que el Worker sabe ejecutar
+var SupportedOps = map[string]bool{
+	"read_csv":         true,
+	"read_jsonl":       true,
+	"map":              true,
+	"flat_map":         true,
+	"filter":           true,
+	"reduce_by_key":    true,
+	"aggregate_by_key": true,
+	"group_by_key":     true,
+	"top_n_by_key":     true,
+	"window":           true,
+	"join":             true,
+}
+
+// SupportedOpNames - Operadores de SupportedOps ordenados (se anuncian al registrarse)
+func SupportedOpNames() []string {
+	names := make([]string, 0, len(SupportedOps))
+	for op := range SupportedOps {
+		names = append(names, op)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsSourceOp - true si el operador lee datos externos (sin padres)
+func IsSourceOp(op string) bool {
+	return op == "read_csv" || op == "read_jsonl"
+}
+
+// ValidateJobRequest - Valida un JobRequest completo
+// Entrada: req - job a validar
+// Salida: error descriptivo con el primer problema encontrado, o nil
+func ValidateJobRequest(req JobRequest) error {
+	if req.Parallelism < 0 {
+		return fmt.Errorf("parallelism no puede ser negativo")
+	}
+	if req.TaskTimeoutSecs < 0 {
+		return fmt.Errorf("task_timeout_secs no puede ser negativo")
+	}
+	if err := ValidateRetryPolicy(req.Retry); err != nil {
+		return fmt.Errorf("retry: %v", err)
+	}
+	return ValidateDAG(req.DAG)
+}
+
+// ValidateDAG - Valida la estructura de un DAG
+// Entrada: dag - grafo a validar
+// Salida: error si hay IDs duplicados, operadores desconocidos,
+//
+//	aristas invalidas, ciclos o nodos con aridad incorrecta
+func ValidateDAG(dag DAG) error {
+	if len(dag.Nodes) == 0 {
+		return fmt.Errorf("el DAG no tiene nodos")
+	}
+
+	nodes := make(map[string]DAGNode, len(dag.Nodes))
+	for _, n := range dag.Nodes {
+		if n.ID == "" {
+			return fmt.Errorf("nodo sin id (op %s)", n.Op)
+		}
+		if _, dup := nodes[n.ID]; dup {
+			return fmt.Errorf("id de nodo duplicado: %s", n.ID)
+		}
+		if !SupportedOps[n.Op] {
+			return fmt.Errorf("nodo %s: operacion desconocida: %s", n.ID, n.Op)
+		}
+		if n.TimeoutSecs < 0 {
+			return fmt.Errorf("nodo %s: timeout_secs no puede ser negativo", n.ID)
+		}
+		if err := ValidateRetryPolicy(n.Retry); err != nil {
+			return fmt.Errorf("nodo %s: retry: %v", n.ID, err)
+		}
+		if IsSourceOp(n.Op) && n.Path == "" {
+			return fmt.Errorf("nodo %s: %s requiere path", n.ID, n.Op)
+		}
+		if err := validateConstraints(n.Constraints); err != nil {
+			return fmt.Errorf("nodo %s: constraints: %v", n.ID, err)
+		}
+		nodes[n.ID] = n
+	}
+
+	parents := make(map[string]int)
+	children := make(map[string][]string)
+	for _, e := range dag.Edges {
+		if len(e) != 2 {
+			return fmt.Errorf("arista invalida: %v", e)
+		}
+		if _, ok := nodes[e[0]]; !ok {
+			return fmt.Errorf("arista %s -> %s: nodo origen inexistente", e[0], e[1])
+		}
+		if _, ok := nodes[e[1]]; !ok {
+			return fmt.Errorf("arista %s -> %s: nodo destino inexistente", e[0], e[1])
+		}
+		if e[0] == e[1] {
+			return fmt.Errorf("arista %s -> %s: ciclo sobre si mismo", e[0], e[1])
+		}
+		parents[e[1]]++
+		children[e[0]] = append(children[e[0]], e[1])
+	}
+
+	for _, n := range dag.Nodes {
+		switch {
+		case IsSourceOp(n.Op) && parents[n.ID] > 0:
+			return fmt.Errorf("nodo %s: %s no admite nodos padre", n.ID, n.Op)
+		case !IsSourceOp(n.Op) && parents[n.ID] == 0:
+			return fmt.Errorf("nodo %s: %s requiere al menos un nodo padre", n.ID, n.Op)
+		case n.Op == "join" && parents[n.ID] != 2:
+			return fmt.Errorf("nodo %s: join requiere exactamente 2 padres", n.ID)
+		}
+	}
+
+	// Deteccion de ciclos (Kahn): todos los nodos deben poder ordenarse
+	inDegree := make(map[string]int, len(parents))
+	for k, v := range parents {
+		inDegree[k] = v
+	}
+	var ready []string
+	for _, n := range dag.Nodes {
+		if inDegree[n.ID] == 0 {
+			ready = append(ready, n.ID)
+		}
+	}
+	visited := 0
+	for len(ready) > 0 {
+		id := ready[0]
+		ready = ready[1:]
+		visited++
+		for _, c := range children[id] {
+			inDegree[c]--
+			if inDegree[c] == 0 {
+				ready = append(ready, c)
+			}
+		}
+	}
+	if visited != len(dag.Nodes) {
+		return fmt.Errorf("el DAG contiene ciclos")
+	}
+	return nil
+}
+
+// validateConstraints - Valida las restricciones de colocacion de un nodo
+// Salida: error si alguna etiqueta tiene clave vacia
+func validateConstraints(c *PlacementConstraints) error {
+	if c == nil {
+		return nil
+	}
+	for _, labels := range []map[string]string{c.Required, c.Preferred} {
+		for k := range labels {
+			if strings.TrimSpace(k) == "" {
+				return fmt.Errorf("etiqueta con clave vacia")
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateRetryPolicy - Valida los valores de una politica de reintentos
+// Entrada: p - politica (nil es valido: se usa la del job o la default)
+// Salida: error si hay valores negativos o jitter fuera de [0, 1]
+func ValidateRetryPolicy(p *RetryPolicy) error {
+	if p == nil {
+		return nil
+	}
+	if p.MaxAttempts < 0 || p.BackoffMs < 0 || p.MaxBackoffMs < 0 {
+		return fmt.Errorf("max_attempts, backoff_ms y max_backoff_ms no pueden ser negativos")
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return fmt.Errorf("jitter debe estar entre 0 y 1")
+	}
+	return nil
+}
+
+// ParseLabels - Interpreta una lista de etiquetas de worker
+// Entrada: spec - "clave=valor" separados por comas (ej: "zone=a,disk=ssd")
+// Salida: mapa clave -> valor (nil si spec esta vacio) o error de formato
+func ParseLabels(spec string) (map[string]string, error) {
+	var labels map[string]string
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(entry, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("etiqueta invalida %q (formato clave=valor)", entry)
+		}
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		labels[key] = strings.TrimSpace(value)
+	}
+	return labels, nil
+}
@@ -0,0 +1,254 @@
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: template.go
+Descripcion: Plantillas de jobs con sustitucion de parametros.
+             Una plantilla es un JobRequest JSON con placeholders
+             ${param} y una declaracion tipada "params". Los valores
+             se validan contra su tipo y se sustituyen sobre el arbol
+             JSON antes de decodificar y validar el DAG.
+*/
+
+package jobtemplate
+
+import (
+	"encoding/json"
+	"fmt"
+	"mini-spark/internal/common"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// placeholder patron de parametro dentro de un string: ${nombre}
+var placeholder = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// Render - Renderiza una plantilla con los parametros dados
+// Entrada: tmpl - JSON de la plantilla, params - valores (string o tipos JSON)
+// Salida: JobRequest renderizado, JSON renderizado (sin "params") y error
+// Descripcion: 1. Lee la declaracion "params" y resuelve cada valor
+//
+//	(param explicito > default) verificando su tipo.
+//	2. Sustituye placeholders: un string que es exactamente "${p}" toma
+//	el valor tipado (ej: parallelism numerico); dentro de texto se
+//	inserta su representacion en string.
+//	3. Decodifica el resultado como JobRequest (la validacion del DAG
+//	se hace despues, en el Master o en el SDK).
+func Render(tmpl []byte, params map[string]interface{}) (common.JobRequest, []byte, error) {
+	var req common.JobRequest
+
+	var root map[string]interface{}
+	if err := json.Unmarshal(tmpl, &root); err != nil {
+		return req, nil, fmt.Errorf("plantilla JSON inválida: %v", err)
+	}
+
+	decls, err := parseDecls(root["params"])
+	if err != nil {
+		return req, nil, err
+	}
+	delete(root, "params")
+
+	values, err := resolve(decls, params)
+	if err != nil {
+		return req, nil, err
+	}
+
+	rendered, err := substitute(root, values)
+	if err != nil {
+		return req, nil, err
+	}
+
+	data, err := json.Marshal(rendered)
+	if err != nil {
+		return req, nil, err
+	}
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		return req, nil, fmt.Errorf("plantilla renderizada no es un job válido: %v", err)
+	}
+	return req, data, nil
+}
+
+// IsTemplate - true si el JSON declara parametros o contiene placeholders
+func IsTemplate(data []byte) bool {
+	var root map[string]json.RawMessage
+	if err := json.Unmarshal(data, &root); err != nil {
+		return false
+	}
+	if _, ok := root["params"]; ok {
+		return true
+	}
+	return placeholder.Match(data)
+}
+
+// parseDecls - Decodifica la declaracion "params" de la plantilla
+func parseDecls(raw interface{}) (map[string]common.TemplateParam, error) {
+	decls := make(map[string]common.TemplateParam)
+	if raw == nil {
+		return decls, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &decls); err != nil {
+		return nil, fmt.Errorf("declaración de params inválida: %v", err)
+	}
+	for name, d := range decls {
+		if d.Type == "" {
+			d.Type = "string"
+			decls[name] = d
+		}
+		switch d.Type {
+		case "string", "int", "float", "bool":
+		default:
+			return nil, fmt.Errorf("param %s: tipo desconocido %q", name, d.Type)
+		}
+		if d.Default != nil {
+			if _, err := coerce(d.Type, d.Default); err != nil {
+				return nil, fmt.Errorf("param %s: default inválido: %v", name, err)
+			}
+		}
+	}
+	return decls, nil
+}
+
+// resolve - Combina valores explicitos y defaults verificando tipos
+// Salida: mapa nombre -> valor tipado, o error por param desconocido,
+//
+//	faltante o con tipo incorrecto
+func resolve(decls map[string]common.TemplateParam, params map[string]interface{}) (map[string]interface{}, error) {
+	values := make(map[string]interface{}, len(decls))
+	for name := range params {
+		if _, ok := decls[name]; !ok {
+			return nil, fmt.Errorf("param desconocido: %s", name)
+		}
+	}
+
+	// Orden estable para que el primer error reportado sea determinista
+	names := make([]string, 0, len(decls))
+	for name := range decls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		d := decls[name]
+		raw, ok := params[name]
+		if !ok {
+			raw = d.Default
+		}
+		if raw == nil {
+			return nil, fmt.Errorf("falta el param obligatorio: %s", name)
+		}
+		v, err := coerce(d.Type, raw)
+		if err != nil {
+			return nil, fmt.Errorf("param %s: %v", name, err)
+		}
+		values[name] = v
+	}
+	return values, nil
+}
+
+// coerce - Convierte un valor al tipo declarado
+// Entrada: typ - string|int|float|bool, v - string (CLI) o valor JSON
+// Salida: valor tipado o error si no es convertible
+func coerce(typ string, v interface{}) (interface{}, error) {
+	s, isString := v.(string)
+	switch typ {
+	case "string":
+		if isString {
+			return s, nil
+		}
+		return fmt.Sprint(v), nil
+	case "int":
+		if isString {
+			n, err := strconv.Atoi(strings.TrimSpace(s))
+			if err != nil {
+				return nil, fmt.Errorf("se esperaba int, obtenido %q", s)
+			}
+			return n, nil
+		}
+		if f, ok := v.(float64); ok && f == float64(int(f)) {
+			return int(f), nil
+		}
+		if n, ok := v.(int); ok {
+			return n, nil
+		}
+	case "float":
+		if isString {
+			f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+			if err != nil {
+				return nil, fmt.Errorf("se esperaba float, obtenido %q", s)
+			}
+			return f, nil
+		}
+		switch n := v.(type) {
+		case float64:
+			return n, nil
+		case int:
+			return float64(n), nil
+		}
+	case "bool":
+		if isString {
+			b, err := strconv.ParseBool(strings.TrimSpace(s))
+			if err != nil {
+				return nil, fmt.Errorf("se esperaba bool, obtenido %q", s)
+			}
+			return b, nil
+		}
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("se esperaba %s, obtenido %v", typ, v)
+}
+
+// substitute - Reemplaza placeholders recorriendo el arbol JSON
+func substitute(node interface{}, values map[string]interface{}) (interface{}, error) {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		for k, v := range n {
+			out, err := substitute(v, values)
+			if err != nil {
+				return nil, err
+			}
+			n[k] = out
+		}
+		return n, nil
+	case []interface{}:
+		for i, v := range n {
+			out, err := substitute(v, values)
+			if err != nil {
+				return nil, err
+			}
+			n[i] = out
+		}
+		return n, nil
+	case string:
+		// Placeholder completo: conserva el tipo del parametro
+		if m := placeholder.FindStringSubmatch(n); m != nil && m[0] == n {
+			v, ok := values[m[1]]
+			if !ok {
+				return nil, fmt.Errorf("placeholder sin declarar: %s", m[0])
+			}
+			return v, nil
+		}
+		var missing string
+		out := placeholder.ReplaceAllStringFunc(n, func(ph string) string {
+			name := ph[2 : len(ph)-1]
+			v, ok := values[name]
+			if !ok {
+				missing = ph
+				return ph
+			}
+			return fmt.Sprint(v)
+		})
+		if missing != "" {
+			return nil, fmt.Errorf("placeholder sin declarar: %s", missing)
+		}
+		return out, nil
+	}
+	return node, nil
+}
@@ -12,10 +12,18 @@ package master
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"mini-spark/internal/common"
+	"mini-spark/internal/jobtemplate"
+	"mini-spark/internal/operators"
+	"mini-spark/internal/query"
+	"mini-spark/internal/tracing"
 	"mini-spark/internal/utils"
 	"net"
 	"net/http"
+	"net/url"
+	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -24,45 +32,134 @@ import (
 
 // RegisterHandler - Registra un worker en el cluster
 // Entrada: w - response writer, r - request con RegisterRequest JSON
-// Salida: HTTP 200 OK o 400 Bad Request
-// Descripcion: Procesa solicitud de registro de worker, extrae IP remota,
+// Salida: HTTP 200 OK, 400 Bad Request (JSON o address invalidos) o
+//
+//	409 Conflict si el worker es incompatible (ver workerIncompatibility)
 //
-//	construye URL del worker y lo agrega al pool de workers.
-//	Inicializa estado UP y timestamp de heartbeat.
+// Descripcion: Procesa solicitud de registro de worker. Usa la direccion
+//
+//	anunciada por el worker o, si no la envia, construye la URL con la IP
+//	remota y el puerto. Guarda version, operadores, UDFs, slots y
+//	etiquetas. Inicializa estado UP y timestamp de heartbeat.
 func (m *Master) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 	var req common.RegisterRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Bad Request", http.StatusBadRequest)
 		return
 	}
+	if reason := workerIncompatibility(req); reason != "" {
+		utils.LogJSON("WARN", "Worker incompatible rechazado", map[string]interface{}{
+			"worker_id": req.ID,
+			"version":   req.Version,
+			"reason":    reason,
+		})
+		http.Error(w, "Worker incompatible: "+reason, http.StatusConflict)
+		return
+	}
 
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	// Extraer IP del cliente desde RemoteAddr
-	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	workerURL, err := advertisedURL(req.Address)
 	if err != nil {
-		host = "localhost"
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
-	// Normalizar localhost IPv6/IPv4
-	if host == "::1" || host == "127.0.0.1" {
-		host = "localhost"
+	if workerURL == "" {
+		// Extraer IP del cliente desde RemoteAddr
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = "localhost"
+		}
+		// Normalizar localhost IPv6/IPv4
+		if host == "::1" || host == "127.0.0.1" {
+			host = "localhost"
+		}
+		// Construir URL completa del worker
+		workerURL = fmt.Sprintf("http://%s:%d", host, req.Port)
 	}
 
-	// Construir URL completa del worker
-	workerURL := fmt.Sprintf("http://%s:%d", host, req.Port)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	// Registrar worker en mapa global
 	m.Workers[req.ID] = &common.WorkerInfo{
 		ID:            req.ID,
 		URL:           workerURL,
 		LastHeartbeat: time.Now(),
 		Status:        "UP",
+		Slots:         req.Slots,
+		Version:       req.Version,
+		Operators:     req.Operators,
+		UDFs:          req.UDFs,
+		Labels:        req.Labels,
 	}
 
-	utils.LogJSON("INFO", "Worker registrado", map[string]interface{}{"worker_id": req.ID, "url": workerURL})
+	utils.LogJSON("INFO", "Worker registrado", map[string]interface{}{
+		"worker_id": req.ID,
+		"url":       workerURL,
+		"slots":     req.Slots,
+		"version":   req.Version,
+		"labels":    req.Labels,
+	})
+	m.notifySlotFreed()
 	w.WriteHeader(http.StatusOK)
 }
 
+// workerIncompatibility - Motivo por el que un worker no puede unirse
+// Entrada: req - registro del worker
+// Salida: "" si es compatible; si no, el motivo
+// Descripcion: La version de protocolo debe coincidir (un build anterior
+//
+//	no la envia) y, si el worker declara operadores o UDFs, debe soportar
+//	todos los que conoce el Master; si no, fallaria las tareas que los usan.
+func workerIncompatibility(req common.RegisterRequest) string {
+	if req.ProtocolVersion != common.ProtocolVersion {
+		return fmt.Sprintf("protocolo %d, el Master requiere %d", req.ProtocolVersion, common.ProtocolVersion)
+	}
+	if req.Operators != nil {
+		if missing := missingNames(common.SupportedOpNames(), req.Operators); len(missing) > 0 {
+			return "operadores no soportados: " + strings.Join(missing, ", ")
+		}
+	}
+	if req.UDFs != nil {
+		if missing := missingNames(operators.UDFNames(), req.UDFs); len(missing) > 0 {
+			return "UDFs no registradas: " + strings.Join(missing, ", ")
+		}
+	}
+	return ""
+}
+
+// missingNames - Elementos de required que no estan en have
+func missingNames(required, have []string) []string {
+	set := make(map[string]bool, len(have))
+	for _, name := range have {
+		set[name] = true
+	}
+	var missing []string
+	for _, name := range required {
+		if !set[name] {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// advertisedURL - Normaliza la direccion anunciada por un worker
+// Entrada: address - "host:port" o URL http(s); "" si no se anuncio
+// Salida: URL base sin "/" final ("" si address esta vacio) o error
+func advertisedURL(address string) (string, error) {
+	address = strings.TrimSpace(address)
+	if address == "" {
+		return "", nil
+	}
+	if !strings.Contains(address, "://") {
+		address = "http://" + address
+	}
+	u, err := url.Parse(address)
+	if err != nil || u.Host == "" || u.Port() == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", fmt.Errorf("address invalida %q (formato host:port o http://host:port)", address)
+	}
+	return strings.TrimRight(u.String(), "/"), nil
+}
+
 // HeartbeatHandler - Procesa heartbeats de workers
 // Entrada: w - response writer, r - request con HeartbeatRequest JSON
 // Salida: HTTP 200 OK o 400 Bad Request
@@ -79,46 +176,229 @@ func (m *Master) HeartbeatHandler(w http.ResponseWriter, r *http.Request) {
 	// Actualizar estado del worker si existe
 	if worker, exists := m.Workers[req.ID]; exists {
 		worker.LastHeartbeat = time.Now() // Actualizar timestamp
-		worker.Status = "UP"              // Reactivar si estaba DOWN
+		if worker.Status != "DRAINING" {
+			worker.Status = "UP" // Reactivar si estaba DOWN
+		}
 		worker.Metrics = req.Metrics      // Guardar metricas actuales
+		m.recordMetrics(req.ID, req.Metrics)
 	}
 	m.mu.Unlock()
+	// Las metricas nuevas pueden liberar capacidad para tareas en espera
+	m.notifySlotFreed()
 	w.WriteHeader(http.StatusOK)
 }
 
 // SubmitJobHandler - Recibe y registra nuevos jobs para ejecucion
-// Entrada: w - response writer, r - request con JobRequest JSON
+// Entrada: w - response writer, r - request con JobRequest JSON o
+//
+//	TemplateSubmitRequest ({"template": {...}, "params": {...}})
+//
 // Salida: HTTP 200 con job_id o 400 Bad Request
-// Descripcion: Parsea definicion de job (DAG), asigna UUID, inicializa
+// Descripcion: GET delega a ListJobsHandler. Parsea definicion de job (DAG), renderiza la plantilla si
 //
-//	estado de progreso, persiste en disco y lanza scheduler
-//	en goroutine separada para procesar nodos source.
+//	aplica, asigna UUID, inicializa estado de progreso, persiste en disco
+//	y lanza scheduler en goroutine separada para procesar nodos source.
 func (m *Master) SubmitJobHandler(w http.ResponseWriter, r *http.Request) {
-	var req common.JobRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if r.Method == http.MethodGet {
+		m.ListJobsHandler(w, r)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	var tr common.TemplateSubmitRequest
+	if err := json.Unmarshal(body, &tr); err != nil {
 		http.Error(w, "JSON inválido", http.StatusBadRequest)
 		return
 	}
 
+	var job *common.Job
+	if len(tr.Template) > 0 {
+		// Sustituir y verificar tipos de parametros antes de validar el DAG
+		req, rendered, err := jobtemplate.Render(tr.Template, tr.Params)
+		if err != nil {
+			http.Error(w, "Plantilla inválida: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		job, err = m.submitJob(req, tr.Params, rendered)
+		if err != nil {
+			http.Error(w, "DAG inválido: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else {
+		var req common.JobRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, "JSON inválido", http.StatusBadRequest)
+			return
+		}
+		job, err = m.submitJob(req, nil, nil)
+		if err != nil {
+			http.Error(w, "DAG inválido: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	// Responder con ID del job
+	json.NewEncoder(w).Encode(map[string]string{"job_id": job.ID, "status": "ACCEPTED"})
+}
+
+// submitJob - Registra un JobRequest y lanza su planificacion
+// Entrada: req - definicion del job (DAG y paralelismo),
+//
+//	params/rendered - parametros y JSON renderizado si viene de plantilla (nil si no)
+//
+// Salida: puntero al Job creado o error si el DAG es invalido
+// Descripcion: Valida el DAG, asigna UUID, inicializa estado de progreso, persiste en disco
+//
+//	y lanza scheduler en goroutine separada para procesar nodos source.
+//	Compartido por la API de jobs y el front-end SQL.
+func (m *Master) submitJob(req common.JobRequest, params map[string]interface{}, rendered []byte) (*common.Job, error) {
+	// Rechazar DAGs mal formados antes de crear estado
+	if err := common.ValidateJobRequest(req); err != nil {
+		return nil, err
+	}
+
+	pool := req.Pool
+	if pool == "" {
+		pool = common.DefaultPool
+	}
+	if _, ok := m.Pools[pool]; !ok {
+		return nil, fmt.Errorf("pool desconocido: %s", pool)
+	}
+	m.mu.Lock()
+	err := m.checkConstraints(req.DAG)
+	m.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
 	// Generar ID unico para el job
 	jobID := uuid.New().String()
 	// Crear objeto Job con estado inicial RUNNING
-	job := &common.Job{ID: jobID, Name: req.Name, Status: "RUNNING", Graph: req.DAG, Parallelism: req.Parallelism ,Submitted: time.Now()}
+	job := &common.Job{ID: jobID, Name: req.Name, Status: "RUNNING", Graph: req.DAG, Parallelism: req.Parallelism, Submitted: time.Now(), TaskTimeoutSecs: req.TaskTimeoutSecs, Retry: req.Retry,
+		Pool: pool, Priority: req.Priority, Trace: tracing.NewTrace().Traceparent()}
+	// Guardar la plantilla renderizada junto al registro del job
+	job.Params = params
+	job.Rendered = rendered
 
 	m.mu.Lock()
 	// Registrar job en mapa global
 	m.Jobs[jobID] = job
 	// Inicializar mapas de progreso y outputs
 	m.InitJobProgress(job)
+	m.recordEvent(jobID, common.JobEvent{Time: job.Submitted, Type: common.EventJobSubmitted, Detail: "pool " + pool})
 	// Persistir estado a disco
 	m.SaveState()
 	m.mu.Unlock()
 
-	utils.LogJSON("INFO", "Job recibido", map[string]interface{}{"job_id": jobID, "parellelism": job.Parallelism})
+	m.stats.jobsSubmitted.With(pool).Inc()
+	utils.LogJSON("INFO", "Job recibido", map[string]interface{}{"job_id": jobID, "parellelism": job.Parallelism, "pool": pool, "priority": job.Priority, "trace_id": jobTraceContext(job).TraceID})
 	// Lanzar scheduler para procesar nodos source (sin dependencias)
 	go m.ScheduleSourceTasks(job)
-	// Responder con ID del job
-	json.NewEncoder(w).Encode(map[string]string{"job_id": jobID, "status": "ACCEPTED"})
+	return job, nil
+}
+
+// checkConstraints - Verifica que algun worker cumpla las etiquetas requeridas de cada nodo
+// Salida: error con el primer nodo que ningun worker UP puede ejecutar
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) checkConstraints(dag common.DAG) error {
+	for _, node := range dag.Nodes {
+		if node.Constraints == nil || len(node.Constraints.Required) == 0 {
+			continue
+		}
+		satisfied := false
+		for _, w := range m.Workers {
+			if w.Status == "UP" && node.Constraints.Allows(w.Labels) {
+				satisfied = true
+				break
+			}
+		}
+		if !satisfied {
+			return fmt.Errorf("nodo %s: ningun worker cumple las etiquetas requeridas %v", node.ID, node.Constraints.Required)
+		}
+	}
+	return nil
+}
+
+// TablesHandler - Registra y lista tablas disponibles para SQL
+// Entrada: w - response writer, r - GET (listar) o POST con TableDef JSON
+// Salida: HTTP 200 con tablas registradas, 400 o 405
+// Descripcion: Las tablas asocian un nombre y un esquema de columnas a un
+//
+//	archivo CSV/JSONL. Se persisten junto al estado del Master.
+func (m *Master) TablesHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		m.mu.Lock()
+		tables := make([]common.TableDef, 0, len(m.Tables))
+		for _, t := range m.Tables {
+			tables = append(tables, t)
+		}
+		m.mu.Unlock()
+		json.NewEncoder(w).Encode(tables)
+	case http.MethodPost:
+		var def common.TableDef
+		if err := json.NewDecoder(r.Body).Decode(&def); err != nil {
+			http.Error(w, "JSON inválido", http.StatusBadRequest)
+			return
+		}
+		if def.Name == "" || def.Path == "" || len(def.Columns) == 0 {
+			http.Error(w, "name, path y columns son obligatorios", http.StatusBadRequest)
+			return
+		}
+		if def.Format == "" {
+			def.Format = "csv"
+		}
+		if def.Format != "csv" && def.Format != "jsonl" {
+			http.Error(w, "format debe ser csv o jsonl", http.StatusBadRequest)
+			return
+		}
+		m.mu.Lock()
+		m.Tables[def.Name] = def
+		m.SaveState()
+		m.mu.Unlock()
+
+		utils.LogJSON("INFO", "Tabla registrada", map[string]interface{}{"table": def.Name, "path": def.Path})
+		json.NewEncoder(w).Encode(def)
+	default:
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+	}
+}
+
+// SQLHandler - Compila una consulta SQL a DAG y la envia como job
+// Entrada: w - response writer, r - request con SQLRequest JSON
+// Salida: HTTP 200 con job_id y DAG generado, o 400 si la consulta es invalida
+// Descripcion: Usa las tablas registradas para resolver columnas y genera
+//
+//	nodos read/filter/map/join/aggregate_by_key del DAG estandar.
+func (m *Master) SQLHandler(w http.ResponseWriter, r *http.Request) {
+	var req common.SQLRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	m.mu.Lock()
+	tables := make(map[string]common.TableDef, len(m.Tables))
+	for k, v := range m.Tables {
+		tables[k] = v
+	}
+	m.mu.Unlock()
+
+	jobReq, err := query.CompileSQL(req.Query, tables, req.Name, req.Parallelism)
+	if err != nil {
+		http.Error(w, "Consulta inválida: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	job, err := m.submitJob(jobReq, nil, nil)
+	if err != nil {
+		http.Error(w, "DAG inválido: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"job_id": job.ID, "status": "ACCEPTED", "dag": jobReq.DAG})
 }
 
 // GetJobStatusHandler - Consulta estado y progreso de un job
@@ -127,7 +407,7 @@ func (m *Master) SubmitJobHandler(w http.ResponseWriter, r *http.Request) {
 // Descripcion: Extrae job_id de URL, calcula porcentaje de progreso,
 //
 //	duracion, y estado por nodo. Si URL termina en /results,
-//	delega a GetJobResultsHandler.
+//	delega a GetJobResultsHandler; /events a JobEventsHandler.
 func (m *Master) GetJobStatusHandler(w http.ResponseWriter, r *http.Request) {
 	// Extraer job_id de la URL (/api/v1/jobs/{id})
 	parts := strings.Split(r.URL.Path, "/")
@@ -136,6 +416,21 @@ func (m *Master) GetJobStatusHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	jobID := parts[4]
+	// Contenido de un nodo (/api/v1/jobs/{id}/results/{node})
+	if len(parts) >= 7 && parts[5] == "results" && parts[6] != "" {
+		m.StreamNodeOutputHandler(w, r, jobID, parts[6])
+		return
+	}
+	// Historial de eventos (/api/v1/jobs/{id}/events)
+	if len(parts) >= 6 && parts[5] == "events" {
+		m.JobEventsHandler(w, r, jobID)
+		return
+	}
+	// Cancelacion del job (/api/v1/jobs/{id}/cancel)
+	if len(parts) >= 6 && parts[5] == "cancel" {
+		m.CancelJobHandler(w, r, jobID)
+		return
+	}
 	// Detectar si se solicitan resultados (/api/v1/jobs/{id}/results)
 	if len(parts) >= 6 && parts[5] == "results" {
 		m.GetJobResultsHandler(w, r, jobID)
@@ -144,6 +439,60 @@ func (m *Master) GetJobStatusHandler(w http.ResponseWriter, r *http.Request) {
 
 	m.mu.Lock()
 	job, exists := m.Jobs[jobID]
+	var status common.JobStatusResponse
+	if exists {
+		status = m.jobStatus(job, true)
+	}
+	m.mu.Unlock()
+
+	if !exists {
+		http.Error(w, "Job no encontrado", http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(status)
+}
+
+// ListJobsHandler - Lista los jobs conocidos por el Master
+// Entrada: w - response writer, r - GET (?status=RUNNING|COMPLETED|FAILED|CANCELLED filtra)
+// Salida: HTTP 200 con JobListResponse (mas recientes primero) o 405
+// Descripcion: Cada job trae el resumen de su estado (progreso, fallos,
+//
+//	estado por nodo) sin DAG, particiones ni metricas; el detalle se
+//	consulta en GET /api/v1/jobs/{id}.
+func (m *Master) ListJobsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	filter := r.URL.Query().Get("status")
+	m.mu.Lock()
+	jobs := make([]common.JobStatusResponse, 0, len(m.Jobs))
+	for _, job := range m.Jobs {
+		if filter == "" || job.Status == filter {
+			jobs = append(jobs, m.jobStatus(job, false))
+		}
+	}
+	m.mu.Unlock()
+
+	sort.Slice(jobs, func(i, j int) bool {
+		if !jobs[i].Submitted.Equal(jobs[j].Submitted) {
+			return jobs[i].Submitted.After(jobs[j].Submitted)
+		}
+		return jobs[i].ID < jobs[j].ID
+	})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(common.JobListResponse{Jobs: jobs})
+}
+
+// jobStatus - Estado de un job tal como lo devuelve la API
+// Entrada: job - job registrado, detail - incluir DAG, estado por
+//
+//	particion y metricas de tareas (consulta de un job)
+//
+// Salida: JobStatusResponse con progreso, duracion y estado por nodo
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) jobStatus(job *common.Job, detail bool) common.JobStatusResponse {
+	jobID := job.ID
 	// Construir mapa de estado por nodo
 	progressMap := make(map[string]string)
 	completedCount := 0
@@ -160,11 +509,17 @@ func (m *Master) GetJobStatusHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	// Obtener contador de fallos
 	failures := m.JobFailures[jobID]
-	m.mu.Unlock()
-
-	if !exists {
-		http.Error(w, "Job no encontrado", http.StatusNotFound)
-		return
+	// Copiar estadisticas de localidad (se modifican bajo m.mu)
+	var locality *common.LocalityStats
+	if job.Locality.Hits+job.Locality.Misses > 0 {
+		stats := job.Locality
+		stats.HitRate = float64(stats.Hits) / float64(stats.Hits+stats.Misses)
+		locality = &stats
+	}
+	var speculation *common.SpeculationStats
+	if job.Speculation.Launched > 0 {
+		stats := job.Speculation
+		speculation = &stats
 	}
 	// Calcular duracion desde envio hasta ahora o hasta completado
 	duration := time.Since(job.Submitted).Seconds()
@@ -178,10 +533,45 @@ func (m *Master) GetJobStatusHandler(w http.ResponseWriter, r *http.Request) {
 		progressPercent = (float64(completedCount) / float64(totalNodes)) * 100
 	}
 
-	json.NewEncoder(w).Encode(common.JobStatusResponse{
+	status := common.JobStatusResponse{
 		ID: job.ID, Name: job.Name, Status: job.Status, Submitted: job.Submitted,
-		DurationSecs: duration, Progress: progressPercent, NodeStatus: progressMap, Failures: failures,
-	})
+		DurationSecs: duration, Progress: progressPercent, NodeStatus: progressMap, Failures: failures, Error: job.Error,
+		Pool: job.Pool, Priority: job.Priority,
+		Params: job.Params, Locality: locality, Speculation: speculation,
+		TraceID: jobTraceContext(job).TraceID,
+	}
+	if detail {
+		graph := job.Graph
+		status.DAG = &graph
+		status.Partitions = m.partitionStatus(jobID)
+		status.Metrics = m.jobMetrics(jobID)
+	}
+	return status
+}
+
+// partitionStatus - Estado de cada particion por nodo (indice = particion)
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) partitionStatus(jobID string) map[string][]string {
+	out := make(map[string][]string)
+	for nodeID, parts := range m.TaskProgress[jobID] {
+		n := 0
+		for part := range parts {
+			if part+1 > n {
+				n = part + 1
+			}
+		}
+		states := make([]string, n)
+		for i := range states {
+			states[i] = "PENDING"
+		}
+		for part, status := range parts {
+			if part >= 0 {
+				states[part] = status
+			}
+		}
+		out[nodeID] = states
+	}
+	return out
 }
 
 // GetJobResultsHandler - Devuelve archivos de salida finales de un job
@@ -193,8 +583,12 @@ func (m *Master) GetJobStatusHandler(w http.ResponseWriter, r *http.Request) {
 //	resultados finales, no intermedios.
 func (m *Master) GetJobResultsHandler(w http.ResponseWriter, r *http.Request, jobID string) {
 	m.mu.Lock()
-	_, exists := m.Jobs[jobID]
-	// Copiar mapa de outputs
+	job, exists := m.Jobs[jobID]
+	// Copiar el DAG (el job puede olvidarse al soltar el lock) y los outputs
+	var graph common.DAG
+	if exists {
+		graph = job.Graph
+	}
 	outputs := make(map[string]string)
 	if outs, ok := m.JobOutputs[jobID]; ok {
 		for k, v := range outs {
@@ -210,10 +604,10 @@ func (m *Master) GetJobResultsHandler(w http.ResponseWriter, r *http.Request, jo
 
 	// Calcular out-degree de cada nodo para identificar sinks
 	outDegree := make(map[string]int)
-	for _, node := range m.Jobs[jobID].Graph.Nodes {
+	for _, node := range graph.Nodes {
 		outDegree[node.ID] = 0
 	}
-	for _, edge := range m.Jobs[jobID].Graph.Edges {
+	for _, edge := range graph.Edges {
 		outDegree[edge[0]]++
 	} // Incrementar out-degree del padre
 
@@ -229,9 +623,168 @@ func (m *Master) GetJobResultsHandler(w http.ResponseWriter, r *http.Request, jo
 	json.NewEncoder(w).Encode(common.JobResultsResponse{JobID: jobID, Outputs: finalOutputs})
 }
 
+// StreamNodeOutputHandler - Transmite el contenido de la salida de un nodo
+// Entrada: w - response writer, r - request, jobID - ID del job, nodeID - nodo
+// Salida: HTTP 200 con texto plano (todas las particiones en orden), 404 si
+//
+//	falta la salida de alguna particion o 500 si no se puede leer
+//
+// Descripcion: Concatena los archivos de salida de cada particion del nodo.
+//
+//	Todos se abren antes de responder: nunca se entrega una salida parcial
+//	con 200. Requiere que el Master vea el directorio de salida de los
+//	workers (mismo host o volumen compartido, como en docker-compose).
+func (m *Master) StreamNodeOutputHandler(w http.ResponseWriter, r *http.Request, jobID, nodeID string) {
+	m.mu.Lock()
+	job, exists := m.Jobs[jobID]
+	var paths []string
+	missing := -1
+	if exists {
+		p := job.Parallelism
+		if p < 1 {
+			p = 1
+		}
+		parts := m.JobPartitionOutputs[jobID][nodeID]
+		for i := 0; i < p; i++ {
+			if path, ok := parts[i]; ok {
+				paths = append(paths, path)
+			} else if missing < 0 {
+				missing = i
+			}
+		}
+		// Jobs recuperados de disco solo conservan la ultima salida por nodo
+		if len(paths) == 0 {
+			if path, ok := m.JobOutputs[jobID][nodeID]; ok {
+				paths = append(paths, path)
+				missing = -1
+			}
+		}
+	}
+	m.mu.Unlock()
+
+	if !exists {
+		http.Error(w, "Job no encontrado", http.StatusNotFound)
+		return
+	}
+	if len(paths) == 0 {
+		http.Error(w, "Nodo sin salida", http.StatusNotFound)
+		return
+	}
+	if missing >= 0 {
+		http.Error(w, fmt.Sprintf("Partición %d sin salida", missing), http.StatusNotFound)
+		return
+	}
+
+	// Abrir todas las particiones antes de escribir la cabecera
+	files := make([]*os.File, 0, len(paths))
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			utils.LogJSON("WARN", "Salida no accesible desde el Master", map[string]interface{}{"path": path, "error": err.Error()})
+			if os.IsNotExist(err) {
+				http.Error(w, "Salida no encontrada: "+path, http.StatusNotFound)
+			} else {
+				http.Error(w, "Salida no legible: "+path, http.StatusInternalServerError)
+			}
+			return
+		}
+		files = append(files, f)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	for _, f := range files {
+		io.Copy(w, f)
+	}
+}
+
+// CancelJobHandler - Cancela un job en ejecucion
+// Entrada: w - response writer, r - request POST, jobID - ID del job
+// Salida: HTTP 200 con CancelJobResponse, 404 si no existe, 409 si ya
+//
+//	termino, 405 si no es POST
+//
+// Descripcion: Marca el job CANCELLED, quita sus tareas de la cola
+//
+//	pendiente y cancela en sus workers las que estan en ejecucion.
+//	Los resultados tardios de esas tareas se descartan.
+func (m *Master) CancelJobHandler(w http.ResponseWriter, r *http.Request, jobID string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	m.mu.Lock()
+	job, exists := m.Jobs[jobID]
+	if !exists {
+		m.mu.Unlock()
+		http.Error(w, "Job no encontrado", http.StatusNotFound)
+		return
+	}
+	if job.Status != "RUNNING" {
+		status := job.Status
+		m.mu.Unlock()
+		http.Error(w, "El job ya terminó con estado "+status, http.StatusConflict)
+		return
+	}
+
+	job.Status = "CANCELLED"
+	job.Completed = time.Now()
+	m.finishJob(job)
+	resp := common.CancelJobResponse{JobID: jobID, Status: job.Status}
+	resp.RemovedPending, resp.CancelledRunning = m.stopJobTasks(jobID, "job cancelado")
+	m.SaveState()
+	m.mu.Unlock()
+	m.notifySlotFreed()
+
+	utils.LogJSON("INFO", "Job cancelado", map[string]interface{}{
+		"job_id":            jobID,
+		"removed_pending":   resp.RemovedPending,
+		"cancelled_running": resp.CancelledRunning,
+	})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// QueueHandler - Muestra la cola de tareas pendientes
+// Entrada: w - response writer, r - request GET
+// Salida: HTTP 200 con QueueStatusResponse o 405
+// Descripcion: Lista las tareas a la espera de un worker en orden de la
+//
+//	cola, con el pool y la prioridad de su job y el tiempo en espera.
+func (m *Master) QueueHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	tasks := m.Pending.Tasks()
+	resp := common.QueueStatusResponse{Length: len(tasks), ByJob: make(map[string]int), Tasks: make([]common.QueuedTask, 0, len(tasks))}
+
+	m.mu.Lock()
+	now := time.Now()
+	for _, t := range tasks {
+		qt := common.QueuedTask{
+			TaskID: t.ID, JobID: t.JobID, NodeID: t.NodeID, PartitionID: t.PartitionID, Attempt: t.Attempt,
+			Pool: m.jobPool(t.JobID), Priority: m.jobPriority(t.JobID), Speculative: t.Speculative,
+		}
+		if !t.QueuedAt.IsZero() {
+			qt.WaitSecs = now.Sub(t.QueuedAt).Seconds()
+		}
+		resp.Tasks = append(resp.Tasks, qt)
+		resp.ByJob[t.JobID]++
+	}
+	m.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
 // CompleteTaskHandler - Procesa reporte de tareas completadas/fallidas
 // Entrada: w - response writer, r - request con TaskResult JSON
-// Salida: HTTP 200 OK o 400 Bad Request
+// Salida: HTTP 200 OK, 400 Bad Request o 410 Gone (otro intento ya gano)
 // Descripcion: Actualiza estado de tarea, maneja reintentos en caso de fallo,
 //
 //	registra outputs exitosos, dispara scheduling de nodos
@@ -246,31 +799,92 @@ func (m *Master) CompleteTaskHandler(w http.ResponseWriter, r *http.Request) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	// Eliminar asignacion de tarea
+	// Worker que ejecuto la tarea: dueño de la particion de salida
+	ownerID := m.TaskAssignments[res.ID]
+	if ownerID == "" {
+		ownerID = res.WorkerID
+	}
+
+	// Eliminar asignacion de tarea (libera un slot del worker)
 	delete(m.TaskAssignments, res.ID)
+	m.notifySlotFreed()
 	
 	// Recuperar tarea original (para reintentos)
 	originalTask, taskFound := m.RunningTasks[res.ID]
 	delete(m.RunningTasks, res.ID)
+	started, hasStart := m.TaskStarted[res.ID]
+	delete(m.TaskStarted, res.ID)
+
+	// Reporte tardio de un intento cancelado (timeout o perdedor):
+	// un fallo se ignora; un exito aun puede ganar si la particion sigue pendiente
+	_, wasCancelled := m.cancelled[res.ID]
+	delete(m.cancelled, res.ID)
+	if wasCancelled && res.Status == "FAILED" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	// Job terminado (cancelado, fallido o completado) o inexistente: ningun
+	// resultado se acepta, ni siquiera el de un intento cancelado; el worker
+	// descarta su salida
+	if _, ok := m.Jobs[res.JobID]; !ok || m.jobFinished(res.JobID) {
+		http.Error(w, "Job terminado o inexistente", http.StatusGone)
+		return
+	}
+
+	// --- INTENTOS DUPLICADOS (ejecucion especulativa) ---
+	// El primer resultado gana: si la particion ya fue completada por otro
+	// intento, se responde 410 Gone para que el worker descarte su salida
+	if m.getPartitionStatus(res.JobID, res.NodeID, res.PartitionID) == "COMPLETED" {
+		utils.LogJSON("INFO", "Resultado descartado (otro intento ganó)", map[string]interface{}{
+			"task_id": res.ID,
+			"node":    res.NodeID,
+			"part":    res.PartitionID,
+		})
+		http.Error(w, "Partición ya completada por otro intento", http.StatusGone)
+		return
+	}
+
+	// Historial y traza del intento (sin la tarea original se usan los datos del resultado)
+	attempt := originalTask
+	if taskFound {
+		m.traceTaskResult(originalTask, res, ownerID)
+	} else {
+		attempt = common.Task{ID: res.ID, JobID: res.JobID, NodeID: res.NodeID, PartitionID: res.PartitionID, Attempt: res.Attempt}
+	}
+	if res.Status == "FAILED" {
+		m.recordTaskEvent(common.EventTaskFailed, attempt, ownerID, res.ErrorMsg)
+	}
 
 	// --- MANEJO DE FALLOS ---
+	// Un fallo FATAL es de la tarea, no del worker: no cuenta para la blacklist
+	if res.Status == "FAILED" && res.ErrorClass != common.ErrorClassFatal {
+		m.recordWorkerFailure(ownerID, res.JobID)
+	}
+	if res.Status == "FAILED" && m.attemptRunning(res) {
+		// Otro intento de la misma particion sigue vivo: no reintentar
+		utils.LogJSON("WARN", "Fallo en intento duplicado (otro intento sigue activo)", map[string]interface{}{
+			"node":  res.NodeID,
+			"part":  res.PartitionID,
+			"error": res.ErrorMsg,
+		})
+		w.WriteHeader(http.StatusOK)
+		return
+	}
 	if res.Status == "FAILED" {
 		m.JobFailures[res.JobID]++
+		m.stats.tasksFailed.With(m.nodeOp(res.JobID, res.NodeID), res.ErrorClass).Inc()
 		utils.LogJSON("ERROR", "Fallo en tarea", map[string]interface{}{
-			"node": res.NodeID, 
-			"part": res.PartitionID, 
+			"node":  res.NodeID,
+			"part":  res.PartitionID,
 			"error": res.ErrorMsg,
+			"class": res.ErrorClass,
 		})
 
-		if taskFound && originalTask.Attempt < common.MaxRetries {
-			originalTask.Attempt++
-			originalTask.ID = uuid.New().String()
-			go func(t common.Task) { m.TaskQueue <- t }(originalTask)
+		if taskFound {
+			// Reintento con backoff segun la politica, o fallo inmediato si es FATAL
+			m.retryOrFail(originalTask, ownerID, res.ErrorClass, res.ErrorMsg)
 		} else {
-			if job, ok := m.Jobs[res.JobID]; ok {
-				job.Status = "FAILED"
-				job.Completed = time.Now()
-			}
+			m.failJob(res.JobID, fmt.Sprintf("nodo %s, partición %d: %s", res.NodeID, res.PartitionID, res.ErrorMsg))
 		}
 		m.SaveState()
 		w.WriteHeader(http.StatusOK)
@@ -281,6 +895,36 @@ func (m *Master) CompleteTaskHandler(w http.ResponseWriter, r *http.Request) {
 
 	// 1. Actualizar estado de la PARTICIÓN específica
 	m.setPartitionStatus(res.JobID, res.NodeID, res.PartitionID, "COMPLETED")
+	m.recordTaskEvent(common.EventTaskCompleted, attempt, ownerID, "")
+
+	// Registrar duracion de la etapa (base de la deteccion de rezagadas)
+	if hasStart {
+		key := stageKey(res.JobID, res.NodeID)
+		m.StageDurations[key] = append(m.StageDurations[key], time.Since(started))
+	}
+	var elapsed time.Duration
+	if hasStart {
+		elapsed = time.Since(started)
+	}
+	m.stats.observeTaskCompleted(m.nodeOp(res.JobID, res.NodeID), elapsed, res.Usage)
+	m.recordTaskMetrics(res, ownerID, originalTask.Attempt)
+	if taskFound && originalTask.Speculative {
+		if job, ok := m.Jobs[res.JobID]; ok {
+			job.Speculation.Won++
+		}
+	}
+	// Cancelar los demas intentos de la particion (perdedores)
+	for id, t := range m.RunningTasks {
+		if t.JobID == res.JobID && t.NodeID == res.NodeID && t.PartitionID == res.PartitionID {
+			if wk, ok := m.Workers[m.TaskAssignments[id]]; ok {
+				go m.cancelOnWorker(wk, id, "otro intento ganó")
+			}
+			delete(m.RunningTasks, id)
+			delete(m.TaskAssignments, id)
+			delete(m.TaskStarted, id)
+			m.cancelled[id] = res.JobID
+		}
+	}
 
 	// 2. NUEVO: Verificar si TODAS las particiones del nodo terminaron
 	// Esto es necesario para que el API muestre el nodo como "COMPLETED"
@@ -312,16 +956,34 @@ func (m *Master) CompleteTaskHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	m.JobPartitionOutputs[res.JobID][res.NodeID][res.PartitionID] = res.Result
 
+	// Registrar dueño para colocar tareas dependientes junto a sus datos
+	if _, ok := m.JobPartitionOwners[res.JobID]; !ok {
+		m.JobPartitionOwners[res.JobID] = make(map[string]map[int]string)
+	}
+	if _, ok := m.JobPartitionOwners[res.JobID][res.NodeID]; !ok {
+		m.JobPartitionOwners[res.JobID][res.NodeID] = make(map[int]string)
+	}
+	m.JobPartitionOwners[res.JobID][res.NodeID][res.PartitionID] = ownerID
+
 	// Compatibilidad con CLI
 	if _, ok := m.JobOutputs[res.JobID]; !ok {
 		m.JobOutputs[res.JobID] = make(map[string]string)
 	}
 	m.JobOutputs[res.JobID][res.NodeID] = res.Result
 
-	utils.LogJSON("INFO", "Tarea completada", map[string]interface{}{
-		"node": res.NodeID, 
+	completed := map[string]interface{}{
+		"node": res.NodeID,
 		"part": res.PartitionID,
-	})
+	}
+	if res.Usage != nil {
+		completed["wall_secs"] = res.Usage.WallSecs
+		completed["cpu_secs"] = res.Usage.CPUSecs
+		completed["input_bytes"] = res.Usage.InputBytes
+		completed["output_bytes"] = res.Usage.OutputBytes
+		completed["records_out"] = res.Usage.RecordsOut
+		completed["spill_count"] = res.Usage.SpillCount
+	}
+	utils.LogJSON("INFO", "Tarea completada", completed)
 
 	m.SaveState()
 
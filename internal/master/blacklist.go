@@ -0,0 +1,114 @@
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: blacklist.go
+Descripcion: Exclusion temporal (blacklist) de workers con fallos repetidos.
+             Un worker con disco lleno o entorno de UDFs roto sigue enviando
+             heartbeats, asi que HealthCheckLoop no lo detecta. El Master
+             cuenta los fallos de tareas por worker y por worker+job; al
+             superar el umbral el worker no recibe tareas (de ningun job o
+             solo de ese job) durante un periodo de enfriamiento.
+*/
+
+package master
+
+import (
+	"mini-spark/internal/utils"
+	"time"
+)
+
+// BlacklistConfig parametros de exclusion de workers
+type BlacklistConfig struct {
+	Enabled     bool          // Activa la exclusion de workers
+	MaxFailures int           // Fallos de un worker (cualquier job) antes de excluirlo del cluster
+	MaxPerJob   int           // Fallos de un worker en un mismo job antes de excluirlo de ese job
+	Cooldown    time.Duration // Duracion de la exclusion
+}
+
+// DefaultBlacklistConfig - Configuracion por defecto de la blacklist
+func DefaultBlacklistConfig() BlacklistConfig {
+	return BlacklistConfig{
+		Enabled:     true,
+		MaxFailures: 4,
+		MaxPerJob:   2,
+		Cooldown:    5 * time.Minute,
+	}
+}
+
+// recordWorkerFailure - Registra un fallo de tarea atribuible a un worker
+// Entrada: workerID - worker donde fallo la tarea, jobID - job de la tarea
+// Salida: ninguna (void)
+// Descripcion: Incrementa los contadores por worker y por worker+job. Al
+//
+//	alcanzar MaxPerJob excluye al worker de ese job; al alcanzar
+//	MaxFailures lo excluye de todo el cluster. El contador
+//	correspondiente se reinicia al excluir.
+//
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) recordWorkerFailure(workerID, jobID string) {
+	if workerID == "" || !m.Blacklist.Enabled {
+		return
+	}
+	now := time.Now()
+
+	if _, ok := m.JobWorkerFailures[jobID]; !ok {
+		m.JobWorkerFailures[jobID] = make(map[string]int)
+	}
+	m.JobWorkerFailures[jobID][workerID]++
+	m.WorkerFailures[workerID]++
+
+	if m.Blacklist.MaxPerJob > 0 && m.JobWorkerFailures[jobID][workerID] >= m.Blacklist.MaxPerJob {
+		if _, ok := m.jobBlacklist[jobID]; !ok {
+			m.jobBlacklist[jobID] = make(map[string]time.Time)
+		}
+		m.jobBlacklist[jobID][workerID] = now.Add(m.Blacklist.Cooldown)
+		m.JobWorkerFailures[jobID][workerID] = 0
+		utils.LogJSON("WARN", "Worker excluido del job", map[string]interface{}{
+			"worker_id":     workerID,
+			"job_id":        jobID,
+			"cooldown_secs": m.Blacklist.Cooldown.Seconds(),
+		})
+	}
+	if m.Blacklist.MaxFailures > 0 && m.WorkerFailures[workerID] >= m.Blacklist.MaxFailures {
+		m.blacklisted[workerID] = now.Add(m.Blacklist.Cooldown)
+		m.WorkerFailures[workerID] = 0
+		utils.LogJSON("WARN", "Worker excluido del cluster", map[string]interface{}{
+			"worker_id":     workerID,
+			"cooldown_secs": m.Blacklist.Cooldown.Seconds(),
+		})
+	}
+}
+
+// isBlacklisted - true si el worker no puede recibir tareas del job
+// Entrada: workerID - worker candidato, jobID - job de la tarea
+// Descripcion: Las exclusiones vencidas se eliminan al consultarlas.
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) isBlacklisted(workerID, jobID string) bool {
+	now := time.Now()
+	if until, ok := m.blacklisted[workerID]; ok {
+		if now.Before(until) {
+			return true
+		}
+		delete(m.blacklisted, workerID)
+		utils.LogJSON("INFO", "Fin de exclusion de worker", map[string]interface{}{"worker_id": workerID})
+	}
+	if until, ok := m.jobBlacklist[jobID][workerID]; ok {
+		if now.Before(until) {
+			return true
+		}
+		delete(m.jobBlacklist[jobID], workerID)
+	}
+	return false
+}
+
+// blacklistedJobs - Jobs de los que un worker esta excluido actualmente
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) blacklistedJobs(workerID string) []string {
+	now := time.Now()
+	var jobs []string
+	for jobID, workers := range m.jobBlacklist {
+		if until, ok := workers[workerID]; ok && now.Before(until) {
+			jobs = append(jobs, jobID)
+		}
+	}
+	return jobs
+}
@@ -0,0 +1,224 @@
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: decommission.go
+Descripcion: Retiro ordenado (decommission) de workers.
+             Un worker en DRAINING no recibe tareas nuevas y termina las
+             que tiene en ejecucion. Al desregistrarse, el Master traspasa
+             la propiedad de sus particiones de salida a otro worker (el
+             almacenamiento es compartido) y vuelve a ejecutar las que ya
+             no estan disponibles y aun se necesitan.
+*/
+
+package master
+
+import (
+	"bytes"
+	"encoding/json"
+	"mini-spark/internal/common"
+	"mini-spark/internal/utils"
+	"net/http"
+	"os"
+	"sort"
+)
+
+// DecommissionWorkerHandler - Inicia el retiro ordenado de un worker
+// Entrada: w - response writer, r - request POST, workerID - worker a retirar
+// Salida: HTTP 202 con WorkerStatusResponse, 404 si no existe, 409 si
+//
+//	esta DOWN, 405 si no es POST
+//
+// Descripcion: Marca el worker DRAINING (el scheduler deja de usarlo) y le
+//
+//	ordena drenar: termina sus tareas, se desregistra y se apaga.
+//	Es idempotente (el SIGTERM del worker llega por esta misma ruta).
+func (m *Master) DecommissionWorkerHandler(w http.ResponseWriter, r *http.Request, workerID string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	m.mu.Lock()
+	worker, ok := m.Workers[workerID]
+	if !ok {
+		m.mu.Unlock()
+		http.Error(w, "Worker no encontrado", http.StatusNotFound)
+		return
+	}
+	if worker.Status == "DOWN" {
+		m.mu.Unlock()
+		http.Error(w, "El worker está DOWN", http.StatusConflict)
+		return
+	}
+	m.startDecommission(worker, "api")
+	resp := common.WorkerStatusResponse{WorkerInfo: *worker, Failures: m.WorkerFailures[workerID]}
+	m.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// startDecommission - Marca un worker DRAINING y le ordena drenar
+// Entrada: worker - worker UP o DRAINING, reason - origen ("api", "autoscale")
+// Descripcion: No hace nada si el worker ya esta drenando.
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) startDecommission(worker *common.WorkerInfo, reason string) {
+	if worker.Status == "DRAINING" {
+		return
+	}
+	worker.Status = "DRAINING"
+	utils.LogJSON("INFO", "Decommission de worker", map[string]interface{}{
+		"worker_id": worker.ID,
+		"reason":    reason,
+	})
+	go m.drainOnWorker(*worker)
+}
+
+// drainOnWorker - Ordena a un worker dejar de aceptar tareas y drenar
+// Salida: ninguna (void); los errores solo se loguean
+func (m *Master) drainOnWorker(worker common.WorkerInfo) {
+	resp, err := http.Post(worker.URL+"/decommission", "application/json", bytes.NewBuffer(nil))
+	if err != nil {
+		utils.LogJSON("WARN", "No se pudo ordenar decommission al worker", map[string]interface{}{
+			"worker_id": worker.ID,
+			"error":     err.Error(),
+		})
+		return
+	}
+	resp.Body.Close()
+}
+
+// DeregisterHandler - Baja de un worker que termino de drenar
+// Entrada: w - response writer, r - request con DeregisterRequest JSON
+// Salida: HTTP 200 OK, 404 si el worker no existe, 400 si JSON invalido
+// Descripcion: Reencola las tareas que aun tuviera asignadas, traspasa sus
+//
+//	particiones de salida (handoffOutputs) y lo elimina del cluster.
+func (m *Master) DeregisterHandler(w http.ResponseWriter, r *http.Request) {
+	var req common.DeregisterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	m.mu.Lock()
+	if _, ok := m.Workers[req.ID]; !ok {
+		m.mu.Unlock()
+		http.Error(w, "Worker no encontrado", http.StatusNotFound)
+		return
+	}
+	// Tareas que no alcanzaron a terminar (ej: drenado con timeout)
+	m.markWorkerDown(req.ID, "decommission")
+	moved, rerun := m.handoffOutputs(req.ID)
+	delete(m.Workers, req.ID)
+	delete(m.busyUntil, req.ID)
+	delete(m.metricsHistory, req.ID)
+	m.SaveState()
+	m.mu.Unlock()
+	m.notifySlotFreed()
+
+	utils.LogJSON("INFO", "Worker desregistrado", map[string]interface{}{
+		"worker_id":        req.ID,
+		"handed_off":       moved,
+		"rerun_partitions": rerun,
+	})
+	w.WriteHeader(http.StatusOK)
+}
+
+// handoffOutputs - Traspasa las particiones producidas por un worker que se retira
+// Entrada: workerID - worker saliente
+// Salida: particiones traspasadas a otro worker y particiones reprogramadas
+// Descripcion: Para cada particion de un job RUNNING cuyo dueño es el
+//
+//	worker: si su archivo sigue accesible (almacenamiento compartido) la
+//	propiedad pasa a otro worker UP, para que la localidad lo prefiera;
+//	si no, y algun hijo aun la necesita, se vuelve a ejecutar.
+//
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) handoffOutputs(workerID string) (moved, rerun int) {
+	var peers []string
+	for id, w := range m.Workers {
+		if id != workerID && w.Status == "UP" {
+			peers = append(peers, id)
+		}
+	}
+	sort.Strings(peers)
+
+	for jobID, nodes := range m.JobPartitionOwners {
+		job, ok := m.Jobs[jobID]
+		if !ok || job.Status != "RUNNING" {
+			continue
+		}
+		for nodeID, owners := range nodes {
+			for part, owner := range owners {
+				if owner != workerID {
+					continue
+				}
+				path := m.JobPartitionOutputs[jobID][nodeID][part]
+				if _, err := os.Stat(path); path != "" && err == nil {
+					if len(peers) == 0 {
+						delete(owners, part)
+					} else {
+						owners[part] = peers[moved%len(peers)]
+					}
+					moved++
+					continue
+				}
+				delete(owners, part)
+				if m.partitionNeeded(job, nodeID, part) {
+					m.requeuePartition(job, nodeID, part)
+					rerun++
+				}
+			}
+		}
+	}
+	return moved, rerun
+}
+
+// partitionNeeded - true si algun hijo del nodo aun no completo la particion
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) partitionNeeded(job *common.Job, nodeID string, part int) bool {
+	for _, edge := range job.Graph.Edges {
+		if edge[0] == nodeID && m.getPartitionStatus(job.ID, edge[1], part) != "COMPLETED" {
+			return true
+		}
+	}
+	return false
+}
+
+// requeuePartition - Vuelve a ejecutar una particion cuya salida se perdio
+// Entrada: job - job RUNNING, nodeID - nodo, part - particion
+// Descripcion: Reconstruye las entradas desde las salidas de los padres
+//
+//	(mapeo 1-a-1) y la encola de nuevo.
+//
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) requeuePartition(job *common.Job, nodeID string, part int) {
+	parallelism := job.Parallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	var node common.DAGNode
+	for _, n := range job.Graph.Nodes {
+		if n.ID == nodeID {
+			node = n
+		}
+	}
+	var inputs, preferred []string
+	for _, edge := range job.Graph.Edges {
+		if edge[1] != nodeID {
+			continue
+		}
+		if out := m.JobPartitionOutputs[job.ID][edge[0]][part]; out != "" {
+			inputs = append(inputs, out)
+		}
+		if owner := m.JobPartitionOwners[job.ID][edge[0]][part]; owner != "" && !containsString(preferred, owner) {
+			preferred = append(preferred, owner)
+		}
+	}
+	delete(m.JobPartitionOutputs[job.ID][nodeID], part)
+	utils.LogJSON("WARN", "Reprogramando partición perdida", map[string]interface{}{
+		"job_id": job.ID,
+		"node":   nodeID,
+		"part":   part,
+	})
+	m.queueTask(job.ID, node, inputs, preferred, part, parallelism)
+}
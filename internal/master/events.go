@@ -0,0 +1,199 @@
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: events.go
+Descripcion: Historial de eventos de cada job.
+             El Master registra cuando cada particion se encola, se asigna,
+             empieza, termina, falla, se reintenta o se replanifica, y el
+             fin del job. El historial se escribe de forma incremental en
+             un archivo JSON Lines por job (junto al archivo de estado) y
+             se sirve en GET /api/v1/jobs/{id}/events para armar lineas
+             de tiempo.
+*/
+
+package master
+
+import (
+	"bufio"
+	"encoding/json"
+	"mini-spark/internal/common"
+	"mini-spark/internal/utils"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// maxJobEvents eventos conservados por job (se descartan los mas antiguos)
+const maxJobEvents = 10000
+
+// recordEvent - Agrega un evento al historial de un job
+// Descripcion: Asigna el numero de secuencia y, si Time esta vacio, la hora
+//
+//	actual, y lo agrega al archivo del job (costo constante por evento).
+//
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) recordEvent(jobID string, event common.JobEvent) {
+	events := m.JobEvents[jobID]
+	event.Seq = 1
+	if len(events) > 0 {
+		event.Seq = events[len(events)-1].Seq + 1
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	events = append(events, event)
+	if len(events) > maxJobEvents {
+		events = events[len(events)-maxJobEvents:]
+	}
+	m.JobEvents[jobID] = events
+
+	// Los jobs terminados ya cerraron su archivo (ver finishJob)
+	if f := m.eventLog(jobID, !m.jobFinished(jobID)); f != nil {
+		if err := json.NewEncoder(f).Encode(event); err != nil {
+			utils.LogJSON("WARN", "No se pudo persistir evento", map[string]interface{}{"job_id": jobID, "error": err.Error()})
+		}
+	}
+}
+
+// eventLogPath - Archivo de eventos de un job (JSON Lines)
+func (m *Master) eventLogPath(jobID string) string {
+	return filepath.Join(m.stateFile+".events", jobID+".jsonl")
+}
+
+// eventLog - Archivo de eventos abierto de un job
+// Entrada: jobID, create - abrirlo (en modo append) si no lo esta
+// Salida: archivo o nil si no esta abierto o no se pudo abrir
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) eventLog(jobID string, create bool) *os.File {
+	if f, ok := m.eventLogs[jobID]; ok || !create {
+		return f
+	}
+	path := m.eventLogPath(jobID)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		utils.LogJSON("WARN", "No se pudo crear directorio de eventos", map[string]interface{}{"error": err.Error()})
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		utils.LogJSON("WARN", "No se pudo abrir historial de eventos", map[string]interface{}{"job_id": jobID, "error": err.Error()})
+		return nil
+	}
+	m.eventLogs[jobID] = f
+	return f
+}
+
+// closeEventLog - Cierra el archivo de eventos de un job
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) closeEventLog(jobID string) {
+	if f, ok := m.eventLogs[jobID]; ok {
+		f.Close()
+		delete(m.eventLogs, jobID)
+	}
+}
+
+// removeEventLog - Cierra y borra el archivo de eventos de un job
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) removeEventLog(jobID string) {
+	m.closeEventLog(jobID)
+	os.Remove(m.eventLogPath(jobID))
+}
+
+// loadEventLog - Lee el historial de un job desde su archivo
+// Salida: ultimos maxJobEvents eventos (nil si no hay archivo)
+func (m *Master) loadEventLog(jobID string) []common.JobEvent {
+	f, err := os.Open(m.eventLogPath(jobID))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var events []common.JobEvent
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e common.JobEvent
+		if json.Unmarshal(scanner.Bytes(), &e) != nil {
+			continue // Linea truncada por una caida
+		}
+		events = append(events, e)
+		if len(events) > 2*maxJobEvents {
+			events = append([]common.JobEvent{}, events[len(events)-maxJobEvents:]...)
+		}
+	}
+	if len(events) > maxJobEvents {
+		events = events[len(events)-maxJobEvents:]
+	}
+	return events
+}
+
+// recordTaskEvent - Agrega un evento de una tarea al historial de su job
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) recordTaskEvent(eventType string, task common.Task, workerID, detail string) {
+	m.recordEvent(task.JobID, common.JobEvent{
+		Type:      eventType,
+		NodeID:    task.NodeID,
+		Partition: task.PartitionID,
+		TaskID:    task.ID,
+		Attempt:   task.Attempt,
+		WorkerID:  workerID,
+		Detail:    detail,
+	})
+}
+
+// finishJob - Registra el fin de un job en metricas, trazas e historial
+// Descripcion: Cierra su archivo de eventos, libera el estado que solo se
+//
+//	usa mientras corre y aplica la retencion de jobs terminados.
+//
+// Nota: Debe llamarse con m.mu tomado, despues de fijar Status, Completed y Error
+func (m *Master) finishJob(job *common.Job) {
+	m.stats.jobsFinished.With(job.Status).Inc()
+	m.traceJobEnd(job)
+	m.eventLog(job.ID, true) // El evento final se persiste aunque el job ya no este RUNNING
+	m.recordEvent(job.ID, common.JobEvent{Time: job.Completed, Type: "JOB_" + job.Status, Detail: job.Error})
+	m.closeEventLog(job.ID)
+	m.releaseJob(job.ID)
+	m.pruneFinishedJobs()
+}
+
+// JobEventsHandler - Devuelve el historial de eventos de un job
+// Entrada: w - response writer, r - GET (?after=N: solo eventos con Seq > N),
+//
+//	jobID - ID del job
+//
+// Salida: HTTP 200 con JobEventsResponse, 400 si after es invalido, 404 o 405
+func (m *Master) JobEventsHandler(w http.ResponseWriter, r *http.Request, jobID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	after := 0
+	if v := r.URL.Query().Get("after"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "after debe ser un entero >= 0", http.StatusBadRequest)
+			return
+		}
+		after = n
+	}
+
+	m.mu.Lock()
+	job, exists := m.Jobs[jobID]
+	resp := common.JobEventsResponse{JobID: jobID, Events: []common.JobEvent{}}
+	if exists {
+		resp.Status = job.Status
+		for _, e := range m.JobEvents[jobID] {
+			if e.Seq > after {
+				resp.Events = append(resp.Events, e)
+			}
+		}
+	}
+	m.mu.Unlock()
+
+	if !exists {
+		http.Error(w, "Job no encontrado", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
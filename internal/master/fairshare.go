@@ -0,0 +1,200 @@
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: fairshare.go
+Descripcion: Reparto justo (fair sharing) de slots entre jobs.
+             Los jobs pertenecen a pools con peso; el scheduler coloca
+             primero las tareas del pool con menos tareas en ejecucion
+             por unidad de peso y, dentro del pool, las del job con mayor
+             prioridad y luego con menos tareas en ejecucion. Asi un job
+             grande no acapara el cluster frente a uno pequeño enviado
+             despues.
+*/
+
+package master
+
+import (
+	"fmt"
+	"mini-spark/internal/common"
+	"mini-spark/internal/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParsePools - Interpreta la definicion de pools del scheduler
+// Entrada: spec - lista "nombre=peso" separada por comas (ej: "interactive=3,batch=1")
+// Salida: mapa pool -> peso (incluye DefaultPool con peso 1 si no se define) o error
+func ParsePools(spec string) (map[string]int, error) {
+	pools := map[string]int{common.DefaultPool: 1}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, weightStr, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("pool invalido %q (formato nombre=peso)", entry)
+		}
+		weight, err := strconv.Atoi(strings.TrimSpace(weightStr))
+		if err != nil || weight < 1 {
+			return nil, fmt.Errorf("pool %s: el peso debe ser un entero >= 1", name)
+		}
+		pools[strings.TrimSpace(name)] = weight
+	}
+	return pools, nil
+}
+
+// jobQueue tareas retenidas de un job y su estado de reparto
+type jobQueue struct {
+	jobID     string
+	pool      string
+	priority  int
+	submitted time.Time
+	tasks     []common.Task // Tareas del job en orden de llegada
+	next      int           // Siguiente tarea a intentar en esta pasada
+}
+
+// fairOrderLess - true si el job a debe recibir el siguiente slot antes que b
+// Descripcion: Menor uso del pool (en ejecucion / peso), luego mayor
+//
+//	prioridad, luego menos tareas en ejecucion del job, luego el mas antiguo.
+func fairOrderLess(a, b *jobQueue, poolRunning, jobRunning map[string]int, weights map[string]int) bool {
+	shareA := float64(poolRunning[a.pool]) / float64(poolWeight(weights, a.pool))
+	shareB := float64(poolRunning[b.pool]) / float64(poolWeight(weights, b.pool))
+	if shareA != shareB {
+		return shareA < shareB
+	}
+	if a.priority != b.priority {
+		return a.priority > b.priority
+	}
+	if jobRunning[a.jobID] != jobRunning[b.jobID] {
+		return jobRunning[a.jobID] < jobRunning[b.jobID]
+	}
+	if !a.submitted.Equal(b.submitted) {
+		return a.submitted.Before(b.submitted)
+	}
+	return a.jobID < b.jobID
+}
+
+// poolWeight - Peso de un pool (1 si no esta configurado)
+func poolWeight(weights map[string]int, pool string) int {
+	if w := weights[pool]; w > 0 {
+		return w
+	}
+	return 1
+}
+
+// schedulePass - Coloca las tareas pendientes respetando el reparto justo
+// Entrada: waitLogged - tareas cuya espera ya se logueo
+// Salida: ninguna (void); las tareas colocadas o descartadas salen de m.Pending
+// Descripcion: Agrupa las tareas de la cola por job y, mientras queden
+//
+//	tareas por intentar, elige el job segun fairOrderLess e intenta
+//	colocar su siguiente tarea. Las que no pueden colocarse (sin slots o
+//	esperando localidad) se saltan sin bloquear a las demas. Los intentos
+//	especulativos obsoletos y las tareas de jobs terminados se descartan.
+func (m *Master) schedulePass(waitLogged map[string]bool) {
+	pending := m.Pending.Tasks()
+	// Olvidar tareas que ya salieron de la cola (cancelacion de su job)
+	queued := make(map[string]bool, len(pending))
+	for _, task := range pending {
+		queued[task.ID] = true
+	}
+	for id := range waitLogged {
+		if !queued[id] {
+			delete(waitLogged, id)
+		}
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	m.mu.Lock()
+	poolRunning := make(map[string]int)
+	jobRunning := make(map[string]int)
+	for _, t := range m.RunningTasks {
+		jobRunning[t.JobID]++
+		poolRunning[m.jobPool(t.JobID)]++
+	}
+	queues := make(map[string]*jobQueue)
+	var order []*jobQueue
+	dropped := make(map[string]bool)
+	for _, task := range pending {
+		if m.isObsolete(task) || m.jobFinished(task.JobID) {
+			// El intento original termino antes de colocar la copia, o el job
+			// fue cancelado/fallo mientras la tarea esperaba
+			dropped[task.ID] = true
+			delete(waitLogged, task.ID)
+			continue
+		}
+		q, ok := queues[task.JobID]
+		if !ok {
+			q = &jobQueue{jobID: task.JobID, pool: m.jobPool(task.JobID)}
+			if job, exists := m.Jobs[task.JobID]; exists {
+				q.priority = job.Priority
+				q.submitted = job.Submitted
+			}
+			queues[task.JobID] = q
+			order = append(order, q)
+		}
+		q.tasks = append(q.tasks, task)
+	}
+	weights := m.Pools
+	m.mu.Unlock()
+	m.Pending.Remove(dropped)
+
+	for {
+		var best *jobQueue
+		for _, q := range order {
+			if q.next < len(q.tasks) && (best == nil || fairOrderLess(q, best, poolRunning, jobRunning, weights)) {
+				best = q
+			}
+		}
+		if best == nil {
+			break
+		}
+		task := best.tasks[best.next]
+		best.next++
+
+		worker, local := m.tryPlace(task)
+		if worker == nil {
+			if !waitLogged[task.ID] {
+				utils.LogJSON("INFO", "Tarea en espera de worker", map[string]interface{}{
+					"task_id": task.ID,
+					"node":    task.NodeID,
+					"part":    task.PartitionID,
+				})
+				waitLogged[task.ID] = true
+			}
+			continue
+		}
+		delete(waitLogged, task.ID)
+		// Sacarla de la cola antes de enviarla: si el envio falla se reencola
+		m.Pending.Remove(map[string]bool{task.ID: true})
+		poolRunning[best.pool]++
+		jobRunning[best.jobID]++
+
+		// Loguear asignacion
+		utils.LogJSON("INFO", "Asignando tarea a worker", map[string]interface{}{
+			"task_id":    task.ID,
+			"node":       task.NodeID,
+			"part":       task.PartitionID,
+			"worker_id":  worker.ID,
+			"worker_url": worker.URL,
+			"policy":     m.Placement.Name(),
+			"local":      local,
+			"pool":       best.pool,
+		})
+		// Enviar tarea al worker en goroutine separada
+		go m.sendTask(worker, task)
+	}
+}
+
+// jobPool - Pool de un job (DefaultPool si no existe o no lo indica)
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) jobPool(jobID string) string {
+	if job, ok := m.Jobs[jobID]; ok && job.Pool != "" {
+		return job.Pool
+	}
+	return common.DefaultPool
+}
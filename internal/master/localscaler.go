@@ -0,0 +1,162 @@
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: localscaler.go
+Descripcion: Scaler que lanza workers como procesos locales.
+             Cada worker nuevo es un proceso cmd/worker en un puerto
+             libre que se registra solo en el Master. Al retirarse, el
+             worker drena y termina por su cuenta; si no termina dentro
+             de DrainTimeout el proceso se mata.
+*/
+
+package master
+
+import (
+	"fmt"
+	"mini-spark/internal/common"
+	"mini-spark/internal/utils"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// LocalProcessScaler lanza y retira procesos worker en esta maquina
+type LocalProcessScaler struct {
+	WorkerBin    string        // Ejecutable del worker (ej: bin/worker)
+	MasterURL    string        // MASTER_URL de los workers lanzados
+	LogDir       string        // Directorio de logs (worker-<puerto>.log)
+	Slots        int           // Slots de cada worker (0 = DefaultWorkerSlots)
+	DrainTimeout time.Duration // Espera maxima a que un worker retirado termine
+
+	mu    sync.Mutex
+	procs map[int]*exec.Cmd // Procesos lanzados: Puerto -> Proceso
+}
+
+// NewLocalProcessScaler - Constructor del scaler de procesos locales
+// Entrada: workerBin - ejecutable, masterURL - URL del Master,
+//
+//	logDir - directorio de logs, slots - slots por worker
+//
+// Salida: puntero a LocalProcessScaler
+func NewLocalProcessScaler(workerBin, masterURL, logDir string, slots int) *LocalProcessScaler {
+	return &LocalProcessScaler{
+		WorkerBin:    workerBin,
+		MasterURL:    masterURL,
+		LogDir:       logDir,
+		Slots:        slots,
+		DrainTimeout: 5 * time.Minute,
+		procs:        make(map[int]*exec.Cmd),
+	}
+}
+
+// Name - Nombre del scaler
+func (s *LocalProcessScaler) Name() string { return "local" }
+
+// ScaleUp - Lanza n procesos worker en puertos libres
+// Salida: error del primer proceso que no pudo lanzarse
+func (s *LocalProcessScaler) ScaleUp(n int) error {
+	for i := 0; i < n; i++ {
+		port, err := freePort()
+		if err != nil {
+			return err
+		}
+		if err := s.spawn(port); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// spawn - Lanza un worker en el puerto dado
+func (s *LocalProcessScaler) spawn(port int) error {
+	args := []string{"-port", strconv.Itoa(port)}
+	if s.Slots > 0 {
+		args = append(args, "-slots", strconv.Itoa(s.Slots))
+	}
+	cmd := exec.Command(s.WorkerBin, args...)
+	cmd.Env = append(os.Environ(), "MASTER_URL="+s.MasterURL)
+	if s.LogDir != "" {
+		os.MkdirAll(s.LogDir, 0755)
+		logFile, err := os.Create(filepath.Join(s.LogDir, fmt.Sprintf("worker-%d.log", port)))
+		if err != nil {
+			return err
+		}
+		defer logFile.Close() // El proceso hijo conserva su descriptor
+		cmd.Stdout = logFile
+		cmd.Stderr = logFile
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("no se pudo lanzar worker en puerto %d: %w", port, err)
+	}
+
+	s.mu.Lock()
+	s.procs[port] = cmd
+	s.mu.Unlock()
+	utils.LogJSON("INFO", "Worker lanzado", map[string]interface{}{"port": port, "pid": cmd.Process.Pid})
+
+	go func() {
+		cmd.Wait()
+		s.mu.Lock()
+		delete(s.procs, port)
+		s.mu.Unlock()
+		utils.LogJSON("INFO", "Proceso worker terminado", map[string]interface{}{"port": port})
+	}()
+	return nil
+}
+
+// ScaleDown - Vigila que los workers retirados terminen
+// Entrada: workers - workers ya en decommission
+// Salida: nil; los workers que no lanzo este scaler se ignoran
+// Descripcion: El worker sale solo al terminar de drenar; si sigue vivo
+//
+//	tras DrainTimeout se mata el proceso.
+func (s *LocalProcessScaler) ScaleDown(workers []common.WorkerInfo) error {
+	for _, w := range workers {
+		u, err := url.Parse(w.URL)
+		if err != nil {
+			continue
+		}
+		port, _ := strconv.Atoi(u.Port())
+		s.mu.Lock()
+		cmd, ok := s.procs[port]
+		s.mu.Unlock()
+		if !ok {
+			continue
+		}
+		time.AfterFunc(s.DrainTimeout, func() {
+			s.mu.Lock()
+			alive := s.procs[port] == cmd
+			s.mu.Unlock()
+			if alive {
+				utils.LogJSON("WARN", "Worker no termino de drenar, matando proceso", map[string]interface{}{"port": port})
+				cmd.Process.Kill()
+			}
+		})
+	}
+	return nil
+}
+
+// Running - Puertos de los procesos worker vivos lanzados por el scaler
+func (s *LocalProcessScaler) Running() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ports := make([]int, 0, len(s.procs))
+	for port := range s.procs {
+		ports = append(ports, port)
+	}
+	return ports
+}
+
+// freePort - Obtiene un puerto TCP libre
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", ":0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
@@ -0,0 +1,103 @@
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: metrics.go
+Descripcion: Metricas Prometheus del Master (GET /metrics).
+             Contadores de jobs enviados y terminados, duracion de tareas
+             por operacion, reintentos y bytes de shuffle, mas gauges de
+             la cola, los workers vivos y las tareas en ejecucion que se
+             calculan en cada lectura.
+*/
+
+package master
+
+import (
+	"mini-spark/internal/common"
+	"mini-spark/internal/metrics"
+	"time"
+)
+
+// masterMetrics series que el Master actualiza al cambiar de estado
+type masterMetrics struct {
+	jobsSubmitted *metrics.CounterVec   // Jobs aceptados por pool
+	jobsFinished  *metrics.CounterVec   // Jobs terminados por estado final
+	taskDuration  *metrics.HistogramVec // Duracion de tareas completadas por operacion
+	tasksFailed   *metrics.CounterVec   // Intentos fallidos por operacion y clase de error
+	taskRetries   *metrics.CounterVec   // Reintentos encolados por operacion
+	shuffleBytes  *metrics.CounterVec   // Bytes leidos por tareas no-source por operacion
+}
+
+// newMasterMetrics - Registra las metricas del Master
+// Descripcion: Los gauges leen el estado con m.mu al momento del scrape.
+func newMasterMetrics(m *Master) (*metrics.Registry, *masterMetrics) {
+	reg := metrics.NewRegistry()
+	mm := &masterMetrics{
+		jobsSubmitted: reg.NewCounter("minispark_jobs_submitted_total", "Jobs aceptados por el Master.", "pool"),
+		jobsFinished:  reg.NewCounter("minispark_jobs_finished_total", "Jobs terminados por estado final (COMPLETED, FAILED, CANCELLED).", "status"),
+		taskDuration:  reg.NewHistogram("minispark_task_duration_seconds", "Duracion de las tareas completadas, desde la asignacion hasta el reporte.", nil, "op"),
+		tasksFailed:   reg.NewCounter("minispark_task_failures_total", "Intentos de tarea reportados como fallidos.", "op", "class"),
+		taskRetries:   reg.NewCounter("minispark_task_retries_total", "Tareas reencoladas para un nuevo intento.", "op"),
+		shuffleBytes:  reg.NewCounter("minispark_shuffle_bytes_total", "Bytes de entrada leidos por tareas que consumen la salida de otra etapa.", "op"),
+	}
+	reg.NewGaugeFunc("minispark_queue_depth", "Tareas pendientes en la cola del scheduler.", func() float64 {
+		return float64(m.Pending.Len())
+	})
+	reg.NewGaugeFunc("minispark_workers_live", "Workers UP registrados.", func() float64 {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		n := 0
+		for _, w := range m.Workers {
+			if w.Status == "UP" {
+				n++
+			}
+		}
+		return float64(n)
+	})
+	reg.NewGaugeFunc("minispark_tasks_running", "Tareas asignadas a workers y aun sin reportar.", func() float64 {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return float64(len(m.RunningTasks))
+	})
+	reg.NewGaugeFunc("minispark_jobs_running", "Jobs en estado RUNNING.", func() float64 {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		n := 0
+		for _, job := range m.Jobs {
+			if job.Status == "RUNNING" {
+				n++
+			}
+		}
+		return float64(n)
+	})
+	return reg, mm
+}
+
+// observeTaskCompleted - Registra duracion y bytes de shuffle de una tarea exitosa
+// Entrada: op - operacion de la tarea ("" si el Master ya no la conoce),
+//
+//	elapsed - tiempo desde la asignacion, usage - recursos reportados por el worker
+func (mm *masterMetrics) observeTaskCompleted(op string, elapsed time.Duration, usage *common.TaskUsage) {
+	if op == "" {
+		op = "unknown"
+	}
+	if elapsed > 0 {
+		mm.taskDuration.With(op).Observe(elapsed.Seconds())
+	}
+	if usage != nil && !common.IsSourceOp(op) {
+		mm.shuffleBytes.With(op).Add(float64(usage.InputBytes))
+	}
+}
+
+// nodeOp - Operacion de un nodo del DAG de un job ("" si no existe)
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) nodeOp(jobID, nodeID string) string {
+	job, ok := m.Jobs[jobID]
+	if !ok {
+		return ""
+	}
+	for _, node := range job.Graph.Nodes {
+		if node.ID == nodeID {
+			return node.Op
+		}
+	}
+	return ""
+}
@@ -0,0 +1,195 @@
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: placement.go
+Descripcion: Politicas de colocacion de tareas en workers.
+             Define la interfaz PlacementPolicy usada por SchedulerLoop
+             y dos implementaciones: round-robin (comportamiento original)
+             y resource-aware, que usa slots libres, memoria y tareas
+             activas reportadas en los heartbeats.
+*/
+
+package master
+
+import (
+	"fmt"
+	"mini-spark/internal/common"
+	"mini-spark/internal/utils"
+	"sort"
+	"time"
+)
+
+// WorkerLoad vista de carga de un worker al momento de colocar una tarea
+type WorkerLoad struct {
+	Worker    *common.WorkerInfo // Worker candidato (estado UP)
+	Assigned  int                // Tareas asignadas por el Master y aun sin reportar
+	FreeSlots int                // Slots libres: Slots - max(Assigned, ActiveTasks del heartbeat)
+}
+
+// PlacementPolicy decide en que worker se ejecuta una tarea
+// Descripcion: Select recibe solo workers con slots libres; si retorna
+//
+//	nil la tarea se mantiene en cola hasta que se libere un slot.
+type PlacementPolicy interface {
+	Name() string
+	Select(task common.Task, candidates []WorkerLoad) *common.WorkerInfo
+}
+
+// NewPlacementPolicy - Construye una politica por nombre
+// Entrada: name - "resource" o "round_robin", memoryLimit - bytes de memoria residente
+//
+//	a partir de los cuales un worker se considera saturado (0 = sin limite)
+//
+// Salida: PlacementPolicy o error si el nombre no existe
+func NewPlacementPolicy(name string, memoryLimit uint64) (PlacementPolicy, error) {
+	switch name {
+	case "", "resource":
+		return &ResourceAwarePolicy{MemoryLimit: memoryLimit}, nil
+	case "round_robin":
+		return &RoundRobinPolicy{}, nil
+	}
+	return nil, fmt.Errorf("politica de colocacion desconocida: %s", name)
+}
+
+// RoundRobinPolicy rota entre los workers con slots libres
+type RoundRobinPolicy struct {
+	next int // Indice round-robin para la siguiente asignacion
+}
+
+// Name - Nombre de la politica
+func (p *RoundRobinPolicy) Name() string { return "round_robin" }
+
+// Select - Elige el siguiente worker en orden de ID
+func (p *RoundRobinPolicy) Select(task common.Task, candidates []WorkerLoad) *common.WorkerInfo {
+	if len(candidates) == 0 {
+		return nil
+	}
+	w := candidates[p.next%len(candidates)].Worker
+	p.next++
+	return w
+}
+
+// ResourceAwarePolicy prefiere workers con mas slots libres y menos memoria
+type ResourceAwarePolicy struct {
+	MemoryLimit uint64 // Bytes de memoria residente maximos por worker (0 = sin limite)
+}
+
+// Name - Nombre de la politica
+func (p *ResourceAwarePolicy) Name() string { return "resource" }
+
+// Select - Elige el worker menos cargado
+// Descripcion: Descarta workers sobre MemoryLimit y ordena por:
+//
+//  1. mas slots libres, 2. menor memoria en uso, 3. menos tareas activas.
+func (p *ResourceAwarePolicy) Select(task common.Task, candidates []WorkerLoad) *common.WorkerInfo {
+	var eligible []WorkerLoad
+	for _, c := range candidates {
+		if p.MemoryLimit > 0 && c.Worker.Metrics.MemoryUsage >= p.MemoryLimit {
+			continue
+		}
+		eligible = append(eligible, c)
+	}
+	if len(eligible) == 0 {
+		return nil
+	}
+	sort.SliceStable(eligible, func(i, j int) bool {
+		a, b := eligible[i], eligible[j]
+		if a.FreeSlots != b.FreeSlots {
+			return a.FreeSlots > b.FreeSlots
+		}
+		if a.Worker.Metrics.MemoryUsage != b.Worker.Metrics.MemoryUsage {
+			return a.Worker.Metrics.MemoryUsage < b.Worker.Metrics.MemoryUsage
+		}
+		return a.Worker.Metrics.ActiveTasks < b.Worker.Metrics.ActiveTasks
+	})
+	return eligible[0].Worker
+}
+
+// workerLoads - Calcula la carga de cada worker UP con slots libres
+// Salida: candidatos ordenados por ID (orden estable para las politicas)
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) workerLoads() []WorkerLoad {
+	assigned := make(map[string]int)
+	for _, wID := range m.TaskAssignments {
+		assigned[wID]++
+	}
+
+	var loads []WorkerLoad
+	for _, w := range m.Workers {
+		if w.Status != "UP" {
+			continue
+		}
+		slots := w.Slots
+		if slots <= 0 {
+			slots = common.DefaultWorkerSlots
+		}
+		// El heartbeat puede ir atrasado respecto a las asignaciones del Master
+		busy := assigned[w.ID]
+		if w.Metrics.ActiveTasks > busy {
+			busy = w.Metrics.ActiveTasks
+		}
+		if free := slots - busy; free > 0 {
+			loads = append(loads, WorkerLoad{Worker: w, Assigned: assigned[w.ID], FreeSlots: free})
+		}
+	}
+	sort.Slice(loads, func(i, j int) bool { return loads[i].Worker.ID < loads[j].Worker.ID })
+	return loads
+}
+
+// defaultBusyBackoff espera ante un 429 sin retry_after_ms
+const defaultBusyBackoff = 500 * time.Millisecond
+
+// markWorkerBusy - Registra que un worker rechazo una tarea por falta de slots
+// Entrada: workerID - worker saturado, busy - capacidad reportada (429)
+// Salida: ninguna (void)
+// Descripcion: Actualiza slots y tareas activas con lo reportado por el
+//
+//	worker (el heartbeat puede ir atrasado) y no le envia tareas hasta
+//	que pase RetryAfterMs (o defaultBusyBackoff).
+//
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) markWorkerBusy(workerID string, busy common.BusyResponse) {
+	w, ok := m.Workers[workerID]
+	if !ok {
+		return
+	}
+	if busy.Slots > 0 {
+		w.Slots = busy.Slots
+	}
+	if busy.ActiveTasks > w.Metrics.ActiveTasks {
+		w.Metrics.ActiveTasks = busy.ActiveTasks
+	}
+	backoff := time.Duration(busy.RetryAfterMs) * time.Millisecond
+	if backoff <= 0 {
+		backoff = defaultBusyBackoff
+	}
+	m.busyUntil[workerID] = time.Now().Add(backoff)
+	utils.LogJSON("WARN", "Worker saturado, reencolando tarea", map[string]interface{}{
+		"worker_id":    workerID,
+		"slots":        busy.Slots,
+		"active_tasks": busy.ActiveTasks,
+		"backoff_secs": backoff.Seconds(),
+	})
+}
+
+// isBusy - true si el worker rechazo tareas y su espera no vencio
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) isBusy(workerID string) bool {
+	until, ok := m.busyUntil[workerID]
+	if !ok {
+		return false
+	}
+	if time.Now().Before(until) {
+		return true
+	}
+	delete(m.busyUntil, workerID)
+	return false
+}
+
+// notifySlotFreed - Despierta al scheduler si espera un slot libre
+// Descripcion: No bloqueante; multiples avisos se colapsan en uno.
+func (m *Master) notifySlotFreed() {
+	select {
+	case m.slotFreed <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,169 @@
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: queue.go
+Descripcion: Cola de tareas pendientes del Master.
+             Reemplaza al canal con buffer: no tiene limite (encolar nunca
+             bloquea, aunque se haga con m.mu tomado), mantiene las tareas
+             ordenadas por prioridad del job y orden de llegada, permite
+             inspeccionarla y quitar las tareas de un job cancelado, y
+             despierta al scheduler al recibir trabajo nuevo.
+*/
+
+package master
+
+import (
+	"mini-spark/internal/common"
+	"sort"
+	"sync"
+)
+
+// queueEntry tarea pendiente con su prioridad y orden de llegada
+type queueEntry struct {
+	task     common.Task
+	priority int
+	seq      uint64
+}
+
+// PendingQueue cola de tareas a la espera de un worker
+// Tiene su propio mutex: puede usarse con o sin m.mu tomado
+type PendingQueue struct {
+	mu      sync.Mutex
+	entries []queueEntry  // Ordenadas por prioridad (desc) y llegada
+	seq     uint64        // Contador de llegada
+	wake    chan struct{} // Aviso al scheduler: hay tareas nuevas
+}
+
+// NewPendingQueue - Constructor de la cola de tareas pendientes
+func NewPendingQueue() *PendingQueue {
+	return &PendingQueue{wake: make(chan struct{}, 1)}
+}
+
+// Push - Encola una tarea
+// Entrada: task - tarea a colocar, priority - prioridad de su job
+// Salida: ninguna (void); nunca bloquea
+// Descripcion: Inserta detras de las tareas de igual o mayor prioridad
+//
+//	y despierta al scheduler.
+func (q *PendingQueue) Push(task common.Task, priority int) {
+	q.mu.Lock()
+	q.seq++
+	i := sort.Search(len(q.entries), func(i int) bool { return q.entries[i].priority < priority })
+	q.entries = append(q.entries, queueEntry{})
+	copy(q.entries[i+1:], q.entries[i:])
+	q.entries[i] = queueEntry{task: task, priority: priority, seq: q.seq}
+	q.mu.Unlock()
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Tasks - Copia de las tareas pendientes en orden de la cola
+func (q *PendingQueue) Tasks() []common.Task {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	tasks := make([]common.Task, len(q.entries))
+	for i, e := range q.entries {
+		tasks[i] = e.task
+	}
+	return tasks
+}
+
+// Len - Numero de tareas pendientes
+func (q *PendingQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.entries)
+}
+
+// Remove - Quita las tareas cuyo ID esta en ids
+// Salida: numero de tareas quitadas
+func (q *PendingQueue) Remove(ids map[string]bool) int {
+	return q.removeWhere(func(t common.Task) bool { return ids[t.ID] })
+}
+
+// RemoveJob - Quita todas las tareas pendientes de un job (ej: job cancelado)
+// Salida: numero de tareas quitadas
+func (q *PendingQueue) RemoveJob(jobID string) int {
+	return q.removeWhere(func(t common.Task) bool { return t.JobID == jobID })
+}
+
+// removeWhere - Quita las tareas que cumplen match conservando el orden
+func (q *PendingQueue) removeWhere(match func(common.Task) bool) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	kept := q.entries[:0]
+	removed := 0
+	for _, e := range q.entries {
+		if match(e.task) {
+			removed++
+			continue
+		}
+		kept = append(kept, e)
+	}
+	// Liberar referencias de las posiciones sobrantes
+	for i := len(kept); i < len(q.entries); i++ {
+		q.entries[i] = queueEntry{}
+	}
+	q.entries = kept
+	return removed
+}
+
+// Wait - Canal que recibe un aviso cuando se encola una tarea
+// Descripcion: Varios Push seguidos se colapsan en un solo aviso.
+func (q *PendingQueue) Wait() <-chan struct{} {
+	return q.wake
+}
+
+// enqueue - Encola una tarea con la prioridad de su job
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) enqueue(task common.Task) {
+	m.Pending.Push(task, m.jobPriority(task.JobID))
+}
+
+// jobPriority - Prioridad de un job (0 si no existe)
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) jobPriority(jobID string) int {
+	if job, ok := m.Jobs[jobID]; ok {
+		return job.Priority
+	}
+	return 0
+}
+
+// jobFinished - true si el job existe y ya no esta en ejecucion
+// Descripcion: Sus tareas pendientes (reintentos, copias) se descartan.
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) jobFinished(jobID string) bool {
+	job, ok := m.Jobs[jobID]
+	return ok && (job.Status == "CANCELLED" || job.Status == "FAILED" || job.Status == "COMPLETED")
+}
+
+// stopJobTasks - Detiene las tareas de un job que dejo de ejecutarse
+// Entrada: jobID - job cancelado o fallido, reason - motivo enviado al worker
+// Salida: tareas quitadas de la cola y tareas en ejecucion canceladas
+// Descripcion: Las tareas en ejecucion se marcan canceladas y se pide a
+//
+//	sus workers abortarlas para liberar los slots. Sus reportes tardios,
+//	incluso los exitosos, se rechazan porque el job ya termino (ver
+//	CompleteTaskHandler): debe llamarse despues de fijar el Status final.
+//
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) stopJobTasks(jobID, reason string) (removed, running int) {
+	removed = m.Pending.RemoveJob(jobID)
+	for id, task := range m.RunningTasks {
+		if task.JobID != jobID {
+			continue
+		}
+		workerID := m.TaskAssignments[id]
+		delete(m.RunningTasks, id)
+		delete(m.TaskAssignments, id)
+		delete(m.TaskStarted, id)
+		m.cancelled[id] = jobID
+		if wk, ok := m.Workers[workerID]; ok {
+			go m.cancelOnWorker(wk, id, reason)
+		}
+		running++
+	}
+	return removed, running
+}
@@ -0,0 +1,121 @@
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: retry.go
+Descripcion: Politica de reintentos de tareas del Master.
+             Resuelve la politica efectiva (nodo > job > default), calcula
+             la espera exponencial con jitter antes de reencolar, y falla
+             el job de inmediato ante errores clasificados como FATAL,
+             registrando la causa raiz.
+*/
+
+package master
+
+import (
+	"fmt"
+	"math/rand"
+	"mini-spark/internal/common"
+	"mini-spark/internal/utils"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// resolveRetry - Politica de reintentos efectiva de un nodo
+// Entrada: job - job del nodo (puede ser nil), node - nodo del DAG
+// Salida: politica del nodo, completada con la del job y la default
+func resolveRetry(job *common.Job, node common.DAGNode) common.RetryPolicy {
+	policy := common.RetryPolicy{}
+	if node.Retry != nil {
+		policy = *node.Retry
+	}
+	if job != nil && job.Retry != nil {
+		policy = policy.Merge(*job.Retry)
+	}
+	return policy.Merge(common.DefaultRetryPolicy)
+}
+
+// RetryDelay - Espera antes del siguiente intento
+// Entrada: policy - politica efectiva, attempt - intento que acaba de fallar (1..N)
+// Salida: BackoffMs * 2^(attempt-1), con tope MaxBackoffMs y variacion +-Jitter
+func RetryDelay(policy common.RetryPolicy, attempt int) time.Duration {
+	policy = policy.Merge(common.DefaultRetryPolicy)
+	delay := float64(policy.BackoffMs)
+	for i := 1; i < attempt && delay < float64(policy.MaxBackoffMs); i++ {
+		delay *= 2
+	}
+	if delay > float64(policy.MaxBackoffMs) {
+		delay = float64(policy.MaxBackoffMs)
+	}
+	if policy.Jitter > 0 {
+		delay *= 1 + policy.Jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(delay) * time.Millisecond
+}
+
+// retryOrFail - Reintenta una tarea fallida o falla su job
+// Entrada: task - intento fallido, workerID - worker donde fallo,
+//
+//	class - RETRYABLE|FATAL, cause - mensaje de error
+//
+// Salida: ninguna (void)
+// Descripcion: FATAL o reintentos agotados marcan el job FAILED con la
+//
+//	causa raiz. En otro caso reencola la tarea (Attempt+1, nuevo ID,
+//	excluyendo el worker que fallo) tras RetryDelay.
+//
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) retryOrFail(task common.Task, workerID, class, cause string) {
+	policy := task.Retry.Merge(common.DefaultRetryPolicy)
+	if class == common.ErrorClassFatal {
+		m.failJob(task.JobID, fmt.Sprintf("nodo %s, partición %d: %s (fallo no reintentable)", task.NodeID, task.PartitionID, cause))
+		return
+	}
+	if task.Attempt >= policy.MaxAttempts {
+		m.failJob(task.JobID, fmt.Sprintf("nodo %s, partición %d: %s (%d intentos agotados)", task.NodeID, task.PartitionID, cause, task.Attempt))
+		return
+	}
+
+	delay := RetryDelay(policy, task.Attempt)
+	task.Attempt++
+	task.ID = uuid.New().String()
+	task.TraceParent = newTaskTrace(m.Jobs[task.JobID])
+	task.PreferredWorkers = nil
+	if workerID != "" && !containsString(task.ExcludedWorkers, workerID) {
+		task.ExcludedWorkers = append(task.ExcludedWorkers, workerID)
+	}
+	utils.LogJSON("WARN", "Reintentando tarea", map[string]interface{}{
+		"node":       task.NodeID,
+		"part":       task.PartitionID,
+		"attempt":    task.Attempt,
+		"delay_secs": delay.Seconds(),
+	})
+	m.stats.taskRetries.With(task.Op).Inc()
+	m.recordTaskEvent(common.EventTaskRetried, task, "", fmt.Sprintf("reintento en %s: %s", delay.Round(time.Millisecond), cause))
+	priority := m.jobPriority(task.JobID)
+	time.AfterFunc(delay, func() {
+		// La espera en cola se cuenta desde el fin del backoff
+		task.QueuedAt = time.Now()
+		m.Pending.Push(task, priority)
+	})
+}
+
+// failJob - Marca un job como FAILED registrando la causa raiz
+// Descripcion: Solo la primera causa se conserva. Sus tareas pendientes
+//
+//	se descartan y las que siguen en ejecucion se cancelan en sus
+//	workers (mismo desmontaje que CancelJobHandler). Debe llamarse con
+//	m.mu tomado.
+func (m *Master) failJob(jobID, cause string) {
+	job, ok := m.Jobs[jobID]
+	if !ok || job.Status != "RUNNING" {
+		return
+	}
+	utils.LogJSON("ERROR", "Job fallido", map[string]interface{}{"job_id": jobID, "cause": cause})
+	job.Status = "FAILED"
+	job.Completed = time.Now()
+	job.Error = cause
+	m.finishJob(job)
+	if _, running := m.stopJobTasks(jobID, "job fallido"); running > 0 {
+		m.notifySlotFreed()
+	}
+}
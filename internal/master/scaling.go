@@ -0,0 +1,308 @@
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: scaling.go
+Descripcion: Escalado dinamico de workers.
+             El Master calcula señales de escalado (tareas en cola,
+             workers ociosos, tiempo estimado para vaciar la cola) y
+             pide a un Scaler intercambiable mas workers cuando la cola
+             crece o retira los ociosos (decommission) cuando no hay
+             trabajo, respetando un minimo y un maximo de workers.
+*/
+
+package master
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"mini-spark/internal/common"
+	"mini-spark/internal/utils"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultTaskEstimate duracion supuesta de una tarea si aun no termino ninguna
+const defaultTaskEstimate = time.Second
+
+// Scaler agrega o retira workers del cluster
+// Descripcion: ScaleUp pide n workers nuevos (se registran solos en el
+//
+//	Master). ScaleDown recibe workers a los que el Master ya ordeno
+//	drenar; la implementacion solo libera sus recursos (procesos, VMs).
+type Scaler interface {
+	Name() string
+	ScaleUp(n int) error
+	ScaleDown(workers []common.WorkerInfo) error
+}
+
+// ScalingConfig parametros del escalado dinamico
+type ScalingConfig struct {
+	MinWorkers    int           // Minimo de workers UP (se completa aunque no haya cola)
+	MaxWorkers    int           // Maximo de workers UP
+	MaxStep       int           // Workers agregados o retirados como maximo por accion
+	TargetBacklog time.Duration // Se escala si vaciar la cola tomaria mas que esto
+	IdleTimeout   time.Duration // Tiempo sin tareas para considerar ocioso un worker
+	Cooldown      time.Duration // Espera minima entre acciones (los workers tardan en registrarse)
+	Interval      time.Duration // Periodo de evaluacion
+}
+
+// DefaultScalingConfig - Configuracion por defecto del escalado
+func DefaultScalingConfig() ScalingConfig {
+	return ScalingConfig{
+		MinWorkers:    1,
+		MaxWorkers:    8,
+		MaxStep:       2,
+		TargetBacklog: 30 * time.Second,
+		IdleTimeout:   time.Minute,
+		Cooldown:      30 * time.Second,
+		Interval:      5 * time.Second,
+	}
+}
+
+// NewScaler - Construye un scaler por nombre
+// Entrada: name - "none" o "local", workerBin - ejecutable del worker,
+//
+//	masterURL - URL que usaran los workers lanzados, logDir - logs de
+//	los procesos, slots - slots de cada worker lanzado
+//
+// Salida: Scaler (nil para "none") o error si el nombre no existe
+func NewScaler(name, workerBin, masterURL, logDir string, slots int) (Scaler, error) {
+	switch name {
+	case "", "none":
+		return nil, nil
+	case "local":
+		return NewLocalProcessScaler(workerBin, masterURL, logDir, slots), nil
+	}
+	return nil, fmt.Errorf("scaler desconocido: %s", name)
+}
+
+// ScalingLoop - Evalua periodicamente si escalar el cluster
+// Entrada: ninguna
+// Salida: ninguna (void), loop infinito
+// Descripcion: Cada Scaling.Interval ejecuta Autoscale (si hay Scaler).
+func (m *Master) ScalingLoop() {
+	for {
+		time.Sleep(m.Scaling.Interval)
+		m.Autoscale()
+	}
+}
+
+// Autoscale - Decide y ejecuta una accion de escalado
+// Entrada: ninguna
+// Salida: ninguna (void); los errores del Scaler solo se loguean
+// Descripcion: Con menos de MinWorkers agrega los que faltan. Con tareas
+//
+//	en cola y un backlog estimado mayor que TargetBacklog (o sin
+//	workers) agrega los necesarios para cumplirlo. Sin cola, retira los
+//	workers ociosos por encima de MinWorkers via decommission.
+//	Entre acciones espera Cooldown.
+func (m *Master) Autoscale() {
+	if m.Scaler == nil {
+		return
+	}
+	m.mu.Lock()
+	now := time.Now()
+	sig := m.scalingSignals(now)
+	cfg := m.Scaling
+	if !m.lastScaleAt.IsZero() && now.Sub(m.lastScaleAt) < cfg.Cooldown {
+		m.mu.Unlock()
+		return
+	}
+
+	up := 0
+	var down []common.WorkerInfo
+	switch {
+	case sig.Workers < cfg.MinWorkers:
+		up = cfg.MinWorkers - sig.Workers
+	case sig.PendingTasks > 0 && (sig.Workers == 0 || sig.BacklogSecs > cfg.TargetBacklog.Seconds()):
+		up = workersForBacklog(sig, cfg.TargetBacklog)
+	case sig.PendingTasks == 0:
+		for _, id := range sig.IdleWorkers {
+			if sig.Workers-len(down) <= cfg.MinWorkers || (cfg.MaxStep > 0 && len(down) >= cfg.MaxStep) {
+				break
+			}
+			w := m.Workers[id]
+			m.startDecommission(w, "autoscale")
+			down = append(down, *w)
+		}
+	}
+	if up > cfg.MaxWorkers-sig.Workers {
+		up = cfg.MaxWorkers - sig.Workers
+	}
+	if cfg.MaxStep > 0 && up > cfg.MaxStep {
+		up = cfg.MaxStep
+	}
+	if up <= 0 && len(down) == 0 {
+		m.mu.Unlock()
+		return
+	}
+	if up > 0 {
+		m.lastScaleAction = fmt.Sprintf("scale_up %d", up)
+	} else {
+		ids := make([]string, len(down))
+		for i, w := range down {
+			ids[i] = w.ID
+		}
+		m.lastScaleAction = "scale_down " + strings.Join(ids, ",")
+	}
+	m.lastScaleAt = now
+	action := m.lastScaleAction
+	m.mu.Unlock()
+
+	utils.LogJSON("INFO", "Escalado de workers", map[string]interface{}{
+		"action":        action,
+		"scaler":        m.Scaler.Name(),
+		"pending_tasks": sig.PendingTasks,
+		"workers":       sig.Workers,
+		"backlog_secs":  sig.BacklogSecs,
+	})
+	var err error
+	if up > 0 {
+		err = m.Scaler.ScaleUp(up)
+	} else {
+		err = m.Scaler.ScaleDown(down)
+	}
+	if err != nil {
+		utils.LogJSON("ERROR", "Fallo del scaler", map[string]interface{}{
+			"action": action,
+			"error":  err.Error(),
+		})
+	}
+}
+
+// workersForBacklog - Workers a agregar para vaciar la cola dentro de target
+// Descripcion: Estima los slots necesarios (cola * duracion media / target)
+//
+//	y los convierte en workers segun los slots promedio por worker.
+//	Siempre retorna al menos 1.
+func workersForBacklog(sig common.ScalingSignals, target time.Duration) int {
+	slotsPerWorker := common.DefaultWorkerSlots
+	if sig.Workers > 0 {
+		slotsPerWorker = sig.TotalSlots / sig.Workers
+	}
+	if target <= 0 || slotsPerWorker <= 0 {
+		return 1
+	}
+	needed := float64(sig.PendingTasks) * sig.AvgTaskSecs / target.Seconds()
+	n := int(math.Ceil((needed - float64(sig.TotalSlots)) / float64(slotsPerWorker)))
+	if n < 1 {
+		return 1
+	}
+	return n
+}
+
+// scalingSignals - Calcula las señales de escalado
+// Entrada: now - instante de la evaluacion (para el tiempo ocioso)
+// Salida: ScalingSignals
+// Descripcion: Actualiza desde cuando esta ocioso cada worker UP (sin
+//
+//	tareas asignadas ni activas segun su heartbeat).
+//
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) scalingSignals(now time.Time) common.ScalingSignals {
+	assigned := make(map[string]int)
+	for _, wID := range m.TaskAssignments {
+		assigned[wID]++
+	}
+	sig := common.ScalingSignals{
+		PendingTasks: m.Pending.Len(),
+		RunningTasks: len(m.RunningTasks),
+		IdleWorkers:  []string{},
+	}
+	for id := range m.idleSince {
+		if w, ok := m.Workers[id]; !ok || w.Status != "UP" {
+			delete(m.idleSince, id)
+		}
+	}
+	for id, w := range m.Workers {
+		if w.Status != "UP" {
+			continue
+		}
+		slots := w.Slots
+		if slots <= 0 {
+			slots = common.DefaultWorkerSlots
+		}
+		busy := assigned[id]
+		if w.Metrics.ActiveTasks > busy {
+			busy = w.Metrics.ActiveTasks
+		}
+		sig.Workers++
+		sig.TotalSlots += slots
+		if slots > busy {
+			sig.FreeSlots += slots - busy
+		}
+		if busy > 0 {
+			delete(m.idleSince, id)
+			continue
+		}
+		since, ok := m.idleSince[id]
+		if !ok {
+			since = now
+			m.idleSince[id] = now
+		}
+		if now.Sub(since) >= m.Scaling.IdleTimeout {
+			sig.IdleWorkers = append(sig.IdleWorkers, id)
+		}
+	}
+	sort.Strings(sig.IdleWorkers)
+
+	sig.AvgTaskSecs = m.avgTaskDuration().Seconds()
+	slots := sig.TotalSlots
+	if slots == 0 {
+		slots = 1
+	}
+	sig.BacklogSecs = float64(sig.PendingTasks) * sig.AvgTaskSecs / float64(slots)
+	return sig
+}
+
+// avgTaskDuration - Duracion media de las tareas terminadas
+// Salida: media de StageDurations (jobs en curso) y de las tareas de jobs
+//
+//	terminados, o defaultTaskEstimate si no hay datos
+//
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) avgTaskDuration() time.Duration {
+	total := m.finishedTaskTime
+	count := m.finishedTaskCount
+	for _, durations := range m.StageDurations {
+		for _, d := range durations {
+			total += d
+			count++
+		}
+	}
+	if count == 0 {
+		return defaultTaskEstimate
+	}
+	return total / time.Duration(count)
+}
+
+// ScalingHandler - Consulta las señales y el estado del escalado
+// Entrada: w - response writer, r - request GET
+// Salida: HTTP 200 con ScalingStatusResponse JSON, 405 si no es GET
+func (m *Master) ScalingHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	m.mu.Lock()
+	resp := common.ScalingStatusResponse{
+		Scaler:     "none",
+		MinWorkers: m.Scaling.MinWorkers,
+		MaxWorkers: m.Scaling.MaxWorkers,
+		Signals:    m.scalingSignals(time.Now()),
+		LastAction: m.lastScaleAction,
+	}
+	if m.Scaler != nil {
+		resp.Scaler = m.Scaler.Name()
+	}
+	if !m.lastScaleAt.IsZero() {
+		at := m.lastScaleAt
+		resp.LastActionAt = &at
+	}
+	m.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
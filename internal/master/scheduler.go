@@ -3,7 +3,8 @@ Autores: Steven Sequeira Araya, Jefferson Salas Cordero
 Nombre del archivo: scheduler.go
 Descripcion: Planificador de tareas del nodo Master.
              Implementa algoritmo topologico para ordenar ejecucion de DAG,
-             asignacion round-robin de tareas a workers, y deteccion de
+             asignacion de tareas a workers segun la PlacementPolicy
+             (ver placement.go), y deteccion de
              dependencias completadas para desbloquear nodos.
 */
 
@@ -50,92 +51,246 @@ func (m *Master) ScheduleSourceTasks(job *common.Job) {
 		if inDegree[node.ID] == 0 {
 			// NODO SOURCE: Crear N tareas (una por partición)
 			for i := 0; i < parallelism; i++ {
-				m.queueTask(job.ID, node, []string{}, i, parallelism)
+				m.queueTask(job.ID, node, []string{}, nil, i, parallelism)
 			}
 		}
 	}
 }
 
 // queueTask - Crea y encola una tarea para ejecucion
-// Entrada: jobID - ID del job, node - nodo del DAG, inputs - archivos de entrada
+// Entrada: jobID - ID del job, node - nodo del DAG, inputs - archivos de entrada,
+//
+//	preferred - workers que tienen las entradas en disco local (nil si no aplica)
+//
 // Salida: ninguna (void)
 // Descripcion: Construye objeto Task, actualiza estado a SCHEDULED,
 //
-//	y lo inserta en TaskQueue para asignacion a workers.
-func (m *Master) queueTask(jobID string, node common.DAGNode, inputs []string, partID, totalParts int) {
+//	y lo inserta en la cola pendiente para asignacion a workers.
+//
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) queueTask(jobID string, node common.DAGNode, inputs, preferred []string, partID, totalParts int) {
 	// Marcar estado de la partición específica
 	m.setPartitionStatus(jobID, node.ID, partID, "SCHEDULED")
 	
 	// Si alguna partición corre, el nodo está RUNNING
 	m.setNodeStatus(jobID, node.ID, "RUNNING")
 
+	// Timeout y reintentos efectivos: el nodo tiene prioridad sobre el job
+	job := m.Jobs[jobID]
+	timeoutSecs := node.TimeoutSecs
+	if job != nil && timeoutSecs == 0 {
+		timeoutSecs = job.TaskTimeoutSecs
+	}
+
 	task := common.Task{
-		ID:              uuid.New().String(),
-		JobID:           jobID,
-		NodeID:          node.ID,
-		Op:              node.Op,
-		Fn:              node.Fn,
-		Args:            []string{node.Path},
-		InputFiles:      inputs,
-		PartitionID:     partID,     // Asignamos ID
-		TotalPartitions: totalParts, // Total
-		Attempt:         1,
+		ID:               uuid.New().String(),
+		JobID:            jobID,
+		NodeID:           node.ID,
+		Op:               node.Op,
+		Fn:               node.Fn,
+		Args:             []string{node.Path},
+		InputFiles:       inputs,
+		PartitionID:      partID,     // Asignamos ID
+		TotalPartitions:  totalParts, // Total
+		Attempt:          1,
+		PreferredWorkers: preferred,
+		TimeoutSecs:      timeoutSecs,
+		Retry:            resolveRetry(job, node),
+		Constraints:      node.Constraints,
+		TraceParent:      newTaskTrace(job),
+		QueuedAt:         time.Now(),
+		Columns:          node.Columns,
+		GroupBy:          node.GroupBy,
+		Aggregates:       node.Aggregates,
+		PartitionBy:      node.PartitionBy,
+		OrderBy:          node.OrderBy,
+		Limit:            node.Limit,
+		Where:            node.Where,
+		Select:           node.Select,
 	}
 
-	m.TaskQueue <- task
+	m.enqueue(task)
+	m.recordTaskEvent(common.EventTaskQueued, task, "", "")
 	utils.LogJSON("INFO", "Tarea encolada", map[string]interface{}{
-		"task_id": task.ID, 
-		"node": node.ID, 
-		"part": partID,
+		"task_id": task.ID,
+		"node":    node.ID,
+		"part":    partID,
 	})
 }
 
 // SchedulerLoop - Loop principal de asignacion de tareas a workers
-// Entrada: ninguna (lee de TaskQueue)
-// Salida: ninguna (void), loop infinito
-// Descripcion: Consume tareas del TaskQueue, selecciona worker disponible
+// Entrada: ninguna (lee de m.Pending)
+// Salida: ninguna (void), corre hasta Stop
+// Descripcion: Coloca las tareas de la cola pendiente con la
 //
-//	usando round-robin, registra asignacion y envia tarea via HTTP.
-//	Si no hay workers, reencola tarea y espera.
+//	PlacementPolicy configurada entre los workers UP con slots libres,
+//	repartiendo los slots entre jobs segun su pool y prioridad
+//	(ver fairshare.go). Las tareas que no pueden colocarse (sin slots, o
+//	esperando al worker dueño de sus entradas) siguen en la cola y se
+//	reintentan al encolarse trabajo nuevo, liberarse un slot o volver un
+//	worker, sin bloquear a las que si pueden avanzar.
 func (m *Master) SchedulerLoop() {
-	for task := range m.TaskQueue {
-		m.mu.Lock()
-		// Filtrar workers activos (estado UP)
-		var availableWorkers []*common.WorkerInfo
-		for _, w := range m.Workers {
-			if w.Status == "UP" {
-				availableWorkers = append(availableWorkers, w)
+	waitLogged := make(map[string]bool)
+
+	for {
+		m.schedulePass(waitLogged)
+
+		// Sin tareas retenidas solo se espera un aviso
+		var retry <-chan time.Time
+		if m.Pending.Len() > 0 {
+			retry = time.After(schedulerRetryInterval)
+		}
+		select {
+		case <-m.Pending.Wait():
+		case <-m.slotFreed:
+		case <-retry:
+		case <-m.stopped:
+			return
+		}
+	}
+}
+
+// Stop - Detiene SchedulerLoop
+// Descripcion: Las tareas en cola quedan sin colocar. Idempotente.
+func (m *Master) Stop() {
+	m.stopOnce.Do(func() { close(m.stopped) })
+}
+
+// schedulerRetryInterval reintento de tareas retenidas sin eventos nuevos
+// (cubre el vencimiento de la espera de localidad y metricas de heartbeat)
+const schedulerRetryInterval = 250 * time.Millisecond
+
+// tryPlace - Intenta colocar una tarea en un worker
+// Entrada: task - tarea a colocar
+// Salida: worker elegido (nil si debe seguir en espera) y si la colocacion es local
+// Descripcion: Descarta los ExcludedWorkers de la tarea. Si tiene
+//
+//	PreferredWorkers y no vencio LocalityWait, solo se consideran esos
+//	workers. Al colocar registra la asignacion y cuenta el acierto/fallo
+//	de localidad del job.
+func (m *Master) tryPlace(task common.Task) (*common.WorkerInfo, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// El job pudo cancelarse despues de que la pasada tomara la tarea
+	if m.jobFinished(task.JobID) {
+		return nil, false
+	}
+
+	// Workers excluidos por fallos repetidos (en el cluster o en este job),
+	// que rechazaron tareas por falta de slots (backpressure) o sin las
+	// etiquetas requeridas por el nodo
+	var candidates []WorkerLoad
+	for _, c := range m.workerLoads() {
+		if !m.isBlacklisted(c.Worker.ID, task.JobID) && !m.isBusy(c.Worker.ID) && task.Constraints.Allows(c.Worker.Labels) {
+			candidates = append(candidates, c)
+		}
+	}
+	// Un reintento puede volver a un worker excluido si no queda otro vivo
+	if len(task.ExcludedWorkers) > 0 && (task.Speculative || m.upOutside(task.ExcludedWorkers)) {
+		var allowed []WorkerLoad
+		for _, c := range candidates {
+			if !containsString(task.ExcludedWorkers, c.Worker.ID) {
+				allowed = append(allowed, c)
 			}
 		}
+		candidates = allowed
+	}
+	candidates = preferLabels(task, candidates)
+	if len(task.PreferredWorkers) > 0 && time.Since(task.QueuedAt) < m.LocalityWait {
+		var local []WorkerLoad
+		for _, c := range candidates {
+			if containsString(task.PreferredWorkers, c.Worker.ID) {
+				local = append(local, c)
+			}
+		}
+		// Mantener la tarea en espera si el dueño de los datos sigue vivo
+		if len(local) == 0 && m.anyWorkerUp(task.PreferredWorkers) {
+			return nil, false
+		}
+		if len(local) > 0 {
+			candidates = local
+		}
+	}
 
-		// Si no hay workers disponibles, reencolar y esperar
-		if len(availableWorkers) == 0 {
-			m.mu.Unlock()
-			time.Sleep(2 * time.Second)
-			m.TaskQueue <- task
-			continue
+	worker := m.Placement.Select(task, candidates)
+	if worker == nil {
+		return nil, false
+	}
+	// Registrar asignacion tarea-worker
+	m.TaskAssignments[task.ID] = worker.ID
+	m.RunningTasks[task.ID] = task
+	m.TaskStarted[task.ID] = time.Now()
+
+	local := containsString(task.PreferredWorkers, worker.ID)
+	detail := ""
+	if local {
+		detail = "local"
+	}
+	m.recordTaskEvent(common.EventTaskAssigned, task, worker.ID, detail)
+	if len(task.PreferredWorkers) > 0 {
+		if job, ok := m.Jobs[task.JobID]; ok {
+			if local {
+				job.Locality.Hits++
+			} else {
+				job.Locality.Misses++
+			}
 		}
+	}
+	return worker, local
+}
 
-		// Seleccionar worker usando round-robin
-		worker := availableWorkers[m.rrIndex%len(availableWorkers)]
-		m.rrIndex++ // Incrementar indice para siguiente asignacion
-		// Registrar asignacion tarea-worker
-		m.TaskAssignments[task.ID] = worker.ID
-		m.RunningTasks[task.ID] = task
-
-		// Loguear asignacion
-		utils.LogJSON("INFO", "Asignando tarea a worker", map[string]interface{}{
-        "task_id":    task.ID,
-        "node":       task.NodeID,
-        "part":       task.PartitionID,
-				"worker_id":  worker.ID,
-				"worker_url": worker.URL,
-    })
+// preferLabels - Reduce los candidatos a los que cumplen mas etiquetas preferidas
+// Descripcion: Si ningun candidato cumple alguna, se conservan todos
+//
+//	(las preferidas no dejan tareas en espera).
+func preferLabels(task common.Task, candidates []WorkerLoad) []WorkerLoad {
+	best := 0
+	for _, c := range candidates {
+		if p := task.Constraints.Preference(c.Worker.Labels); p > best {
+			best = p
+		}
+	}
+	if best == 0 {
+		return candidates
+	}
+	var preferred []WorkerLoad
+	for _, c := range candidates {
+		if task.Constraints.Preference(c.Worker.Labels) == best {
+			preferred = append(preferred, c)
+		}
+	}
+	return preferred
+}
 
-		m.mu.Unlock()
-		// Enviar tarea al worker en goroutine separada
-		go m.sendTask(worker, task)
+// upOutside - true si hay algun worker UP fuera de la lista excluded
+func (m *Master) upOutside(excluded []string) bool {
+	for id, w := range m.Workers {
+		if w.Status == "UP" && !containsString(excluded, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// anyWorkerUp - true si alguno de los workers indicados esta UP
+func (m *Master) anyWorkerUp(ids []string) bool {
+	for _, id := range ids {
+		if w, ok := m.Workers[id]; ok && w.Status == "UP" {
+			return true
+		}
 	}
+	return false
+}
+
+// containsString - true si s esta en list
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
 }
 
 // sendTask - Envia tarea a worker via HTTP POST
@@ -148,6 +303,7 @@ func (m *Master) sendTask(worker *common.WorkerInfo, task common.Task) {
 	// Serializar tarea a JSON
 	data, _ := json.Marshal(task)
 	// Enviar POST a worker
+	dispatched := time.Now()
 	resp, err := http.Post(worker.URL+"/task", "application/json", bytes.NewBuffer(data))
 	if err != nil {
 		// Loguear error de envio
@@ -156,14 +312,58 @@ func (m *Master) sendTask(worker *common.WorkerInfo, task common.Task) {
         "worker":  worker.ID,
         "error":   err.Error(),
     })
-		// Si falla, liberar asignacion y reencolar
+		// Si falla, liberar asignacion y reencolar solo esta tarea. Las demas
+		// tareas del worker siguen suyas: si esta caido lo detecta el
+		// HealthCheckLoop por falta de heartbeat. Mientras tanto no se le
+		// envian tareas por un backoff para no reintentar en bucle
 		m.mu.Lock()
-		delete(m.TaskAssignments, task.ID)
+		m.recordTaskEvent(common.EventTaskRejected, task, worker.ID, "fallo de envio: "+err.Error())
+		if _, ok := m.RunningTasks[task.ID]; ok {
+			delete(m.TaskAssignments, task.ID)
+			delete(m.RunningTasks, task.ID)
+			delete(m.TaskStarted, task.ID)
+			m.enqueue(task)
+		}
+		if _, ok := m.Workers[worker.ID]; ok {
+			m.busyUntil[worker.ID] = time.Now().Add(defaultBusyBackoff)
+		}
 		m.mu.Unlock()
-		m.TaskQueue <- task
+		m.notifySlotFreed()
 		return
 	}
 	defer resp.Body.Close()
+
+	// Backpressure: el worker no tiene slots libres (429) o esta drenando (503)
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		m.mu.Lock()
+		if resp.StatusCode == http.StatusTooManyRequests {
+			var busy common.BusyResponse
+			json.NewDecoder(resp.Body).Decode(&busy)
+			m.markWorkerBusy(worker.ID, busy)
+		} else if w, ok := m.Workers[worker.ID]; ok && w.Status == "UP" {
+			w.Status = "DRAINING"
+		}
+		m.recordTaskEvent(common.EventTaskRejected, task, worker.ID, resp.Status)
+		if _, ok := m.RunningTasks[task.ID]; ok {
+			delete(m.TaskAssignments, task.ID)
+			delete(m.RunningTasks, task.ID)
+			delete(m.TaskStarted, task.ID)
+			m.enqueue(task)
+		}
+		m.mu.Unlock()
+		return
+	}
+	// Aceptada: espera en la cola y demora de envio hasta el worker. Si el
+	// resultado llego antes que esta respuesta, el inicio ya no se registra
+	// para no desordenar el historial
+	m.mu.Lock()
+	if _, ok := m.RunningTasks[task.ID]; ok {
+		m.recordTaskEvent(common.EventTaskStarted, task, worker.ID, "")
+	}
+	m.mu.Unlock()
+	attrs := map[string]interface{}{"worker.id": worker.ID}
+	m.traceTaskChild(task, "queue_wait", task.QueuedAt, dispatched, attrs)
+	m.traceTaskChild(task, "scheduling_delay", dispatched, time.Now(), attrs)
 }
 
 func (m *Master) CheckAndScheduleDependents(job *common.Job) {
@@ -182,6 +382,7 @@ func (m *Master) CheckAndScheduleDependents(job *common.Job) {
 			allParentsDone := true
 			hasParents := false
 			var inputFiles []string
+			var preferred []string
 
 			for _, edge := range job.Graph.Edges {
 				if edge[1] == node.ID { // edge[0] -> node
@@ -198,18 +399,26 @@ func (m *Master) CheckAndScheduleDependents(job *common.Job) {
 					if outputs, ok := m.JobPartitionOutputs[job.ID][parentID]; ok {
 						inputFiles = append(inputFiles, outputs[i])
 					}
+					// Worker que tiene la partición en disco local
+					if owner := m.JobPartitionOwners[job.ID][parentID][i]; owner != "" && !containsString(preferred, owner) {
+						preferred = append(preferred, owner)
+					}
 				}
 			}
 
 			if hasParents && allParentsDone {
 				// Programar la partición 'i' del nodo hijo
-				m.queueTask(job.ID, node, inputFiles, i, parallelism)
+				m.queueTask(job.ID, node, inputFiles, preferred, i, parallelism)
 			}
 		}
 	}
 }
 
 func (m *Master) CheckJobCompletion(job *common.Job) {
+	// Un job fallido o cancelado no vuelve a COMPLETED
+	if job.Status != "RUNNING" {
+		return
+	}
 	allDone := true
 	parallelism := job.Parallelism
 	if parallelism < 1 { parallelism = 1 }
@@ -227,6 +436,7 @@ func (m *Master) CheckJobCompletion(job *common.Job) {
 		utils.LogJSON("INFO", "Job completado", map[string]interface{}{"job_id": job.ID})
 		job.Status = "COMPLETED"
 		job.Completed = time.Now()
+		m.finishJob(job)
 		m.SaveState()
 	}
 }
@@ -246,35 +456,55 @@ func (m *Master) HealthCheckLoop() {
 		// Revisar cada worker registrado
 		for wID, w := range m.Workers {
 			// Si worker esta UP pero sin heartbeat reciente, marcarlo DOWN
-			if w.Status == "UP" && now.Sub(w.LastHeartbeat) > 10*time.Second {
-				utils.LogJSON("ALERT", "Worker muerto", map[string]interface{}{"worker_id": wID})
-				w.Status = "DOWN"
-				// Reencolar todas las tareas asignadas a este worker
-				for tID, workerAssigned := range m.TaskAssignments {
-					if workerAssigned == wID {
-						if task, ok := m.RunningTasks[tID]; ok {
-							// Liberar recursos
-							delete(m.TaskAssignments, tID)
-							oldID := task.ID
-							// Generar nuevo ID para evitar conflictos
-							task.ID = uuid.New().String()
-							
-							// Loguear replanificacion
-							utils.LogJSON("WARN", "Replanificando tarea (Worker muerto)", map[string]interface{}{
-                  "node":          task.NodeID,
-                  "failed_worker": wID,
-                  "old_task_id":   oldID,
-                  "new_task_id":   task.ID,
-              })
-
-							delete(m.RunningTasks, tID)
-							// Reencolar tarea
-							go func(t common.Task) { m.TaskQueue <- t }(task)
-						}
-					}
-				}
+			if (w.Status == "UP" || w.Status == "DRAINING") && now.Sub(w.LastHeartbeat) > 10*time.Second {
+				m.markWorkerDown(wID, "sin heartbeat")
 			}
 		}
 		m.mu.Unlock()
 	}
 }
+
+// markWorkerDown - Marca un worker como DOWN y reencola sus tareas
+// Entrada: wID - worker caido, reason - motivo (para el log)
+// Salida: ninguna (void)
+// Descripcion: Las tareas asignadas al worker se reencolan con nuevo ID.
+//
+//	Los intentos anteriores se marcan cancelados y se pide al worker
+//	abortarlos (si sigue vivo): sus reportes tardios no fallan el job.
+//	Un heartbeat posterior lo vuelve a marcar UP.
+//
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) markWorkerDown(wID, reason string) {
+	utils.LogJSON("ALERT", "Worker muerto", map[string]interface{}{"worker_id": wID, "reason": reason})
+	worker := m.Workers[wID]
+	worker.Status = "DOWN"
+	// Reencolar todas las tareas asignadas a este worker
+	for tID, workerAssigned := range m.TaskAssignments {
+		if workerAssigned != wID {
+			continue
+		}
+		task, ok := m.RunningTasks[tID]
+		if !ok {
+			continue
+		}
+		// Liberar recursos y abortar el intento anterior
+		delete(m.TaskAssignments, tID)
+		delete(m.RunningTasks, tID)
+		delete(m.TaskStarted, tID)
+		m.cancelled[tID] = task.JobID
+		go m.cancelOnWorker(worker, tID, "worker caido ("+reason+")")
+		// Generar nuevo ID para evitar conflictos
+		task.ID = uuid.New().String()
+		task.TraceParent = newTaskTrace(m.Jobs[task.JobID])
+		m.recordTaskEvent(common.EventTaskRescheduled, task, wID, "worker caido ("+reason+"), intento anterior "+tID)
+
+		// Loguear replanificacion
+		utils.LogJSON("WARN", "Replanificando tarea (Worker muerto)", map[string]interface{}{
+			"node":          task.NodeID,
+			"failed_worker": wID,
+			"old_task_id":   tID,
+			"new_task_id":   task.ID,
+		})
+		m.enqueue(task)
+	}
+}
@@ -0,0 +1,180 @@
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: speculation.go
+Descripcion: Ejecucion especulativa de tareas rezagadas (stragglers).
+             Registra la duracion de las tareas de cada etapa (nodo del
+             DAG) y lanza un intento duplicado en otro worker para las
+             tareas que superan por mucho la mediana. El primer resultado
+             gana; el del intento perdedor se descarta.
+*/
+
+package master
+
+import (
+	"fmt"
+	"mini-spark/internal/common"
+	"mini-spark/internal/utils"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SpeculationConfig parametros de la ejecucion especulativa
+type SpeculationConfig struct {
+	Enabled    bool          // Activa el lanzamiento de intentos duplicados
+	Multiplier float64       // Una tarea es rezagada si dura > Multiplier * mediana de su etapa
+	Quantile   float64       // Fraccion de particiones de la etapa que deben haber terminado
+	MinRuntime time.Duration // Duracion minima antes de considerar especular
+	Interval   time.Duration // Periodo de revision de tareas en ejecucion
+}
+
+// DefaultSpeculationConfig - Configuracion por defecto de la especulacion
+func DefaultSpeculationConfig() SpeculationConfig {
+	return SpeculationConfig{
+		Enabled:    true,
+		Multiplier: 1.5,
+		Quantile:   0.5,
+		MinRuntime: 2 * time.Second,
+		Interval:   time.Second,
+	}
+}
+
+// stageKey - Clave de etapa (nodo del DAG dentro de un job)
+func stageKey(jobID, nodeID string) string {
+	return jobID + "/" + nodeID
+}
+
+// partitionKey - Clave de una particion de una etapa
+func partitionKey(jobID, nodeID string, partID int) string {
+	return fmt.Sprintf("%s/%s/%d", jobID, nodeID, partID)
+}
+
+// medianDuration - Mediana de una lista de duraciones (no vacia)
+func medianDuration(durations []time.Duration) time.Duration {
+	sorted := append([]time.Duration{}, durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// SpeculationLoop - Revisa periodicamente tareas rezagadas
+// Entrada: ninguna
+// Salida: ninguna (void), loop infinito
+// Descripcion: Cada Speculation.Interval ejecuta CheckStragglers.
+func (m *Master) SpeculationLoop() {
+	for {
+		time.Sleep(m.Speculation.Interval)
+		if m.Speculation.Enabled {
+			m.CheckStragglers()
+		}
+	}
+}
+
+// CheckStragglers - Lanza intentos especulativos de tareas rezagadas
+// Entrada: ninguna
+// Salida: ninguna (void)
+// Descripcion: Para cada tarea en RunningTasks cuya etapa tiene al menos
+//
+//	Quantile de sus particiones terminadas, si su tiempo en ejecucion
+//	supera Multiplier * mediana (y MinRuntime), encola una copia
+//	marcada Speculative que excluye al worker original.
+//	Cada particion se especula como maximo una vez.
+func (m *Master) CheckStragglers() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for id, task := range m.RunningTasks {
+		if task.Speculative {
+			continue
+		}
+		key := partitionKey(task.JobID, task.NodeID, task.PartitionID)
+		if _, done := m.speculated[key]; done {
+			continue
+		}
+		started, ok := m.TaskStarted[id]
+		if !ok {
+			continue
+		}
+
+		durations := m.StageDurations[stageKey(task.JobID, task.NodeID)]
+		total := task.TotalPartitions
+		if total < 1 {
+			total = 1
+		}
+		if len(durations) == 0 || float64(len(durations)) < m.Speculation.Quantile*float64(total) {
+			continue
+		}
+		elapsed := now.Sub(started)
+		median := medianDuration(durations)
+		if elapsed < m.Speculation.MinRuntime || float64(elapsed) < m.Speculation.Multiplier*float64(median) {
+			continue
+		}
+
+		// Solo especular si hay otro worker que pueda recibir la copia
+		original := m.TaskAssignments[id]
+		if !m.otherWorkerUp(original) {
+			continue
+		}
+
+		spec := task
+		spec.ID = uuid.New().String()
+		spec.Speculative = true
+		spec.ExcludedWorkers = []string{original}
+		spec.PreferredWorkers = nil
+		spec.QueuedAt = now
+		spec.TraceParent = newTaskTrace(m.Jobs[task.JobID])
+		m.speculated[key] = spec.ID
+		if job, ok := m.Jobs[task.JobID]; ok {
+			job.Speculation.Launched++
+		}
+
+		utils.LogJSON("WARN", "Lanzando intento especulativo", map[string]interface{}{
+			"node":           task.NodeID,
+			"part":           task.PartitionID,
+			"task_id":        id,
+			"spec_task_id":   spec.ID,
+			"elapsed_secs":   elapsed.Seconds(),
+			"median_secs":    median.Seconds(),
+			"slow_worker_id": original,
+		})
+		m.enqueue(spec)
+		m.recordTaskEvent(common.EventTaskSpeculated, spec, "", fmt.Sprintf("copia de %s (lleva %s, mediana %s)", id, elapsed.Round(time.Millisecond), median.Round(time.Millisecond)))
+	}
+}
+
+// otherWorkerUp - true si existe un worker UP distinto de workerID
+func (m *Master) otherWorkerUp(workerID string) bool {
+	for id, w := range m.Workers {
+		if id != workerID && w.Status == "UP" {
+			return true
+		}
+	}
+	return false
+}
+
+// attemptRunning - true si otro intento de la misma particion sigue en ejecucion
+// Entrada: res - resultado reportado (su tarea se excluye de la busqueda)
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) attemptRunning(res common.TaskResult) bool {
+	for id, t := range m.RunningTasks {
+		if id != res.ID && t.JobID == res.JobID && t.NodeID == res.NodeID && t.PartitionID == res.PartitionID {
+			return true
+		}
+	}
+	return false
+}
+
+// isObsolete - true si una tarea en cola ya no necesita ejecutarse
+// Descripcion: Un intento especulativo es obsoleto si la particion ya
+//
+//	fue completada por el intento original.
+//
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) isObsolete(task common.Task) bool {
+	return task.Speculative && m.getPartitionStatus(task.JobID, task.NodeID, task.PartitionID) == "COMPLETED"
+}
@@ -12,11 +12,19 @@ package master
 import (
 	"encoding/json"
 	"mini-spark/internal/common"
+	"mini-spark/internal/metrics"
+	"mini-spark/internal/tracing"
 	"mini-spark/internal/utils"
 	"os"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 )
 
+// DefaultLocalityWait espera por defecto para colocar una tarea junto a sus datos
+const DefaultLocalityWait = 3 * time.Second
+
 // Master representa el nodo coordinador central del sistema
 type Master struct {
 	Workers map[string]*common.WorkerInfo // Mapa de workers registrados (ID -> WorkerInfo)
@@ -25,16 +33,56 @@ type Master struct {
 	JobProgress map[string]map[string]string // Progreso por nodo: JobID -> NodeID -> Estado
 	JobOutputs  map[string]map[string]string // Archivos de salida: JobID -> NodeID -> Path
 	JobFailures map[string]int               // Contador de fallos: JobID -> Num fallos
-	
-	JobPartitionOutputs map[string]map[string]map[int]string // Salidas por particion: JobID -> NodeID -> PartitionID -> Path
-	TaskProgress map[string]map[string]map[int]string // Progreso por tarea: JobID -> NodeID -> PartitionID -> Status
-	
-	TaskQueue       chan common.Task       // Cola de tareas pendientes (buffered channel)
+
+	JobPartitionOutputs map[string]map[string]map[int]string                  // Salidas por particion: JobID -> NodeID -> PartitionID -> Path
+	TaskProgress        map[string]map[string]map[int]string                  // Progreso por tarea: JobID -> NodeID -> PartitionID -> Status
+	JobPartitionOwners  map[string]map[string]map[int]string                  // Worker que produjo cada particion: JobID -> NodeID -> PartitionID -> WorkerID
+	TaskMetrics         map[string]map[string]map[int]common.PartitionMetrics // Metricas del intento ganador: JobID -> NodeID -> PartitionID -> Metricas
+	JobEvents           map[string][]common.JobEvent                          // Historial de eventos: JobID -> Eventos en orden
+	eventLogs           map[string]*os.File                                   // Historial en disco (append-only) de jobs en curso: JobID -> Archivo
+	MaxFinishedJobs     int                                                   // Jobs terminados conservados; los mas antiguos se descartan (0 = todos, por defecto)
+
+	Pending         *PendingQueue          // Cola de tareas pendientes de colocar (sin limite)
 	TaskAssignments map[string]string      // Asignaciones activas: TaskID -> WorkerID
 	RunningTasks    map[string]common.Task // Tareas en ejecucion: TaskID -> Task
+	TaskStarted     map[string]time.Time   // Inicio de cada tarea asignada: TaskID -> Timestamp
+
+	StageDurations    map[string][]time.Duration // Duraciones de tareas terminadas por etapa de jobs en curso: JobID/NodeID -> Duraciones
+	finishedTaskTime  time.Duration              // Suma de duraciones de tareas de jobs terminados (media del escalado)
+	finishedTaskCount int                        // Cantidad de esas tareas
+	Speculation       SpeculationConfig          // Parametros de ejecucion especulativa
+	speculated        map[string]string          // Particiones ya especuladas: JobID/NodeID/Part -> TaskID especulativo
+	TaskTimeout       time.Duration              // Timeout por defecto de tareas (0 = sin limite)
+	cancelled         map[string]string          // Intentos cancelados (timeout/perdedores): TaskID -> JobID; sus reportes tardios se ignoran
+
+	WorkerFailures    map[string]int                  // Fallos de tareas por worker: WorkerID -> Num fallos
+	JobWorkerFailures map[string]map[string]int       // Fallos por worker en cada job: JobID -> WorkerID -> Num fallos
+	Blacklist         BlacklistConfig                 // Parametros de exclusion de workers
+	blacklisted       map[string]time.Time            // Workers excluidos del cluster: WorkerID -> Fin de exclusion
+	jobBlacklist      map[string]map[string]time.Time // Workers excluidos de un job: JobID -> WorkerID -> Fin de exclusion
+
+	Tables map[string]common.TableDef // Tablas registradas para SQL: Nombre -> TableDef
+
+	Placement      PlacementPolicy                   // Politica de colocacion de tareas en workers
+	slotFreed      chan struct{}                     // Aviso al scheduler: se libero un slot o cambio la carga
+	stopped        chan struct{}                     // Cerrado por Stop: SchedulerLoop termina
+	stopOnce       sync.Once                         // Stop puede llamarse mas de una vez
+	busyUntil      map[string]time.Time              // Workers que respondieron 429: WorkerID -> Fin de la espera
+	metricsHistory map[string][]common.MetricsSample // Metricas de los ultimos heartbeats: WorkerID -> Muestras
+	LocalityWait   time.Duration                     // Espera maxima por el worker dueño de las entradas antes de colocar en otro
+	Pools          map[string]int                    // Pools del scheduler: Nombre -> Peso en el reparto de slots
+
+	Scaler          Scaler               // Agrega/retira workers (nil = tamaño fijo)
+	Scaling         ScalingConfig        // Parametros del escalado dinamico
+	idleSince       map[string]time.Time // Workers UP sin tareas: WorkerID -> Desde cuando
+	lastScaleAction string               // Ultima accion de escalado
+	lastScaleAt     time.Time            // Momento de la ultima accion de escalado
+
+	Metrics *metrics.Registry // Metricas Prometheus expuestas en GET /metrics
+	stats   *masterMetrics    // Series que se actualizan al cambiar de estado
+	Tracer  *tracing.Tracer   // Spans de jobs y tareas (sin exporter se descartan)
 
 	WorkerKeys []string   // Keys de workers (no usado actualmente)
-	rrIndex    int        // Indice round-robin para asignacion de tareas
 	mu         sync.Mutex // Mutex para concurrencia segura
 
 	stateFile string // Ruta del archivo de persistencia JSON
@@ -43,23 +91,52 @@ type Master struct {
 // NewMaster - Constructor del nodo Master
 // Entrada: stateFile - ruta del archivo de persistencia JSON
 // Salida: puntero a instancia Master inicializada
-// Descripcion: Inicializa mapas vacios, crea canal TaskQueue con buffer de 100,
+// Descripcion: Inicializa mapas vacios, crea la cola de tareas pendientes,
 //
-//	y configura archivo de estado para SaveState/LoadState.
+//	usa la politica resource-aware por defecto y configura archivo
+//	de estado para SaveState/LoadState.
 func NewMaster(stateFile string) *Master {
-	return &Master{
-		Workers:         make(map[string]*common.WorkerInfo),
-		Jobs:            make(map[string]*common.Job),
-		JobProgress:     make(map[string]map[string]string),
-		JobOutputs:      make(map[string]map[string]string),
-		JobFailures:     make(map[string]int),
+	m := &Master{
+		Workers:             make(map[string]*common.WorkerInfo),
+		Jobs:                make(map[string]*common.Job),
+		JobProgress:         make(map[string]map[string]string),
+		JobOutputs:          make(map[string]map[string]string),
+		JobFailures:         make(map[string]int),
 		JobPartitionOutputs: make(map[string]map[string]map[int]string),
-		TaskProgress:    make(map[string]map[string]map[int]string),
-		TaskQueue:       make(chan common.Task, 100), // Buffer de 100 tareas
-		TaskAssignments: make(map[string]string),
-		RunningTasks:    make(map[string]common.Task),
-		stateFile:       stateFile,
+		TaskProgress:        make(map[string]map[string]map[int]string),
+		JobPartitionOwners:  make(map[string]map[string]map[int]string),
+		TaskMetrics:         make(map[string]map[string]map[int]common.PartitionMetrics),
+		JobEvents:           make(map[string][]common.JobEvent),
+		eventLogs:           make(map[string]*os.File),
+		Pending:             NewPendingQueue(),
+		TaskAssignments:     make(map[string]string),
+		RunningTasks:        make(map[string]common.Task),
+		TaskStarted:         make(map[string]time.Time),
+		StageDurations:      make(map[string][]time.Duration),
+		Speculation:         DefaultSpeculationConfig(),
+		speculated:          make(map[string]string),
+		TaskTimeout:         DefaultTaskTimeout,
+		cancelled:           make(map[string]string),
+		WorkerFailures:      make(map[string]int),
+		JobWorkerFailures:   make(map[string]map[string]int),
+		Blacklist:           DefaultBlacklistConfig(),
+		blacklisted:         make(map[string]time.Time),
+		jobBlacklist:        make(map[string]map[string]time.Time),
+		Tables:              make(map[string]common.TableDef),
+		Placement:           &ResourceAwarePolicy{},
+		slotFreed:           make(chan struct{}, 1),
+		stopped:             make(chan struct{}),
+		busyUntil:           make(map[string]time.Time),
+		metricsHistory:      make(map[string][]common.MetricsSample),
+		LocalityWait:        DefaultLocalityWait,
+		Pools:               map[string]int{common.DefaultPool: 1},
+		Scaling:             DefaultScalingConfig(),
+		idleSince:           make(map[string]time.Time),
+		stateFile:           stateFile,
 	}
+	m.Metrics, m.stats = newMasterMetrics(m)
+	m.Tracer = tracing.NewTracer("mini-spark-master", nil)
+	return m
 }
 
 // InitJobProgress - Inicializa mapas de seguimiento para un nuevo job
@@ -79,6 +156,9 @@ func (m *Master) InitJobProgress(job *common.Job) {
 	if _, ok := m.JobPartitionOutputs[job.ID]; !ok {
 		m.JobPartitionOutputs[job.ID] = make(map[string]map[int]string)
 	}
+	if _, ok := m.JobPartitionOwners[job.ID]; !ok {
+		m.JobPartitionOwners[job.ID] = make(map[string]map[int]string)
+	}
 	// Inicializar contador de fallos en 0
 	if _, ok := m.JobFailures[job.ID]; !ok {
 		m.JobFailures[job.ID] = 0
@@ -88,11 +168,13 @@ func (m *Master) InitJobProgress(job *common.Job) {
 		m.JobProgress[job.ID][node.ID] = "PENDING"
 		// Inicializar estado de cada partición
 		m.TaskProgress[job.ID][node.ID] = make(map[int]string)
-		
+
 		// Usar Parallelism del Job, default 1
 		p := job.Parallelism
-		if p < 1 { p = 1 }
-		
+		if p < 1 {
+			p = 1
+		}
+
 		for i := 0; i < p; i++ {
 			m.TaskProgress[job.ID][node.ID][i] = "PENDING"
 		}
@@ -146,9 +228,11 @@ func (m *Master) setPartitionStatus(jobID, nodeID string, partID int, status str
 // SaveState - Persiste estado del Master a disco en formato JSON
 // Entrada: ninguna (usa this.stateFile)
 // Salida: ninguna (void), loguea errores si falla
-// Descripcion: Serializa Jobs, JobOutputs y JobFailures a archivo JSON.
+// Descripcion: Serializa Jobs, JobOutputs, JobFailures, Tables y TaskMetrics
 //
-//	Usa formato indentado para legibilidad. No persiste workers
+//	a archivo JSON compacto. El historial de eventos no se incluye: se
+//	escribe de forma incremental en un archivo por job (ver events.go).
+//	Con MaxFinishedJobs > 0 el tamaño queda acotado. No persiste workers
 //	ni tareas en ejecucion (son volatiles).
 func (m *Master) SaveState() {
 	// Estructura temporal para serializacion
@@ -156,10 +240,14 @@ func (m *Master) SaveState() {
 		Jobs        map[string]*common.Job
 		JobOutputs  map[string]map[string]string
 		JobFailures map[string]int
+		Tables      map[string]common.TableDef
+		TaskMetrics map[string]map[string]map[int]common.PartitionMetrics
 	}{
 		Jobs:        m.Jobs,
 		JobOutputs:  m.JobOutputs,
 		JobFailures: m.JobFailures,
+		Tables:      m.Tables,
+		TaskMetrics: m.TaskMetrics,
 	}
 
 	// Crear archivo de estado
@@ -170,10 +258,7 @@ func (m *Master) SaveState() {
 	}
 	defer file.Close()
 
-	// Serializar con formato indentado
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(data); err != nil {
+	if err := json.NewEncoder(file).Encode(data); err != nil {
 		utils.LogJSON("ERROR", "Error serializando estado", map[string]interface{}{"error": err.Error()})
 	}
 }
@@ -181,7 +266,10 @@ func (m *Master) SaveState() {
 // LoadState - Recupera estado del Master desde disco
 // Entrada: ninguna (usa this.stateFile)
 // Salida: ninguna (void), inicializa sin estado si archivo no existe
-// Descripcion: Deserializa JSON de estado, restaura Jobs, JobOutputs y JobFailures.
+// Descripcion: Deserializa JSON de estado, restaura Jobs, JobOutputs, JobFailures,
+//
+//	tablas y metricas por tarea; el historial de eventos se lee del
+//	archivo de cada job. Aplica MaxFinishedJobs.
 //
 //	Reinicializa JobProgress para jobs recuperados.
 //	Marca jobs completados con todos sus nodos COMPLETED.
@@ -199,6 +287,9 @@ func (m *Master) LoadState() {
 		Jobs        map[string]*common.Job
 		JobOutputs  map[string]map[string]string
 		JobFailures map[string]int
+		Tables      map[string]common.TableDef
+		TaskMetrics map[string]map[string]map[int]common.PartitionMetrics
+		JobEvents   map[string][]common.JobEvent // Formato anterior (historial dentro del estado)
 	}{}
 
 	// Deserializar JSON
@@ -211,21 +302,109 @@ func (m *Master) LoadState() {
 	m.Jobs = data.Jobs
 	m.JobOutputs = data.JobOutputs
 	m.JobFailures = data.JobFailures
+	if data.Tables != nil {
+		m.Tables = data.Tables
+	}
+	if data.TaskMetrics != nil {
+		m.TaskMetrics = data.TaskMetrics
+	}
+	if data.JobEvents != nil {
+		m.JobEvents = data.JobEvents
+	}
 
 	// Reconstruir mapas de progreso
 	for _, job := range m.Jobs {
+		if events := m.loadEventLog(job.ID); len(events) > 0 {
+			m.JobEvents[job.ID] = events
+		}
 		m.InitJobProgress(job)
 		// Si el job estaba completado, marcar todos sus nodos
 		if job.Status == "COMPLETED" {
 			for _, node := range job.Graph.Nodes {
 				m.setNodeStatus(job.ID, node.ID, "COMPLETED")
 				p := job.Parallelism
-				if p < 1 { p = 1 }
+				if p < 1 {
+					p = 1
+				}
 				for i := 0; i < p; i++ {
 					m.setPartitionStatus(job.ID, node.ID, i, "COMPLETED")
 				}
 			}
 		}
 	}
+	m.pruneFinishedJobs()
 	utils.LogJSON("INFO", "Estado recuperado", map[string]interface{}{"jobs_loaded": len(m.Jobs)})
 }
+
+// releaseJob - Libera el estado de un job que solo se usa mientras corre
+// Descripcion: Especulacion, exclusiones por job y duraciones por etapa
+//
+//	(estas se acumulan en la media global del escalado).
+//
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) releaseJob(jobID string) {
+	prefix := jobID + "/"
+	for key := range m.speculated {
+		if strings.HasPrefix(key, prefix) {
+			delete(m.speculated, key)
+		}
+	}
+	for key, durations := range m.StageDurations {
+		if strings.HasPrefix(key, prefix) {
+			for _, d := range durations {
+				m.finishedTaskTime += d
+			}
+			m.finishedTaskCount += len(durations)
+			delete(m.StageDurations, key)
+		}
+	}
+	delete(m.jobBlacklist, jobID)
+	delete(m.JobWorkerFailures, jobID)
+}
+
+// pruneFinishedJobs - Descarta los jobs terminados mas antiguos
+// Descripcion: Con MaxFinishedJobs > 0 conserva los terminados mas
+//
+//	recientes; del resto borra todo su estado (salidas, status) y su
+//	archivo de eventos. Sin limite (por defecto) no descarta nada.
+//
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) pruneFinishedJobs() {
+	if m.MaxFinishedJobs <= 0 {
+		return
+	}
+	var finished []*common.Job
+	for _, job := range m.Jobs {
+		if m.jobFinished(job.ID) {
+			finished = append(finished, job)
+		}
+	}
+	if len(finished) <= m.MaxFinishedJobs {
+		return
+	}
+	sort.Slice(finished, func(i, j int) bool { return finished[i].Completed.Before(finished[j].Completed) })
+	for _, job := range finished[:len(finished)-m.MaxFinishedJobs] {
+		m.forgetJob(job.ID)
+	}
+}
+
+// forgetJob - Elimina todo el estado de un job terminado
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) forgetJob(jobID string) {
+	m.releaseJob(jobID)
+	delete(m.Jobs, jobID)
+	delete(m.JobProgress, jobID)
+	delete(m.JobOutputs, jobID)
+	delete(m.JobFailures, jobID)
+	delete(m.JobPartitionOutputs, jobID)
+	delete(m.TaskProgress, jobID)
+	delete(m.JobPartitionOwners, jobID)
+	delete(m.TaskMetrics, jobID)
+	delete(m.JobEvents, jobID)
+	for taskID, owner := range m.cancelled {
+		if owner == jobID {
+			delete(m.cancelled, taskID)
+		}
+	}
+	m.removeEventLog(jobID)
+}
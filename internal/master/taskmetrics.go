@@ -0,0 +1,124 @@
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: taskmetrics.go
+Descripcion: Metricas de ejecucion por tarea.
+             Guarda las metricas que reporta el worker del intento que
+             gano cada particion (registros, bytes, spills, duracion, CPU
+             e intento) y las agrega por nodo y por job para el status,
+             con indicadores de skew (maximo/mediana entre particiones).
+*/
+
+package master
+
+import (
+	"mini-spark/internal/common"
+	"sort"
+)
+
+// recordTaskMetrics - Guarda las metricas de la particion completada por res
+// Entrada: res - resultado del worker, workerID - dueño de la salida,
+//
+//	attempt - intento conocido por el Master (0 = desconocido)
+//
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) recordTaskMetrics(res common.TaskResult, workerID string, attempt int) {
+	if res.Usage == nil {
+		return
+	}
+	if res.Attempt > 0 {
+		attempt = res.Attempt
+	}
+	if attempt < 1 {
+		attempt = 1
+	}
+	if _, ok := m.TaskMetrics[res.JobID]; !ok {
+		m.TaskMetrics[res.JobID] = make(map[string]map[int]common.PartitionMetrics)
+	}
+	if _, ok := m.TaskMetrics[res.JobID][res.NodeID]; !ok {
+		m.TaskMetrics[res.JobID][res.NodeID] = make(map[int]common.PartitionMetrics)
+	}
+	m.TaskMetrics[res.JobID][res.NodeID][res.PartitionID] = common.PartitionMetrics{
+		Partition: res.PartitionID,
+		Attempt:   attempt,
+		WorkerID:  workerID,
+		TaskUsage: *res.Usage,
+	}
+}
+
+// jobMetrics - Agrega las metricas de las tareas de un job por nodo
+// Salida: nil si ninguna tarea reporto metricas
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) jobMetrics(jobID string) *common.JobMetrics {
+	nodes := m.TaskMetrics[jobID]
+	if len(nodes) == 0 {
+		return nil
+	}
+	metrics := &common.JobMetrics{Nodes: make(map[string]common.NodeMetrics, len(nodes))}
+	for nodeID, parts := range nodes {
+		node := nodeMetrics(parts)
+		metrics.Nodes[nodeID] = node
+		for _, p := range node.Partitions {
+			metrics.Add(p)
+		}
+		// Desempate por nombre para que el nodo reportado sea estable
+		if node.SizeSkew > metrics.MaxSizeSkew || (node.SizeSkew == metrics.MaxSizeSkew && metrics.SkewedNode != "" && nodeID < metrics.SkewedNode) {
+			metrics.MaxSizeSkew = node.SizeSkew
+			metrics.SkewedNode = nodeID
+		}
+	}
+	return metrics
+}
+
+// nodeMetrics - Totales y skew de las particiones de un nodo
+func nodeMetrics(parts map[int]common.PartitionMetrics) common.NodeMetrics {
+	var node common.NodeMetrics
+	sizes := make([]float64, 0, len(parts))
+	walls := make([]float64, 0, len(parts))
+	for _, p := range parts {
+		node.Partitions = append(node.Partitions, p)
+	}
+	sort.Slice(node.Partitions, func(i, j int) bool { return node.Partitions[i].Partition < node.Partitions[j].Partition })
+	for i, p := range node.Partitions {
+		node.Add(p)
+		sizes = append(sizes, float64(p.InputBytes))
+		walls = append(walls, p.WallSecs)
+		if i == 0 || p.InputBytes > node.MaxInputBytes {
+			node.MaxInputBytes = p.InputBytes
+			node.LargestPartition = p.Partition
+		}
+		if i == 0 || p.WallSecs > node.MaxWallSecs {
+			node.MaxWallSecs = p.WallSecs
+			node.SlowestPartition = p.Partition
+		}
+	}
+	node.MedianInputBytes = int64(median(sizes))
+	node.MedianWallSecs = median(walls)
+	node.SizeSkew = skew(float64(node.MaxInputBytes), float64(node.MedianInputBytes))
+	node.TimeSkew = skew(node.MaxWallSecs, node.MedianWallSecs)
+	return node
+}
+
+// median - Mediana de una muestra (0 si esta vacia)
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// skew - Cociente maximo/mediana (1 si no hay datos para compararlos)
+func skew(max, median float64) float64 {
+	if median <= 0 {
+		if max > 0 {
+			return max // Mediana vacia: todo el trabajo cayo en pocas particiones
+		}
+		return 1
+	}
+	return max / median
+}
@@ -0,0 +1,114 @@
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: timeout.go
+Descripcion: Deteccion de tareas colgadas por timeout.
+             Una tarea que no reporta (UDF bloqueada, worker trabado
+             que sigue enviando heartbeats) se cancela en su worker y
+             se reintenta en otro; al agotar los reintentos el job
+             se marca FAILED.
+*/
+
+package master
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mini-spark/internal/common"
+	"mini-spark/internal/utils"
+	"net/http"
+	"time"
+)
+
+// DefaultTaskTimeout timeout por tarea si ni el nodo ni el job lo definen
+const DefaultTaskTimeout = 10 * time.Minute
+
+// taskTimeout - Timeout efectivo de una tarea
+// Descripcion: timeout_secs del nodo > task_timeout_secs del job (ya
+//
+//	resueltos en Task.TimeoutSecs) > TaskTimeout del Master (0 = sin limite).
+func (m *Master) taskTimeout(task common.Task) time.Duration {
+	if task.TimeoutSecs > 0 {
+		return time.Duration(task.TimeoutSecs) * time.Second
+	}
+	return m.TaskTimeout
+}
+
+// TimeoutLoop - Revisa periodicamente tareas que excedieron su timeout
+// Entrada: ninguna
+// Salida: ninguna (void), loop infinito
+func (m *Master) TimeoutLoop() {
+	for {
+		time.Sleep(time.Second)
+		m.CheckTimeouts()
+	}
+}
+
+// CheckTimeouts - Cancela y reintenta tareas con timeout vencido
+// Entrada: ninguna
+// Salida: ninguna (void)
+// Descripcion: Para cada tarea en RunningTasks con mas tiempo que su
+//
+//	timeout: libera la asignacion, ordena al worker cancelarla y la
+//	reintenta segun su RetryPolicy excluyendo ese worker. Si otro intento
+//	de la particion sigue activo solo se descarta; si se agotaron los
+//	reintentos el job pasa a FAILED.
+func (m *Master) CheckTimeouts() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for id, task := range m.RunningTasks {
+		timeout := m.taskTimeout(task)
+		started, ok := m.TaskStarted[id]
+		if timeout <= 0 || !ok || now.Sub(started) < timeout {
+			continue
+		}
+
+		workerID := m.TaskAssignments[id]
+		delete(m.RunningTasks, id)
+		delete(m.TaskAssignments, id)
+		delete(m.TaskStarted, id)
+		m.cancelled[id] = task.JobID
+		m.notifySlotFreed()
+		if w, ok := m.Workers[workerID]; ok {
+			go m.cancelOnWorker(w, id, "timeout")
+		}
+
+		utils.LogJSON("WARN", "Timeout de tarea", map[string]interface{}{
+			"task_id":      id,
+			"node":         task.NodeID,
+			"part":         task.PartitionID,
+			"worker_id":    workerID,
+			"timeout_secs": timeout.Seconds(),
+			"attempt":      task.Attempt,
+		})
+
+		m.recordTaskEvent(common.EventTaskTimedOut, task, workerID, fmt.Sprintf("timeout de %s excedido", timeout))
+		m.recordWorkerFailure(workerID, task.JobID)
+		res := common.TaskResult{ID: id, JobID: task.JobID, NodeID: task.NodeID, PartitionID: task.PartitionID}
+		if m.attemptRunning(res) {
+			continue
+		}
+		m.JobFailures[task.JobID]++
+		m.retryOrFail(task, workerID, common.ErrorClassRetryable, fmt.Sprintf("timeout de %s excedido", timeout))
+		m.SaveState()
+	}
+}
+
+// cancelOnWorker - Pide a un worker abortar una tarea
+// Entrada: worker - destino, taskID - tarea a cancelar, reason - motivo
+// Salida: ninguna (void); los errores solo se loguean (el worker puede estar caido)
+func (m *Master) cancelOnWorker(worker *common.WorkerInfo, taskID, reason string) {
+	data, _ := json.Marshal(common.CancelRequest{TaskID: taskID, Reason: reason})
+	resp, err := http.Post(worker.URL+"/task/cancel", "application/json", bytes.NewBuffer(data))
+	if err != nil {
+		utils.LogJSON("WARN", "No se pudo cancelar tarea en worker", map[string]interface{}{
+			"task_id": taskID,
+			"worker":  worker.ID,
+			"error":   err.Error(),
+		})
+		return
+	}
+	resp.Body.Close()
+}
@@ -0,0 +1,115 @@
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: tracing.go
+Descripcion: Spans del Master para las trazas distribuidas.
+             Cada job es una traza con un span raiz (envio -> fin) y un
+             span por intento de tarea (encolado -> resultado) con sus
+             hijos queue_wait (espera en la cola) y scheduling_delay
+             (envio al worker hasta que la acepta). El contexto de la
+             tarea viaja al Worker en Task.TraceParent.
+*/
+
+package master
+
+import (
+	"mini-spark/internal/common"
+	"mini-spark/internal/tracing"
+	"time"
+)
+
+// jobTraceContext - Contexto del span raiz de un job (invalido si no tiene traza)
+func jobTraceContext(job *common.Job) tracing.SpanContext {
+	if job == nil {
+		return tracing.SpanContext{}
+	}
+	ctx, _ := tracing.ParseTraceparent(job.Trace)
+	return ctx
+}
+
+// newTaskTrace - Contexto de un nuevo intento de tarea del job
+// Salida: traceparent del span de la tarea ("" si el job no tiene traza)
+func newTaskTrace(job *common.Job) string {
+	ctx := jobTraceContext(job)
+	if !ctx.IsValid() {
+		return ""
+	}
+	return ctx.Child().Traceparent()
+}
+
+// taskAttributes - Atributos comunes de los spans de una tarea
+func taskAttributes(task common.Task) map[string]interface{} {
+	return map[string]interface{}{
+		"job.id":       task.JobID,
+		"task.id":      task.ID,
+		"task.node":    task.NodeID,
+		"task.op":      task.Op,
+		"task.part":    task.PartitionID,
+		"task.attempt": task.Attempt,
+	}
+}
+
+// traceTaskChild - Registra un span hijo del span de una tarea
+func (m *Master) traceTaskChild(task common.Task, name string, start, end time.Time, attrs map[string]interface{}) {
+	parent, ok := tracing.ParseTraceparent(task.TraceParent)
+	if !ok {
+		return
+	}
+	span := tracing.NewSpan(name, parent, start)
+	span.End = end
+	span.Attributes = taskAttributes(task)
+	for k, v := range attrs {
+		span.Attributes[k] = v
+	}
+	m.Tracer.Record(span)
+}
+
+// traceTaskResult - Registra el span de un intento de tarea al recibir su resultado
+// Descripcion: El span va del encolado al resultado; su contexto es el que
+//
+//	recibio el worker, por lo que los spans del worker quedan como hijos.
+func (m *Master) traceTaskResult(task common.Task, res common.TaskResult, workerID string) {
+	ctx, ok := tracing.ParseTraceparent(task.TraceParent)
+	if !ok {
+		return
+	}
+	span := tracing.Span{
+		Context:    ctx,
+		ParentID:   jobTraceContext(m.Jobs[task.JobID]).SpanID,
+		Name:       "task " + task.NodeID,
+		Start:      task.QueuedAt,
+		Attributes: taskAttributes(task),
+		Error:      res.ErrorMsg,
+	}
+	span.Attributes["task.status"] = res.Status
+	span.Attributes["worker.id"] = workerID
+	if task.Speculative {
+		span.Attributes["task.speculative"] = true
+	}
+	m.Tracer.Record(span)
+}
+
+// traceJobEnd - Registra el span raiz de un job que termino
+// Nota: Debe llamarse con m.mu tomado, despues de fijar Status y Completed
+func (m *Master) traceJobEnd(job *common.Job) {
+	ctx := jobTraceContext(job)
+	if !ctx.IsValid() {
+		return
+	}
+	span := tracing.Span{
+		Context: ctx,
+		Name:    "job " + job.Name,
+		Start:   job.Submitted,
+		End:     job.Completed,
+		Attributes: map[string]interface{}{
+			"job.id":          job.ID,
+			"job.status":      job.Status,
+			"job.pool":        job.Pool,
+			"job.parallelism": job.Parallelism,
+		},
+		Error: job.Error,
+	}
+	if job.Status == "CANCELLED" {
+		span.Error = "job cancelado"
+	}
+	m.Tracer.Record(span)
+}
@@ -0,0 +1,39 @@
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: ui.go
+Descripcion: Interfaz web del Master (GET /ui/).
+             Sirve los archivos estaticos embebidos en el binario
+             (ui/*.html, *.js, *.css), sin dependencias externas ni CDN.
+             La pagina consulta la API REST: lista de jobs, DAG con el
+             estado por nodo y particion, historial de eventos y workers.
+*/
+
+package master
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed ui
+var uiFiles embed.FS
+
+// UIHandler - Handler de la interfaz web
+// Entrada: montado en /ui/ (el mux redirige /ui a /ui/)
+// Salida: index.html y sus recursos; 404 si el archivo no existe
+func UIHandler() http.Handler {
+	static, err := fs.Sub(uiFiles, "ui")
+	if err != nil {
+		panic(err) // El directorio esta embebido en tiempo de compilacion
+	}
+	files := http.StripPrefix("/ui/", http.FileServer(http.FS(static)))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Cache-Control", "no-cache")
+		files.ServeHTTP(w, r)
+	})
+}
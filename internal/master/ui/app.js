@@ -0,0 +1,350 @@
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: app.js
+Descripcion: Interfaz web del Master. Rutas por hash:
+             #/                 lista de jobs
+             #/jobs/{id}        DAG, particiones y errores de un job
+             #/workers          salud de los workers
+             Consulta la API REST cada REFRESH_MS mientras la pestana
+             esta visible. Sin dependencias externas.
+*/
+
+"use strict";
+
+const REFRESH_MS = 2000;
+const MAX_PART_BOXES = 48; // Particiones dibujadas por nodo en el DAG
+const ERROR_EVENTS = ["TASK_FAILED", "TASK_TIMED_OUT", "TASK_REJECTED", "JOB_FAILED"];
+
+const app = document.getElementById("app");
+const refreshLabel = document.getElementById("refresh");
+let timer = null;
+let selectedPartition = null; // "nodo/particion" resaltada en la tabla de errores
+
+// --- Utilidades ---
+
+function esc(value) {
+  return String(value === undefined || value === null ? "" : value)
+    .replace(/&/g, "&amp;").replace(/</g, "&lt;").replace(/>/g, "&gt;")
+    .replace(/"/g, "&quot;").replace(/'/g, "&#39;");
+}
+
+async function getJSON(path) {
+  const resp = await fetch(path, { headers: { Accept: "application/json" } });
+  if (!resp.ok) {
+    throw new Error(path + ": " + resp.status + " " + (await resp.text()).trim());
+  }
+  return resp.json();
+}
+
+function badge(status) {
+  return '<span class="badge ' + esc(status) + '">' + esc(status) + "</span>";
+}
+
+function progressBar(percent) {
+  const p = Math.max(0, Math.min(100, percent || 0));
+  return '<span class="bar"><span style="width:' + p.toFixed(0) + '%"></span></span> ' + p.toFixed(0) + "%";
+}
+
+function duration(secs) {
+  if (!secs || secs < 0) return "-";
+  if (secs < 60) return secs.toFixed(1) + " s";
+  if (secs < 3600) return Math.floor(secs / 60) + " min " + Math.round(secs % 60) + " s";
+  return Math.floor(secs / 3600) + " h " + Math.round((secs % 3600) / 60) + " min";
+}
+
+function bytes(n) {
+  if (!n) return "0 B";
+  const units = ["B", "KiB", "MiB", "GiB", "TiB"];
+  let i = 0;
+  while (n >= 1024 && i < units.length - 1) {
+    n /= 1024;
+    i++;
+  }
+  return n.toFixed(i === 0 ? 0 : 1) + " " + units[i];
+}
+
+function time(ts) {
+  return ts ? new Date(ts).toLocaleTimeString() : "-";
+}
+
+function ago(ts) {
+  if (!ts) return "-";
+  return duration((Date.now() - new Date(ts).getTime()) / 1000);
+}
+
+function workerLink(id) {
+  return id ? '<a class="mono" href="#/workers/' + encodeURIComponent(id) + '">' + esc(id) + "</a>" : "";
+}
+
+// --- Vistas ---
+
+async function renderJobs() {
+  const list = await getJSON("/api/v1/jobs");
+  const rows = list.jobs.map(function (job) {
+    return "<tr><td><a href=\"#/jobs/" + encodeURIComponent(job.id) + "\">" + esc(job.name || job.id) + "</a>" +
+      '<div class="mono muted">' + esc(job.id) + "</div></td>" +
+      "<td>" + badge(job.status) + "</td>" +
+      "<td>" + progressBar(job.progress_percent) + "</td>" +
+      "<td>" + esc(Object.keys(job.node_status || {}).length) + "</td>" +
+      "<td>" + esc(job.failure_count) + "</td>" +
+      "<td>" + esc(job.pool) + " / " + esc(job.priority) + "</td>" +
+      "<td>" + time(job.submitted_at) + "</td>" +
+      "<td>" + duration(job.duration_secs) + "</td>" +
+      '<td class="error">' + esc(job.error) + "</td></tr>";
+  });
+  app.innerHTML = "<h2>Jobs (" + list.jobs.length + ")</h2>" +
+    "<table><tr><th>Job</th><th>Estado</th><th>Progreso</th><th>Nodos</th><th>Fallos</th>" +
+    "<th>Pool / prioridad</th><th>Enviado</th><th>Duración</th><th>Error</th></tr>" +
+    (rows.join("") || '<tr><td colspan="9" class="muted">Sin jobs</td></tr>') + "</table>";
+}
+
+async function renderJob(jobID) {
+  const base = "/api/v1/jobs/" + encodeURIComponent(jobID);
+  const [job, history] = await Promise.all([getJSON(base), getJSON(base + "/events")]);
+  const events = history.events || [];
+
+  // Ultimo evento de tarea y fallos por particion (nodo/particion)
+  const lastEvent = {};
+  const failures = {};
+  events.forEach(function (e) {
+    if (!e.node_id) return;
+    const key = e.node_id + "/" + e.partition;
+    lastEvent[key] = e;
+    if (ERROR_EVENTS.indexOf(e.type) >= 0) failures[key] = (failures[key] || 0) + 1;
+  });
+
+  const errors = events.filter(function (e) { return ERROR_EVENTS.indexOf(e.type) >= 0; });
+  const metrics = job.metrics || {};
+
+  app.innerHTML =
+    '<h2>' + esc(job.name || job.id) + " " + badge(job.status) + "</h2>" +
+    '<div class="summary">' +
+    "<div><span>ID</span><span class=\"mono\">" + esc(job.id) + "</span></div>" +
+    "<div><span>Progreso</span>" + progressBar(job.progress_percent) + "</div>" +
+    "<div><span>Duración</span>" + duration(job.duration_secs) + "</div>" +
+    "<div><span>Enviado</span>" + time(job.submitted_at) + "</div>" +
+    "<div><span>Pool / prioridad</span>" + esc(job.pool) + " / " + esc(job.priority) + "</div>" +
+    "<div><span>Fallos</span>" + esc(job.failure_count) + "</div>" +
+    "<div><span>Tareas completadas</span>" + esc(metrics.tasks || 0) + "</div>" +
+    "<div><span>Entrada / salida</span>" + bytes(metrics.input_bytes) + " / " + bytes(metrics.output_bytes) + "</div>" +
+    (job.trace_id ? "<div><span>Traza</span><span class=\"mono\">" + esc(job.trace_id) + "</span></div>" : "") +
+    "</div>" +
+    (job.error ? '<p class="error">' + esc(job.error) + "</p>" : "") +
+    "<h3>DAG</h3>" +
+    '<div class="legend">' + ["PENDING", "SCHEDULED", "RUNNING", "COMPLETED"].map(badge).join("") +
+    " particiones con borde rojo tuvieron intentos fallidos; clic para ver sus errores</div>" +
+    '<div class="dag">' + dagSVG(job, lastEvent, failures) + "</div>" +
+    "<h3>Etapas</h3>" + stagesTable(job) +
+    '<h3 id="errors">Errores de tareas (' + errors.length + ")</h3>" + errorsTable(errors) +
+    "<h3>Eventos (" + events.length + ')</h3><p><a href="' + base + '/events">JSON</a> · ' +
+    '<a href="' + base + '">estado JSON</a> · <a href="' + base + '/results">resultados</a></p>' +
+    eventsTable(events);
+
+  app.querySelectorAll(".part").forEach(function (el) {
+    el.addEventListener("click", function () {
+      selectedPartition = el.getAttribute("data-key");
+      highlightErrors();
+      document.getElementById("errors").scrollIntoView({ behavior: "smooth" });
+    });
+  });
+  highlightErrors();
+}
+
+function highlightErrors() {
+  app.querySelectorAll("tr[data-key]").forEach(function (tr) {
+    tr.classList.toggle("highlight", tr.getAttribute("data-key") === selectedPartition);
+  });
+}
+
+// levels - Columna de cada nodo: camino mas largo desde un nodo source
+function levels(dag) {
+  const parents = {};
+  (dag.nodes || []).forEach(function (n) { parents[n.id] = []; });
+  (dag.edges || []).forEach(function (e) {
+    if (parents[e[1]]) parents[e[1]].push(e[0]);
+  });
+  const level = {};
+  function visit(id, depth) {
+    if (level[id] !== undefined) return level[id];
+    if (depth > 1000) return 0; // El Master valida que no haya ciclos
+    let l = 0;
+    parents[id].forEach(function (p) {
+      if (parents[p]) l = Math.max(l, visit(p, depth + 1) + 1);
+    });
+    level[id] = l;
+    return l;
+  }
+  Object.keys(parents).forEach(function (id) { visit(id, 0); });
+  return level;
+}
+
+// dagSVG - Dibuja el DAG por columnas con el estado de cada nodo y particion
+function dagSVG(job, lastEvent, failures) {
+  const dag = job.dag || { nodes: [] };
+  const level = levels(dag);
+  const nodeW = 220, colGap = 70, rowGap = 24, pad = 16, box = 12, perRow = 12;
+  const columns = [];
+  dag.nodes.forEach(function (n) {
+    const l = level[n.id] || 0;
+    (columns[l] = columns[l] || []).push(n);
+  });
+
+  const pos = {};
+  let height = 0;
+  columns.forEach(function (col, c) {
+    let y = pad;
+    col.forEach(function (n) {
+      const parts = (job.partitions || {})[n.id] || [];
+      const rows = Math.ceil(Math.min(parts.length, MAX_PART_BOXES) / perRow);
+      const h = 46 + rows * (box + 2) + (parts.length > MAX_PART_BOXES ? 16 : 0);
+      pos[n.id] = { x: pad + c * (nodeW + colGap), y: y, h: h, parts: parts };
+      y += h + rowGap;
+    });
+    height = Math.max(height, y);
+  });
+  const width = pad * 2 + columns.length * nodeW + Math.max(0, columns.length - 1) * colGap;
+
+  let svg = '<svg xmlns="http://www.w3.org/2000/svg" width="' + width + '" height="' + Math.max(height, 60) + '">' +
+    '<defs><marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="7" markerHeight="7" orient="auto">' +
+    '<path d="M0,0 L10,5 L0,10 z" fill="#98a1ad"/></marker></defs>';
+
+  (dag.edges || []).forEach(function (e) {
+    const a = pos[e[0]], b = pos[e[1]];
+    if (!a || !b) return;
+    const x1 = a.x + nodeW, y1 = a.y + 20, x2 = b.x, y2 = b.y + 20, mid = (x1 + x2) / 2;
+    svg += '<path class="edge" marker-end="url(#arrow)" d="M' + x1 + "," + y1 + " C" + mid + "," + y1 + " " + mid + "," + y2 + " " + x2 + "," + y2 + '"/>';
+  });
+
+  dag.nodes.forEach(function (n) {
+    const p = pos[n.id];
+    const status = (job.node_status || {})[n.id] || "PENDING";
+    svg += '<g class="node"><title>' + esc(n.id + " (" + n.op + ")") + "</title>" +
+      '<rect class="box" x="' + p.x + '" y="' + p.y + '" width="' + nodeW + '" height="' + p.h + '" rx="6" style="stroke:' + color(status) + '"/>' +
+      '<text x="' + (p.x + 10) + '" y="' + (p.y + 18) + '" font-weight="600">' + esc(truncate(n.id, 26)) + "</text>" +
+      '<text x="' + (p.x + 10) + '" y="' + (p.y + 34) + '" class="muted">' + esc(truncate(n.op, 18)) + " · " + esc(status) + "</text>";
+    p.parts.slice(0, MAX_PART_BOXES).forEach(function (state, i) {
+      const key = n.id + "/" + i;
+      const last = lastEvent[key];
+      const shown = state === "SCHEDULED" && last && last.type === "TASK_STARTED" ? "RUNNING" : state;
+      const bx = p.x + 10 + (i % perRow) * (box + 2);
+      const by = p.y + 42 + Math.floor(i / perRow) * (box + 2);
+      const tip = "particion " + i + ": " + shown + (last ? " · " + last.type + (last.worker_id ? " en " + last.worker_id : "") : "") +
+        (failures[key] ? " · " + failures[key] + " error(es)" : "");
+      svg += '<rect class="part' + (failures[key] ? " failed" : "") + '" data-key="' + esc(key) + '" x="' + bx + '" y="' + by +
+        '" width="' + box + '" height="' + box + '" style="fill:' + color(shown) + '"><title>' + esc(tip) + "</title></rect>";
+    });
+    if (p.parts.length > MAX_PART_BOXES) {
+      svg += '<text x="' + (p.x + 10) + '" y="' + (p.y + p.h - 6) + '" class="muted">+' + (p.parts.length - MAX_PART_BOXES) + " particiones</text>";
+    }
+    svg += "</g>";
+  });
+  return svg + "</svg>";
+}
+
+function color(status) {
+  const value = getComputedStyle(document.documentElement).getPropertyValue("--" + String(status).toLowerCase());
+  return value.trim() || "#c9ced6";
+}
+
+function truncate(s, n) {
+  s = String(s || "");
+  return s.length > n ? s.slice(0, n - 1) + "…" : s;
+}
+
+// stagesTable - Conteo de particiones por estado y metricas de cada nodo
+function stagesTable(job) {
+  const nodes = (job.dag || { nodes: [] }).nodes;
+  const nodeMetrics = (job.metrics || {}).nodes || {};
+  const rows = nodes.map(function (n) {
+    const counts = {};
+    ((job.partitions || {})[n.id] || []).forEach(function (s) { counts[s] = (counts[s] || 0) + 1; });
+    const m = nodeMetrics[n.id] || {};
+    return "<tr><td>" + esc(n.id) + "</td><td>" + esc(n.op) + "</td><td>" + badge((job.node_status || {})[n.id] || "PENDING") + "</td>" +
+      "<td>" + Object.keys(counts).sort().map(function (s) { return esc(s) + ": " + counts[s]; }).join(", ") + "</td>" +
+      "<td>" + bytes(m.input_bytes) + "</td><td>" + esc(m.records_out || 0) + "</td>" +
+      "<td>" + (m.max_wall_secs ? duration(m.max_wall_secs) : "-") + "</td>" +
+      "<td>" + (m.size_skew ? m.size_skew.toFixed(1) : "-") + "</td></tr>";
+  });
+  return "<table><tr><th>Nodo</th><th>Operación</th><th>Estado</th><th>Particiones</th><th>Entrada</th>" +
+    "<th>Registros escritos</th><th>Tarea más lenta</th><th>Skew de tamaño</th></tr>" + rows.join("") + "</table>";
+}
+
+function errorsTable(errors) {
+  if (errors.length === 0) return '<p class="muted">Sin errores</p>';
+  return "<table><tr><th>Hora</th><th>Tipo</th><th>Nodo</th><th>Partición</th><th>Intento</th><th>Worker</th><th>Detalle</th></tr>" +
+    errors.map(function (e) {
+      return '<tr data-key="' + esc(e.node_id + "/" + e.partition) + '"><td>' + time(e.time) + "</td><td>" + badge(e.type) +
+        "</td><td>" + esc(e.node_id) + "</td><td>" + (e.node_id ? esc(e.partition) : "") + "</td><td>" + esc(e.attempt || "") +
+        "</td><td>" + workerLink(e.worker_id) + '</td><td class="error">' + esc(e.detail) + "</td></tr>";
+    }).join("") + "</table>";
+}
+
+function eventsTable(events) {
+  return "<details><summary>Línea de tiempo</summary><table><tr><th>#</th><th>Hora</th><th>Tipo</th><th>Nodo</th>" +
+    "<th>Partición</th><th>Intento</th><th>Worker</th><th>Detalle</th></tr>" +
+    events.map(function (e) {
+      return "<tr><td>" + e.seq + "</td><td>" + time(e.time) + "</td><td>" + esc(e.type) + "</td><td>" + esc(e.node_id) +
+        "</td><td>" + (e.node_id ? esc(e.partition) : "") + "</td><td>" + esc(e.attempt || "") + "</td><td>" +
+        workerLink(e.worker_id) + "</td><td>" + esc(e.detail) + "</td></tr>";
+    }).join("") + "</table></details>";
+}
+
+async function renderWorkers(selected) {
+  const workers = await getJSON("/api/v1/workers");
+  const rows = workers.map(function (w) {
+    const m = w.metrics || {};
+    const excluded = w.blacklisted_until ? "hasta " + time(w.blacklisted_until) : (w.blacklisted_jobs || []).length ? "jobs: " + w.blacklisted_jobs.length : "";
+    return '<tr' + (w.id === selected ? ' class="highlight"' : "") + "><td>" + workerLink(w.id) + '<div class="muted mono">' + esc(w.url) + "</div></td>" +
+      "<td>" + badge(w.status) + "</td>" +
+      "<td>" + esc(m.active_tasks || 0) + " / " + esc(w.slots) + "</td>" +
+      "<td>" + esc(w.assigned_tasks) + "</td>" +
+      "<td>" + (m.cpu_usage || 0).toFixed(1) + "% (host " + (m.host_cpu_usage || 0).toFixed(1) + "%)</td>" +
+      "<td>" + bytes(m.memory_usage) + "</td>" +
+      "<td>" + (m.load_avg || []).map(function (l) { return l.toFixed(2); }).join(" ") + "</td>" +
+      "<td>" + (m.disk_free_bytes ? bytes(m.disk_free_bytes) : "-") + "</td>" +
+      "<td>" + ago(w.last_heartbeat) + "</td>" +
+      "<td>" + esc(w.failures) + "</td>" +
+      "<td>" + esc(excluded) + (w.busy_until ? " ocupado" : "") + "</td>" +
+      '<td class="muted">' + esc(w.version || "") + "</td></tr>";
+  });
+  app.innerHTML = "<h2>Workers (" + workers.length + ")</h2>" +
+    "<table><tr><th>Worker</th><th>Estado</th><th>Tareas / slots</th><th>Asignadas</th><th>CPU</th><th>RSS</th>" +
+    "<th>Carga</th><th>Disco libre</th><th>Último heartbeat</th><th>Fallos</th><th>Exclusión</th><th>Versión</th></tr>" +
+    (rows.join("") || '<tr><td colspan="12" class="muted">Sin workers registrados</td></tr>') + "</table>";
+}
+
+// --- Enrutamiento y refresco ---
+
+async function render() {
+  const route = location.hash.replace(/^#/, "") || "/";
+  const parts = route.split("/").filter(Boolean).map(decodeURIComponent);
+  try {
+    if (parts[0] === "jobs" && parts[1]) {
+      await renderJob(parts[1]);
+    } else if (parts[0] === "workers") {
+      await renderWorkers(parts[1]);
+    } else {
+      await renderJobs();
+    }
+    refreshLabel.textContent = "actualizado " + new Date().toLocaleTimeString();
+  } catch (err) {
+    app.innerHTML = '<p class="error">' + esc(err.message) + "</p>";
+  }
+}
+
+function schedule() {
+  clearTimeout(timer);
+  timer = setTimeout(async function () {
+    // No redibujar mientras el usuario tiene abierta la linea de tiempo
+    const open = app.querySelector("details[open]");
+    if (!document.hidden && !open) await render();
+    schedule();
+  }, REFRESH_MS);
+}
+
+window.addEventListener("hashchange", function () {
+  selectedPartition = null;
+  render();
+});
+render();
+schedule();
@@ -0,0 +1,154 @@
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: workers.go
+Descripcion: API de introspeccion de workers del Master.
+             Lista los workers registrados y muestra el detalle de cada
+             uno: estado, URL, metricas recientes (historial de
+             heartbeats), tareas asignadas y fallos. Enruta tambien las
+             operaciones sobre un worker (decommission).
+*/
+
+package master
+
+import (
+	"encoding/json"
+	"mini-spark/internal/common"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// metricsHistorySize heartbeats guardados por worker (~3 min a 3s por heartbeat)
+const metricsHistorySize = 60
+
+// WorkerHandler - Operaciones sobre un worker (/api/v1/workers/{id}[/...])
+// Entrada: w - response writer, r - request
+// Salida: segun la operacion; 404 si la ruta no existe
+// Descripcion: GET /api/v1/workers/{id} devuelve el detalle del worker;
+//
+//	POST /api/v1/workers/{id}/decommission inicia el retiro ordenado.
+func (m *Master) WorkerHandler(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v1/workers/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] != "":
+		m.WorkerDetailHandler(w, r, parts[0])
+	case len(parts) == 2 && parts[0] != "" && parts[1] == "decommission":
+		m.DecommissionWorkerHandler(w, r, parts[0])
+	default:
+		http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+	}
+}
+
+// ListWorkersHandler - Lista los workers registrados
+// Entrada: w - response writer, r - request GET
+// Salida: HTTP 200 con []WorkerStatusResponse ordenado por ID, o 405
+// Descripcion: Incluye estado, metricas, tareas asignadas, fallos
+//
+//	acumulados y exclusiones vigentes (del cluster y por job) de cada worker.
+func (m *Master) ListWorkersHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	m.mu.Lock()
+	assigned := make(map[string]int)
+	for _, wID := range m.TaskAssignments {
+		assigned[wID]++
+	}
+	workers := make([]common.WorkerStatusResponse, 0, len(m.Workers))
+	for id := range m.Workers {
+		ws := m.workerStatus(id)
+		ws.AssignedTasks = assigned[id]
+		workers = append(workers, ws)
+	}
+	m.mu.Unlock()
+
+	sort.Slice(workers, func(i, j int) bool { return workers[i].ID < workers[j].ID })
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(workers)
+}
+
+// WorkerDetailHandler - Detalle de un worker
+// Entrada: w - response writer, r - request GET, workerID - worker a consultar
+// Salida: HTTP 200 con WorkerDetailResponse, 404 si no existe, 405 si no es GET
+// Descripcion: Ademas del estado de la lista incluye las tareas que el
+//
+//	Master le tiene asignadas y el historial de metricas de sus heartbeats.
+func (m *Master) WorkerDetailHandler(w http.ResponseWriter, r *http.Request, workerID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	m.mu.Lock()
+	if _, ok := m.Workers[workerID]; !ok {
+		m.mu.Unlock()
+		http.Error(w, "Worker no encontrado", http.StatusNotFound)
+		return
+	}
+	now := time.Now()
+	resp := common.WorkerDetailResponse{
+		WorkerStatusResponse: m.workerStatus(workerID),
+		Tasks:                []common.WorkerTask{},
+		MetricsHistory:       append([]common.MetricsSample{}, m.metricsHistory[workerID]...),
+	}
+	for taskID, wID := range m.TaskAssignments {
+		if wID != workerID {
+			continue
+		}
+		task := m.RunningTasks[taskID]
+		wt := common.WorkerTask{
+			TaskID:      taskID,
+			JobID:       task.JobID,
+			NodeID:      task.NodeID,
+			PartitionID: task.PartitionID,
+			Attempt:     task.Attempt,
+			Speculative: task.Speculative,
+		}
+		if started, ok := m.TaskStarted[taskID]; ok {
+			wt.RunningSecs = now.Sub(started).Seconds()
+		}
+		resp.Tasks = append(resp.Tasks, wt)
+	}
+	m.mu.Unlock()
+	resp.AssignedTasks = len(resp.Tasks)
+
+	sort.Slice(resp.Tasks, func(i, j int) bool {
+		if resp.Tasks[i].RunningSecs != resp.Tasks[j].RunningSecs {
+			return resp.Tasks[i].RunningSecs > resp.Tasks[j].RunningSecs
+		}
+		return resp.Tasks[i].TaskID < resp.Tasks[j].TaskID
+	})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// workerStatus - Estado de un worker con fallos y exclusiones vigentes
+// Entrada: workerID - worker registrado
+// Salida: WorkerStatusResponse (sin AssignedTasks)
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) workerStatus(workerID string) common.WorkerStatusResponse {
+	ws := common.WorkerStatusResponse{
+		WorkerInfo:      *m.Workers[workerID],
+		Failures:        m.WorkerFailures[workerID],
+		BlacklistedJobs: m.blacklistedJobs(workerID),
+	}
+	if until, ok := m.blacklisted[workerID]; ok && time.Now().Before(until) {
+		ws.BlacklistedUntil = &until
+	}
+	if until, ok := m.busyUntil[workerID]; ok && time.Now().Before(until) {
+		ws.BusyUntil = &until
+	}
+	return ws
+}
+
+// recordMetrics - Agrega las metricas de un heartbeat al historial del worker
+// Descripcion: Conserva las ultimas metricsHistorySize muestras.
+// Nota: Debe llamarse con m.mu tomado
+func (m *Master) recordMetrics(workerID string, metrics common.SystemMetrics) {
+	history := append(m.metricsHistory[workerID], common.MetricsSample{Time: time.Now(), SystemMetrics: metrics})
+	if len(history) > metricsHistorySize {
+		history = append([]common.MetricsSample{}, history[len(history)-metricsHistorySize:]...)
+	}
+	m.metricsHistory[workerID] = history
+}
@@ -0,0 +1,353 @@
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: metrics.go
+Descripcion: Metricas en el formato de texto de Prometheus.
+             Registro minimo de contadores, gauges e histogramas con
+             etiquetas, sin dependencias externas. Master y Worker
+             exponen su registro en GET /metrics para que Prometheus
+             lo lea (scrape).
+*/
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefBuckets limites (segundos) por defecto de los histogramas de duracion
+var DefBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 300}
+
+// Registry conjunto de metricas expuestas en /metrics
+type Registry struct {
+	mu       sync.Mutex
+	families []family // En orden de registro
+	names    map[string]bool
+}
+
+// family metrica registrada (con todas sus series)
+type family interface {
+	name() string
+	write(w io.Writer)
+}
+
+// NewRegistry - Constructor de un registro vacio
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+// register - Agrega una familia; un nombre repetido es un error de programacion
+func (r *Registry) register(f family) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[f.name()] {
+		panic("metrics: metrica registrada dos veces: " + f.name())
+	}
+	r.names[f.name()] = true
+	r.families = append(r.families, f)
+}
+
+// WriteText - Escribe todas las metricas en el formato de texto de Prometheus
+func (r *Registry) WriteText(w io.Writer) {
+	r.mu.Lock()
+	families := append([]family{}, r.families...)
+	r.mu.Unlock()
+	for _, f := range families {
+		f.write(w)
+	}
+}
+
+// ServeHTTP - Handler de GET /metrics
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WriteText(w)
+}
+
+// --- Series con etiquetas ---
+
+// vec series de una familia indexadas por los valores de sus etiquetas
+type vec struct {
+	mu     sync.Mutex
+	fname  string
+	help   string
+	typ    string
+	labels []string
+	series map[string]interface{} // clave de valores -> *Counter | *Gauge | *Histogram
+	values map[string][]string    // clave de valores -> valores
+	create func() interface{}
+}
+
+func newVec(name, help, typ string, labels []string, create func() interface{}) *vec {
+	return &vec{fname: name, help: help, typ: typ, labels: labels,
+		series: make(map[string]interface{}), values: make(map[string][]string), create: create}
+}
+
+func (v *vec) name() string { return v.fname }
+
+// with - Serie para los valores de etiqueta dados (la crea si no existe)
+func (v *vec) with(values []string) interface{} {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s espera %d etiquetas, recibio %d", v.fname, len(v.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	s, ok := v.series[key]
+	if !ok {
+		s = v.create()
+		v.series[key] = s
+		v.values[key] = append([]string{}, values...)
+	}
+	return s
+}
+
+// sortedKeys - Claves de las series en orden estable
+func (v *vec) sortedKeys() []string {
+	keys := make([]string, 0, len(v.series))
+	for k := range v.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (v *vec) write(w io.Writer) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", v.fname, escapeHelp(v.help), v.fname, v.typ)
+	for _, key := range v.sortedKeys() {
+		labels := labelPairs(v.labels, v.values[key])
+		switch s := v.series[key].(type) {
+		case *Counter:
+			fmt.Fprintf(w, "%s%s %s\n", v.fname, formatLabels(labels), formatValue(s.Value()))
+		case *Gauge:
+			fmt.Fprintf(w, "%s%s %s\n", v.fname, formatLabels(labels), formatValue(s.Value()))
+		case *Histogram:
+			s.write(w, v.fname, labels)
+		}
+	}
+}
+
+// --- Contadores ---
+
+// Counter valor que solo crece
+type Counter struct {
+	mu sync.Mutex
+	v  float64
+}
+
+// Inc - Suma 1
+func (c *Counter) Inc() { c.Add(1) }
+
+// Add - Suma delta (los valores negativos se ignoran)
+func (c *Counter) Add(delta float64) {
+	if delta < 0 {
+		return
+	}
+	c.mu.Lock()
+	c.v += delta
+	c.mu.Unlock()
+}
+
+// Value - Valor actual
+func (c *Counter) Value() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.v
+}
+
+// CounterVec contadores de una familia, uno por combinacion de etiquetas
+type CounterVec struct{ v *vec }
+
+// NewCounter - Registra un contador con etiquetas (sin etiquetas usar With())
+func (r *Registry) NewCounter(name, help string, labels ...string) *CounterVec {
+	v := newVec(name, help, "counter", labels, func() interface{} { return &Counter{} })
+	r.register(v)
+	return &CounterVec{v: v}
+}
+
+// With - Contador para los valores de etiqueta dados
+func (c *CounterVec) With(values ...string) *Counter { return c.v.with(values).(*Counter) }
+
+// --- Gauges ---
+
+// Gauge valor que sube y baja
+type Gauge struct {
+	mu sync.Mutex
+	v  float64
+}
+
+// Set - Fija el valor
+func (g *Gauge) Set(v float64) {
+	g.mu.Lock()
+	g.v = v
+	g.mu.Unlock()
+}
+
+// Add - Suma delta (puede ser negativo)
+func (g *Gauge) Add(delta float64) {
+	g.mu.Lock()
+	g.v += delta
+	g.mu.Unlock()
+}
+
+// Value - Valor actual
+func (g *Gauge) Value() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.v
+}
+
+// GaugeVec gauges de una familia, uno por combinacion de etiquetas
+type GaugeVec struct{ v *vec }
+
+// NewGauge - Registra un gauge con etiquetas
+func (r *Registry) NewGauge(name, help string, labels ...string) *GaugeVec {
+	v := newVec(name, help, "gauge", labels, func() interface{} { return &Gauge{} })
+	r.register(v)
+	return &GaugeVec{v: v}
+}
+
+// With - Gauge para los valores de etiqueta dados
+func (g *GaugeVec) With(values ...string) *Gauge { return g.v.with(values).(*Gauge) }
+
+// funcMetric metrica sin etiquetas cuyo valor se calcula al leerla
+type funcMetric struct {
+	fname string
+	help  string
+	typ   string
+	fn    func() float64
+}
+
+func (f *funcMetric) name() string { return f.fname }
+
+func (f *funcMetric) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %s\n", f.fname, escapeHelp(f.help), f.fname, f.typ, f.fname, formatValue(f.fn()))
+}
+
+// NewGaugeFunc - Registra un gauge calculado en cada lectura (ej: largo de la cola)
+// Nota: fn no debe tomar locks que se mantengan durante WriteText
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.register(&funcMetric{fname: name, help: help, typ: "gauge", fn: fn})
+}
+
+// NewCounterFunc - Registra un contador cuyo valor lleva otro componente
+func (r *Registry) NewCounterFunc(name, help string, fn func() float64) {
+	r.register(&funcMetric{fname: name, help: help, typ: "counter", fn: fn})
+}
+
+// --- Histogramas ---
+
+// Histogram distribucion de observaciones en buckets acumulativos
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64 // Limites superiores ordenados
+	counts  []uint64  // Observaciones <= buckets[i] (no acumuladas)
+	count   uint64
+	sum     float64
+}
+
+// Observe - Registra una observacion
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	i := sort.SearchFloat64s(h.buckets, v)
+	if i < len(h.counts) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += v
+}
+
+// Count - Numero de observaciones
+func (h *Histogram) Count() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+func (h *Histogram) write(w io.Writer, name string, labels [][2]string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var cumulative uint64
+	for i, le := range h.buckets {
+		cumulative += h.counts[i]
+		fmt.Fprintf(w, "%s_bucket%s %d\n", name, formatLabels(append(labels, [2]string{"le", formatValue(le)})), cumulative)
+	}
+	fmt.Fprintf(w, "%s_bucket%s %d\n", name, formatLabels(append(labels, [2]string{"le", "+Inf"})), h.count)
+	fmt.Fprintf(w, "%s_sum%s %s\n", name, formatLabels(labels), formatValue(h.sum))
+	fmt.Fprintf(w, "%s_count%s %d\n", name, formatLabels(labels), h.count)
+}
+
+// HistogramVec histogramas de una familia, uno por combinacion de etiquetas
+type HistogramVec struct{ v *vec }
+
+// NewHistogram - Registra un histograma con etiquetas
+// Entrada: buckets - limites superiores (nil = DefBuckets)
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefBuckets
+	}
+	sorted := append([]float64{}, buckets...)
+	sort.Float64s(sorted)
+	v := newVec(name, help, "histogram", labels, func() interface{} {
+		return &Histogram{buckets: sorted, counts: make([]uint64, len(sorted))}
+	})
+	r.register(v)
+	return &HistogramVec{v: v}
+}
+
+// With - Histograma para los valores de etiqueta dados
+func (h *HistogramVec) With(values ...string) *Histogram { return h.v.with(values).(*Histogram) }
+
+// --- Formato ---
+
+// labelPairs - Une nombres y valores de etiquetas
+func labelPairs(names, values []string) [][2]string {
+	pairs := make([][2]string, len(names))
+	for i := range names {
+		pairs[i] = [2]string{names[i], values[i]}
+	}
+	return pairs
+}
+
+// formatLabels - {a="x",b="y"} ("" si no hay etiquetas)
+func formatLabels(pairs [][2]string) string {
+	if len(pairs) == 0 {
+		return ""
+	}
+	parts := make([]string, len(pairs))
+	for i, p := range pairs {
+		parts[i] = p[0] + `="` + escapeLabel(p[1]) + `"`
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// formatValue - Valor numerico en el formato de Prometheus
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
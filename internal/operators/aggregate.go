@@ -0,0 +1,374 @@
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: aggregate.go
+Descripcion: Agregaciones declarativas por clave (group_by_key, aggregate_by_key).
+             Permite agrupar filas CSV por una o mas columnas y calcular
+             varios agregados a la vez (sum, count, min, max, avg).
+             Expone el estado parcial de cada grupo para que el Worker
+             pueda hacer spill a disco y mezclar resultados parciales.
+*/
+
+package operators
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// keySeparator - Separador interno para claves compuestas en mapas
+const keySeparator = "\x1f"
+
+// AggregateSpec describe un agregado ya resuelto contra el esquema
+type AggregateSpec struct {
+	Func   string // sum | count | min | max | avg
+	Column int    // Indice de columna (-1 para count(*))
+}
+
+// AggAccumulator estado parcial de un agregado (serializable para spill)
+type AggAccumulator struct {
+	Count int64   `json:"c"`             // Filas (count) o valores numericos (avg)
+	Sum   float64 `json:"s"`             // Suma acumulada (sum, avg)
+	Min   string  `json:"min,omitempty"` // Minimo observado
+	Max   string  `json:"max,omitempty"` // Maximo observado
+	Seen  bool    `json:"v,omitempty"`   // true si min/max tienen valor
+}
+
+// GroupState estado parcial de un grupo: un acumulador por agregado
+type GroupState []AggAccumulator
+
+// SplitRow - Divide una linea CSV en campos sin espacios laterales
+// Entrada: line - linea de texto separada por comas
+// Salida: slice de campos
+// Descripcion: Los operadores escriben "a, b, c" (con espacio), por lo que
+//
+//	se recortan los espacios para que las columnas sean comparables.
+func SplitRow(line string) []string {
+	fields := strings.Split(line, ",")
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	return fields
+}
+
+// ResolveColumn - Traduce una referencia de columna a su indice
+// Entrada: ref - nombre de columna o indice numerico, columns - esquema opcional
+// Salida: indice de columna (base 0) o error si no existe
+// Descripcion: Acepta nombres declarados en el esquema del nodo o
+//
+//	posiciones numericas directas ("0", "2").
+func ResolveColumn(ref string, columns []string) (int, error) {
+	ref = strings.TrimSpace(ref)
+	for i, c := range columns {
+		if strings.EqualFold(c, ref) {
+			return i, nil
+		}
+	}
+	if idx, err := strconv.Atoi(ref); err == nil && idx >= 0 {
+		return idx, nil
+	}
+	return 0, specErrorf("columna desconocida: %s", ref)
+}
+
+// ResolveColumns - Resuelve una lista de referencias de columnas
+// Entrada: refs - nombres o indices, columns - esquema opcional
+// Salida: slice de indices o error en la primera referencia invalida
+func ResolveColumns(refs []string, columns []string) ([]int, error) {
+	idx := make([]int, 0, len(refs))
+	for _, ref := range refs {
+		i, err := ResolveColumn(ref, columns)
+		if err != nil {
+			return nil, err
+		}
+		idx = append(idx, i)
+	}
+	return idx, nil
+}
+
+// ParseAggregates - Interpreta expresiones de agregacion del DAG
+// Entrada: exprs - expresiones tipo "sum(amount)", columns - esquema opcional
+// Salida: slice de AggregateSpec o error si la expresion es invalida
+// Descripcion: Soporta sum, count, min, max y avg. count(*) cuenta filas.
+func ParseAggregates(exprs []string, columns []string) ([]AggregateSpec, error) {
+	specs := make([]AggregateSpec, 0, len(exprs))
+	for _, expr := range exprs {
+		e := strings.TrimSpace(expr)
+		open := strings.Index(e, "(")
+		if open <= 0 || !strings.HasSuffix(e, ")") {
+			return nil, specErrorf("agregado invalido: %s", expr)
+		}
+		fn := strings.ToLower(strings.TrimSpace(e[:open]))
+		arg := strings.TrimSpace(e[open+1 : len(e)-1])
+
+		switch fn {
+		case "sum", "count", "min", "max", "avg":
+		default:
+			return nil, specErrorf("funcion de agregacion desconocida: %s", fn)
+		}
+
+		col := -1
+		if arg != "*" {
+			i, err := ResolveColumn(arg, columns)
+			if err != nil {
+				return nil, err
+			}
+			col = i
+		} else if fn != "count" {
+			return nil, specErrorf("%s(*) no soportado", fn)
+		}
+		specs = append(specs, AggregateSpec{Func: fn, Column: col})
+	}
+	return specs, nil
+}
+
+// NewGroupState - Crea estado vacio para un grupo
+func NewGroupState(n int) GroupState {
+	return make(GroupState, n)
+}
+
+// Add - Acumula una fila en el estado del grupo
+// Entrada: specs - agregados resueltos, fields - campos de la fila
+// Salida: ninguna (void)
+// Descripcion: Valores no numericos se ignoran en sum/avg; min/max comparan
+//
+//	numericamente si ambos valores son numeros y lexicograficamente si no.
+func (g GroupState) Add(specs []AggregateSpec, fields []string) {
+	for i, spec := range specs {
+		acc := &g[i]
+		if spec.Column < 0 {
+			acc.Count++
+			continue
+		}
+		if spec.Column >= len(fields) {
+			continue
+		}
+		val := fields[spec.Column]
+		switch spec.Func {
+		case "count":
+			if val != "" {
+				acc.Count++
+			}
+		case "sum", "avg":
+			if f, err := strconv.ParseFloat(val, 64); err == nil {
+				acc.Sum += f
+				acc.Count++
+			}
+		case "min", "max":
+			acc.observe(val)
+		}
+	}
+}
+
+// Merge - Combina otro estado parcial del mismo grupo (usado tras spill)
+func (g GroupState) Merge(other GroupState) {
+	for i := range g {
+		if i >= len(other) {
+			break
+		}
+		g[i].Count += other[i].Count
+		g[i].Sum += other[i].Sum
+		if other[i].Seen {
+			g[i].observe(other[i].Min)
+			g[i].observe(other[i].Max)
+		}
+	}
+}
+
+// observe - Actualiza min/max con un nuevo valor
+func (a *AggAccumulator) observe(val string) {
+	if !a.Seen {
+		a.Min, a.Max, a.Seen = val, val, true
+		return
+	}
+	if CompareValues(val, a.Min) < 0 {
+		a.Min = val
+	}
+	if CompareValues(val, a.Max) > 0 {
+		a.Max = val
+	}
+}
+
+// CompareValues - Compara dos valores de columna
+// Entrada: a, b - valores en texto
+// Salida: -1, 0 o 1
+// Descripcion: Si ambos son numericos compara como float64; en otro caso
+//
+//	usa orden lexicografico (valido para fechas ISO y timestamps).
+func CompareValues(a, b string) int {
+	fa, errA := strconv.ParseFloat(a, 64)
+	fb, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a, b)
+}
+
+// FormatGroup - Produce la fila final de un grupo
+// Entrada: specs - agregados, key - valores de agrupacion, g - estado final
+// Salida: linea "k1, k2, agg1, agg2" (mismo separador que reduce_by_key)
+func FormatGroup(specs []AggregateSpec, key []string, g GroupState) string {
+	out := append([]string{}, key...)
+	for i, spec := range specs {
+		acc := g[i]
+		switch spec.Func {
+		case "count":
+			out = append(out, strconv.FormatInt(acc.Count, 10))
+		case "sum":
+			out = append(out, strconv.FormatFloat(acc.Sum, 'f', -1, 64))
+		case "avg":
+			avg := 0.0
+			if acc.Count > 0 {
+				avg = acc.Sum / float64(acc.Count)
+			}
+			out = append(out, strconv.FormatFloat(avg, 'f', -1, 64))
+		case "min":
+			out = append(out, acc.Min)
+		case "max":
+			out = append(out, acc.Max)
+		}
+	}
+	return strings.Join(out, ", ")
+}
+
+// GroupKey - Extrae la clave compuesta de una fila
+// Entrada: fields - campos de la fila, keyCols - indices de agrupacion
+// Salida: clave serializada para usar en mapas y valores de la clave
+// Descripcion: Filas sin alguna columna de agrupacion retornan ok=false.
+func GroupKey(fields []string, keyCols []int) (string, []string, bool) {
+	key := make([]string, len(keyCols))
+	for i, c := range keyCols {
+		if c >= len(fields) {
+			return "", nil, false
+		}
+		key[i] = fields[c]
+	}
+	return strings.Join(key, keySeparator), key, true
+}
+
+// SplitGroupKey - Recupera los valores de una clave serializada
+func SplitGroupKey(k string) []string {
+	return strings.Split(k, keySeparator)
+}
+
+// spillRecord formato de una linea de archivo spill de agregacion
+type spillRecord struct {
+	Key   string     `json:"k"`
+	State GroupState `json:"a"`
+}
+
+// EncodeSpillLine - Serializa un grupo parcial como linea JSON
+func EncodeSpillLine(key string, g GroupState) (string, error) {
+	b, err := json.Marshal(spillRecord{Key: key, State: g})
+	return string(b), err
+}
+
+// DecodeSpillLine - Deserializa una linea generada por EncodeSpillLine
+func DecodeSpillLine(line string) (string, GroupState, error) {
+	var rec spillRecord
+	if err := json.Unmarshal([]byte(line), &rec); err != nil {
+		return "", nil, err
+	}
+	return rec.Key, rec.State, nil
+}
+
+// PrepareAggregation - Resuelve columnas y agregados de un nodo
+// Entrada: op - group_by_key | aggregate_by_key, groupBy, aggregates, columns
+// Salida: indices de agrupacion, agregados resueltos o error
+// Descripcion: group_by_key sin agregados equivale a count(*) por grupo.
+func PrepareAggregation(op string, groupBy, aggregates, columns []string) ([]int, []AggregateSpec, error) {
+	if len(groupBy) == 0 {
+		return nil, nil, specErrorf("%s requiere group_by", op)
+	}
+	keyCols, err := ResolveColumns(groupBy, columns)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(aggregates) == 0 {
+		if op != "group_by_key" {
+			return nil, nil, specErrorf("%s requiere al menos un agregado", op)
+		}
+		aggregates = []string{"count(*)"}
+	}
+	specs, err := ParseAggregates(aggregates, columns)
+	if err != nil {
+		return nil, nil, err
+	}
+	return keyCols, specs, nil
+}
+
+// WriteGroups - Escribe grupos finales ordenados por clave
+// Entrada: output - archivo destino, specs - agregados, groups - mapa clave->estado
+// Salida: error si falla I/O
+func WriteGroups(ctx context.Context, output string, specs []AggregateSpec, groups map[string]GroupState) error {
+	keys := make([]string, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := NewWriter(ctx, f)
+	for _, k := range keys {
+		w.WriteString(FormatGroup(specs, SplitGroupKey(k), groups[k]) + "\n")
+	}
+	return w.Flush()
+}
+
+// AggregateByKey - Agrupa filas por columnas y calcula varios agregados
+// Entrada: inputs - archivos CSV, output - destino, op - group_by_key|aggregate_by_key,
+//
+//	groupBy - columnas clave, aggregates - expresiones, columns - esquema opcional
+//
+// Salida: error si la configuracion es invalida o falla I/O
+// Descripcion: Version en memoria (analoga a ReduceByKey). El Worker usa
+//
+//	una variante con spill a disco para datasets grandes.
+func AggregateByKey(ctx context.Context, inputs []string, output, op string, groupBy, aggregates, columns []string) error {
+	keyCols, specs, err := PrepareAggregation(op, groupBy, aggregates, columns)
+	if err != nil {
+		return err
+	}
+
+	groups := make(map[string]GroupState)
+	for _, in := range inputs {
+		file, err := os.Open(in)
+		if err != nil {
+			continue
+		}
+		scanner := NewScanner(ctx, file)
+		for scanner.Scan() {
+			if strings.TrimSpace(scanner.Text()) == "" {
+				continue
+			}
+			fields := SplitRow(scanner.Text())
+			k, _, ok := GroupKey(fields, keyCols)
+			if !ok {
+				continue
+			}
+			g, exists := groups[k]
+			if !exists {
+				g = NewGroupState(len(specs))
+				groups[k] = g
+			}
+			g.Add(specs, fields)
+		}
+		file.Close()
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+	return WriteGroups(ctx, output, specs, groups)
+}
@@ -0,0 +1,26 @@
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: errors.go
+Descripcion: Errores de configuracion de operadores.
+             Distinguen una tarea mal definida (UDF inexistente,
+             columna o agregado invalido) de un fallo de ejecucion,
+             para que el Worker la reporte como fallo no reintentable.
+*/
+
+package operators
+
+import "fmt"
+
+// SpecError error en la definicion de la tarea; reintentar no lo corrige
+type SpecError struct {
+	Msg string
+}
+
+func (e *SpecError) Error() string {
+	return e.Msg
+}
+
+// specErrorf - Construye un SpecError con formato
+func specErrorf(format string, args ...interface{}) error {
+	return &SpecError{Msg: fmt.Sprintf(format, args...)}
+}
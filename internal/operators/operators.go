@@ -12,9 +12,14 @@ package operators
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -61,6 +66,131 @@ var FlatMapFunctions = map[string]func(string) []string{
 	},
 }
 
+// UDFNames - Nombres de las UDFs registradas como "tipo:nombre"
+// Salida: lista ordenada (ej: "filter:long_words", "map:to_lower")
+// Descripcion: El Worker la anuncia al registrarse; el Master la compara
+//
+//	con la suya para detectar builds incompatibles.
+func UDFNames() []string {
+	var names []string
+	for name := range MapFunctions {
+		names = append(names, "map:"+name)
+	}
+	for name := range FilterFunctions {
+		names = append(names, "filter:"+name)
+	}
+	for name := range FlatMapFunctions {
+		names = append(names, "flat_map:"+name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// --- Lectura cancelable ---
+
+// Scanner lector de lineas que se detiene al cancelarse su contexto
+// Descripcion: Los operadores lo usan en lugar de bufio.Scanner para que
+//
+//	una tarea cancelada por el Master deje de leer filas. Err devuelve
+//	ctx.Err() si la lectura se interrumpio por la cancelacion.
+type Scanner struct {
+	*bufio.Scanner
+	ctx    context.Context
+	err    error
+	counts *RecordCounts // Destino del conteo de lineas leidas (nil = no cuenta)
+}
+
+// NewScanner - Scanner de lineas sobre r ligado a ctx
+// Descripcion: Cada linea leida suma a RecordCounts.In si ctx lleva
+//
+//	contadores (ver WithRecordCounts). Para archivos de entrada.
+func NewScanner(ctx context.Context, r io.Reader) *Scanner {
+	return &Scanner{Scanner: bufio.NewScanner(r), ctx: ctx, counts: recordCounts(ctx)}
+}
+
+// NewSpillScanner - Scanner sobre archivos intermedios (spills, runs)
+// Descripcion: Igual que NewScanner pero sus lineas no cuentan como
+//
+//	registros de entrada: ya se contaron al leer la entrada original.
+func NewSpillScanner(ctx context.Context, r io.Reader) *Scanner {
+	return &Scanner{Scanner: bufio.NewScanner(r), ctx: ctx}
+}
+
+// Scan - Avanza a la siguiente linea; false al terminar, fallar o cancelarse ctx
+func (s *Scanner) Scan() bool {
+	if s.err != nil {
+		return false
+	}
+	if err := s.ctx.Err(); err != nil {
+		s.err = err
+		return false
+	}
+	if !s.Scanner.Scan() {
+		return false
+	}
+	if s.counts != nil {
+		atomic.AddInt64(&s.counts.In, 1)
+	}
+	return true
+}
+
+// Err - Error de lectura o de cancelacion (nil si se leyo todo)
+func (s *Scanner) Err() error {
+	if s.err != nil {
+		return s.err
+	}
+	return s.Scanner.Err()
+}
+
+// --- Conteo de registros ---
+
+// RecordCounts registros leidos de las entradas y escritos en la salida
+// Descripcion: Los operadores los cuentan mientras procesan (Scanner y
+//
+//	NewWriter), asi el Worker no vuelve a leer los archivos para medirlos.
+type RecordCounts struct {
+	In  int64 // Lineas leidas de los archivos de entrada
+	Out int64 // Lineas escritas en el archivo de salida
+}
+
+// recordCountsKey clave de RecordCounts en el contexto
+type recordCountsKey struct{}
+
+// WithRecordCounts - Contexto cuyos operadores cuentan registros en counts
+func WithRecordCounts(ctx context.Context, counts *RecordCounts) context.Context {
+	return context.WithValue(ctx, recordCountsKey{}, counts)
+}
+
+// recordCounts - Contadores del contexto (nil si no se pidieron)
+func recordCounts(ctx context.Context) *RecordCounts {
+	counts, _ := ctx.Value(recordCountsKey{}).(*RecordCounts)
+	return counts
+}
+
+// lineCounter io.Writer que cuenta los saltos de linea que pasan por el
+type lineCounter struct {
+	w     io.Writer
+	count *int64
+}
+
+// Write - Escribe p en el destino y suma sus saltos de linea
+func (c lineCounter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	atomic.AddInt64(c.count, int64(bytes.Count(p[:n], []byte{'\n'})))
+	return n, err
+}
+
+// NewWriter - Writer con buffer para el archivo de salida de un operador
+// Descripcion: Cada linea escrita suma a RecordCounts.Out si ctx lleva
+//
+//	contadores. Los spills usan bufio.NewWriter: no son salida.
+func NewWriter(ctx context.Context, w io.Writer) *bufio.Writer {
+	if counts := recordCounts(ctx); counts != nil {
+		return bufio.NewWriter(lineCounter{w: w, count: &counts.Out})
+	}
+	return bufio.NewWriter(w)
+}
+
 // --- Operadores Core ---
 
 // ReadCSV - Lee archivo de texto/CSV linea por linea
@@ -69,7 +199,7 @@ var FlatMapFunctions = map[string]func(string) []string{
 // Descripcion: Copia archivo de entrada a salida sin transformacion.
 //
 //	Usado como nodo source en DAGs.
-func ReadCSV(inputPath, outputPath string) error {
+func ReadCSV(ctx context.Context, inputPath, outputPath string) error {
 	// Abrir archivo de entrada
 	inFile, err := os.Open(inputPath)
 	if err != nil {
@@ -83,11 +213,14 @@ func ReadCSV(inputPath, outputPath string) error {
 	}
 	defer outFile.Close()
 	// Copiar linea por linea
-	scanner := bufio.NewScanner(inFile)
-	writer := bufio.NewWriter(outFile)
+	scanner := NewScanner(ctx, inFile)
+	writer := NewWriter(ctx, outFile)
 	for scanner.Scan() {
 		writer.WriteString(scanner.Text() + "\n")
 	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
 	return writer.Flush()
 }
 
@@ -97,11 +230,11 @@ func ReadCSV(inputPath, outputPath string) error {
 // Descripcion: Lee todos los archivos de entrada, aplica funcion de transformacion
 //
 //	registrada en MapFunctions, escribe lineas transformadas a salida.
-func Map(inputs []string, output string, fnName string) error {
+func Map(ctx context.Context, inputs []string, output string, fnName string) error {
 	// Buscar funcion registrada
 	fn, ok := MapFunctions[fnName]
 	if !ok {
-		return fmt.Errorf("fn map no encontrada: %s", fnName)
+		return specErrorf("fn map no encontrada: %s", fnName)
 	}
 
 	// Crear archivo de salida
@@ -110,7 +243,7 @@ func Map(inputs []string, output string, fnName string) error {
 		return err
 	}
 	defer f.Close()
-	w := bufio.NewWriter(f)
+	w := NewWriter(ctx, f)
 
 	// Procesar cada archivo de entrada
 	for _, in := range inputs {
@@ -118,12 +251,15 @@ func Map(inputs []string, output string, fnName string) error {
 		if file == nil {
 			continue
 		}
-		scanner := bufio.NewScanner(file)
+		scanner := NewScanner(ctx, file)
 		// Aplicar funcion a cada linea
 		for scanner.Scan() {
 			w.WriteString(fn(scanner.Text()) + "\n")
 		}
 		file.Close()
+		if err := scanner.Err(); err != nil {
+			return err
+		}
 	}
 	return w.Flush()
 }
@@ -134,11 +270,11 @@ func Map(inputs []string, output string, fnName string) error {
 // Descripcion: Similar a Map pero cada linea puede generar 0 o mas lineas de salida.
 //
 //	Usado tipicamente para tokenizacion (ej: texto -> palabras).
-func FlatMap(inputs []string, output string, fnName string) error {
+func FlatMap(ctx context.Context, inputs []string, output string, fnName string) error {
 	// Buscar funcion registrada
 	fn, ok := FlatMapFunctions[fnName]
 	if !ok {
-		return fmt.Errorf("fn flat_map no encontrada")
+		return specErrorf("fn flat_map no encontrada")
 	}
 
 	// Crear archivo de salida
@@ -147,7 +283,7 @@ func FlatMap(inputs []string, output string, fnName string) error {
 		return err
 	}
 	defer f.Close()
-	w := bufio.NewWriter(f)
+	w := NewWriter(ctx, f)
 
 	// Procesar cada archivo de entrada
 	for _, in := range inputs {
@@ -155,7 +291,7 @@ func FlatMap(inputs []string, output string, fnName string) error {
 		if file == nil {
 			continue
 		}
-		scanner := bufio.NewScanner(file)
+		scanner := NewScanner(ctx, file)
 		// Aplicar funcion y escribir todos los items generados
 		for scanner.Scan() {
 			for _, item := range fn(scanner.Text()) {
@@ -163,6 +299,9 @@ func FlatMap(inputs []string, output string, fnName string) error {
 			}
 		}
 		file.Close()
+		if err := scanner.Err(); err != nil {
+			return err
+		}
 	}
 	return w.Flush()
 }
@@ -173,11 +312,11 @@ func FlatMap(inputs []string, output string, fnName string) error {
 // Descripcion: Aplica funcion predicado a cada linea. Solo lineas que retornan
 //
 //	true se escriben a salida. Reduce volumen de datos.
-func Filter(inputs []string, output string, fnName string) error {
+func Filter(ctx context.Context, inputs []string, output string, fnName string) error {
 	// Buscar funcion predicado
 	fn, ok := FilterFunctions[fnName]
 	if !ok {
-		return fmt.Errorf("fn filter no encontrada")
+		return specErrorf("fn filter no encontrada")
 	}
 
 	// Crear archivo de salida
@@ -186,7 +325,7 @@ func Filter(inputs []string, output string, fnName string) error {
 		return err
 	}
 	defer f.Close()
-	w := bufio.NewWriter(f)
+	w := NewWriter(ctx, f)
 
 	// Procesar cada archivo de entrada
 	for _, in := range inputs {
@@ -194,7 +333,7 @@ func Filter(inputs []string, output string, fnName string) error {
 		if file == nil {
 			continue
 		}
-		scanner := bufio.NewScanner(file)
+		scanner := NewScanner(ctx, file)
 		// Aplicar predicado y escribir solo lineas que pasan
 		for scanner.Scan() {
 			line := scanner.Text()
@@ -203,6 +342,9 @@ func Filter(inputs []string, output string, fnName string) error {
 			}
 		}
 		file.Close()
+		if err := scanner.Err(); err != nil {
+			return err
+		}
 	}
 	return w.Flush()
 }
@@ -214,7 +356,7 @@ func Filter(inputs []string, output string, fnName string) error {
 //
 //	Lee todos los inputs, mantiene mapa en memoria,
 //	escribe resultado "clave, contador". Operacion shuffle.
-func ReduceByKey(inputs []string, output string) error {
+func ReduceByKey(ctx context.Context, inputs []string, output string) error {
 	// Mapa en memoria para conteo
 	counts := make(map[string]int)
 	// Leer y contar todas las claves
@@ -223,12 +365,15 @@ func ReduceByKey(inputs []string, output string) error {
 		if err != nil {
 			continue
 		}
-		scanner := bufio.NewScanner(file)
+		scanner := NewScanner(ctx, file)
 		for scanner.Scan() {
 			// Incrementar contador de la clave
 			counts[scanner.Text()]++
 		}
 		file.Close()
+		if err := scanner.Err(); err != nil {
+			return err
+		}
 	}
 	// Escribir resultados agregados
 	f, err := os.Create(output)
@@ -236,7 +381,7 @@ func ReduceByKey(inputs []string, output string) error {
 		return err
 	}
 	defer f.Close()
-	w := bufio.NewWriter(f)
+	w := NewWriter(ctx, f)
 	for k, v := range counts {
 		w.WriteString(fmt.Sprintf("%s, %d\n", k, v))
 	}
@@ -246,26 +391,30 @@ func ReduceByKey(inputs []string, output string) error {
 // Join - Realiza inner join de dos archivos CSV por primera columna
 // Entrada: leftFile - archivo izquierdo, rightFile - archivo derecho, output - destino
 // Salida: error si falla I/O
-// Descripcion: Carga leftFile completo en memoria como mapa (clave -> valor).
+// Descripcion: Carga leftFile completo en memoria como mapa (clave -> valores).
 //
 //	Itera rightFile y busca coincidencias, escribiendo join result.
+//	Las claves repetidas producen el producto cruzado de sus filas.
 //	Formato salida: "clave, valor_left, valor_right"
-func Join(leftFile, rightFile, output string) error {
+func Join(ctx context.Context, leftFile, rightFile, output string) error {
 	// Cargar archivo izquierdo en mapa (hash join)
-	leftMap := make(map[string]string)
+	leftMap := make(map[string][]string)
 	lFile, err := os.Open(leftFile)
 	if err != nil {
 		return err
 	}
-	lScanner := bufio.NewScanner(lFile)
+	lScanner := NewScanner(ctx, lFile)
 	for lScanner.Scan() {
 		// Parsear linea como "clave, valor"
 		parts := strings.SplitN(lScanner.Text(), ",", 2)
 		if len(parts) == 2 {
-			leftMap[parts[0]] = parts[1]
+			leftMap[parts[0]] = append(leftMap[parts[0]], parts[1])
 		}
 	}
 	lFile.Close()
+	if err := lScanner.Err(); err != nil {
+		return err
+	}
 
 	// Abrir archivo derecho
 	rFile, err := os.Open(rightFile)
@@ -280,20 +429,23 @@ func Join(leftFile, rightFile, output string) error {
 		return err
 	}
 	defer outFile.Close()
-	w := bufio.NewWriter(outFile)
+	w := NewWriter(ctx, outFile)
 
 	// Iterar archivo derecho y buscar coincidencias
-	rScanner := bufio.NewScanner(rFile)
+	rScanner := NewScanner(ctx, rFile)
 	for rScanner.Scan() {
 		parts := strings.SplitN(rScanner.Text(), ",", 2)
 		if len(parts) == 2 {
 			key := parts[0]
 			valRight := parts[1]
-			// Si hay match, escribir join result
-			if valLeft, ok := leftMap[key]; ok {
+			// Una fila de salida por cada fila izquierda con la misma clave
+			for _, valLeft := range leftMap[key] {
 				w.WriteString(fmt.Sprintf("%s, %s, %s\n", key, valLeft, valRight))
 			}
 		}
 	}
+	if err := rScanner.Err(); err != nil {
+		return err
+	}
 	return w.Flush()
 }
@@ -0,0 +1,237 @@
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: where.go
+Descripcion: Operadores declarativos sobre columnas CSV.
+             Implementa filtros por condiciones ("amount > 10"),
+             proyeccion/reordenamiento de columnas y lectura de
+             JSONL a filas CSV segun un esquema de columnas.
+             Son el destino de compilacion del front-end SQL.
+*/
+
+package operators
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Condition predicado simple "columna operador valor"
+type Condition struct {
+	Column   int    // Columna izquierda
+	Operator string // = | != | < | <= | > | >=
+	Value    string // Literal derecho (si RightCol < 0)
+	RightCol int    // Columna derecha (-1 si se compara contra literal)
+}
+
+// conditionOperators en orden de busqueda (los de dos caracteres primero)
+var conditionOperators = []string{"!=", "<>", "<=", ">=", "=", "<", ">"}
+
+// ParseCondition - Interpreta una condicion de filtro
+// Entrada: expr - texto "col op valor" o "col op col", columns - esquema
+// Salida: Condition o error si la expresion es invalida
+// Descripcion: Los literales de texto van entre comillas simples ('norte').
+//
+//	Un lado derecho sin comillas que coincide con una columna del
+//	esquema se interpreta como comparacion entre columnas.
+func ParseCondition(expr string, columns []string) (Condition, error) {
+	// Ubicar el primer operador de la expresion (el mas largo si empatan)
+	pos, op := -1, ""
+	for _, candidate := range conditionOperators {
+		idx := strings.Index(expr, candidate)
+		if idx > 0 && (pos < 0 || idx < pos) {
+			pos, op = idx, candidate
+		}
+	}
+	if pos < 0 {
+		return Condition{}, specErrorf("condicion invalida: %s", expr)
+	}
+
+	left := strings.TrimSpace(expr[:pos])
+	right := strings.TrimSpace(expr[pos+len(op):])
+	if op == "<>" {
+		op = "!="
+	}
+	col, err := ResolveColumn(left, columns)
+	if err != nil {
+		return Condition{}, err
+	}
+	cond := Condition{Column: col, Operator: op, RightCol: -1}
+	if len(right) >= 2 && strings.HasPrefix(right, "'") && strings.HasSuffix(right, "'") {
+		cond.Value = right[1 : len(right)-1]
+	} else if rc, ok := namedColumn(right, columns); ok {
+		cond.RightCol = rc
+	} else {
+		cond.Value = right
+	}
+	return cond, nil
+}
+
+// namedColumn - Busca una columna solo por nombre (no por indice)
+func namedColumn(ref string, columns []string) (int, bool) {
+	for i, c := range columns {
+		if strings.EqualFold(c, ref) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// Matches - Evalua la condicion sobre una fila
+func (c Condition) Matches(fields []string) bool {
+	left := field(fields, c.Column)
+	right := c.Value
+	if c.RightCol >= 0 {
+		right = field(fields, c.RightCol)
+	}
+	r := CompareValues(left, right)
+	switch c.Operator {
+	case "=":
+		return r == 0
+	case "!=":
+		return r != 0
+	case "<":
+		return r < 0
+	case "<=":
+		return r <= 0
+	case ">":
+		return r > 0
+	case ">=":
+		return r >= 0
+	}
+	return false
+}
+
+// FilterWhere - Filtra filas que cumplen todas las condiciones (AND)
+// Entrada: inputs - archivos CSV, output - destino, where - condiciones, columns - esquema
+// Salida: error si alguna condicion es invalida o falla I/O
+// Descripcion: Variante declarativa de Filter; las lineas se emiten sin cambios.
+func FilterWhere(ctx context.Context, inputs []string, output string, where, columns []string) error {
+	conds := make([]Condition, 0, len(where))
+	for _, w := range where {
+		c, err := ParseCondition(w, columns)
+		if err != nil {
+			return err
+		}
+		conds = append(conds, c)
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := NewWriter(ctx, f)
+
+	for _, in := range inputs {
+		file, err := os.Open(in)
+		if err != nil {
+			continue
+		}
+		scanner := NewScanner(ctx, file)
+		for scanner.Scan() {
+			line := scanner.Text()
+			fields := SplitRow(line)
+			pass := true
+			for _, c := range conds {
+				if !c.Matches(fields) {
+					pass = false
+					break
+				}
+			}
+			if pass {
+				w.WriteString(line + "\n")
+			}
+		}
+		file.Close()
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// Project - Selecciona y reordena columnas de cada fila
+// Entrada: inputs - archivos CSV, output - destino, selectCols - columnas, columns - esquema
+// Salida: error si alguna columna no existe o falla I/O
+// Descripcion: Escribe "c1, c2, ..." (mismo separador que el resto de operadores).
+//
+//	Se usa, por ejemplo, para mover la clave de join a la primera columna.
+func Project(ctx context.Context, inputs []string, output string, selectCols, columns []string) error {
+	idx, err := ResolveColumns(selectCols, columns)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := NewWriter(ctx, f)
+
+	for _, in := range inputs {
+		file, err := os.Open(in)
+		if err != nil {
+			continue
+		}
+		scanner := NewScanner(ctx, file)
+		for scanner.Scan() {
+			if strings.TrimSpace(scanner.Text()) == "" {
+				continue
+			}
+			fields := SplitRow(scanner.Text())
+			out := make([]string, len(idx))
+			for i, c := range idx {
+				out[i] = field(fields, c)
+			}
+			w.WriteString(strings.Join(out, ", ") + "\n")
+		}
+		file.Close()
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// ReadJSONL - Lee un archivo JSON Lines y lo convierte a filas CSV
+// Entrada: inputPath - archivo JSONL, outputPath - destino, columns - campos a extraer
+// Salida: error si falla lectura/escritura
+// Descripcion: Cada objeto JSON produce una fila con los campos del esquema
+//
+//	en orden. Campos ausentes quedan vacios; lineas invalidas se omiten.
+func ReadJSONL(ctx context.Context, inputPath, outputPath string, columns []string) error {
+	inFile, err := os.Open(inputPath)
+	if err != nil {
+		return err
+	}
+	defer inFile.Close()
+	outFile, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+
+	scanner := NewScanner(ctx, inFile)
+	writer := NewWriter(ctx, outFile)
+	for scanner.Scan() {
+		var obj map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &obj); err != nil {
+			continue
+		}
+		out := make([]string, len(columns))
+		for i, c := range columns {
+			if v, ok := obj[c]; ok && v != nil {
+				out[i] = fmt.Sprint(v)
+			}
+		}
+		writer.WriteString(strings.Join(out, ", ") + "\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,235 @@
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: window.go
+Descripcion: Operadores de ventana por clave (top_n_by_key, window).
+             Particionan filas CSV por una o mas columnas, las ordenan
+             dentro de cada particion y emiten rank, dense_rank,
+             row_number o las N primeras filas por clave.
+             El orden es compartido con el sort externo del Worker.
+*/
+
+package operators
+
+import (
+	"context"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OrderKey columna de ordenamiento dentro de una particion
+type OrderKey struct {
+	Column int  // Indice de columna
+	Desc   bool // true para orden descendente
+}
+
+// WindowSpec configuracion resuelta de un operador de ventana
+type WindowSpec struct {
+	Op        string     // top_n_by_key | window
+	Fn        string     // row_number | rank | dense_rank
+	PartCols  []int      // Columnas de particion
+	Order     []OrderKey // Columnas de orden
+	Limit     int        // N maximo por particion (0 = sin limite)
+	EmitValue bool       // true si se agrega la columna rank/row_number
+}
+
+// PrepareWindow - Resuelve la configuracion de un nodo de ventana
+// Entrada: op - top_n_by_key|window, fn - funcion de ventana, partitionBy,
+//
+//	orderBy - columnas ("amount desc"), columns - esquema, limit - N
+//
+// Salida: WindowSpec o error si la configuracion es invalida
+// Descripcion: top_n_by_key requiere limit > 0 y usa row_number por defecto;
+//
+//	window emite la fila original mas el valor de la funcion.
+func PrepareWindow(op, fn string, partitionBy, orderBy, columns []string, limit int) (WindowSpec, error) {
+	spec := WindowSpec{Op: op, Fn: strings.ToLower(fn), Limit: limit}
+	if spec.Fn == "" {
+		spec.Fn = "row_number"
+	}
+	switch spec.Fn {
+	case "row_number", "rank", "dense_rank":
+	default:
+		return spec, specErrorf("funcion de ventana desconocida: %s", fn)
+	}
+
+	switch op {
+	case "top_n_by_key":
+		if limit <= 0 {
+			return spec, specErrorf("top_n_by_key requiere limit > 0")
+		}
+	case "window":
+		spec.EmitValue = true
+	default:
+		return spec, specErrorf("operador de ventana desconocido: %s", op)
+	}
+
+	if len(partitionBy) == 0 {
+		return spec, specErrorf("%s requiere partition_by", op)
+	}
+	partCols, err := ResolveColumns(partitionBy, columns)
+	if err != nil {
+		return spec, err
+	}
+	spec.PartCols = partCols
+
+	for _, o := range orderBy {
+		parts := strings.Fields(o)
+		if len(parts) == 0 {
+			continue
+		}
+		col, err := ResolveColumn(parts[0], columns)
+		if err != nil {
+			return spec, err
+		}
+		key := OrderKey{Column: col}
+		if len(parts) > 1 {
+			switch strings.ToLower(parts[1]) {
+			case "desc":
+				key.Desc = true
+			case "asc":
+			default:
+				return spec, specErrorf("direccion de orden invalida: %s", o)
+			}
+		}
+		spec.Order = append(spec.Order, key)
+	}
+	return spec, nil
+}
+
+// field - Valor de una columna o "" si la fila es mas corta
+func field(fields []string, col int) string {
+	if col < len(fields) {
+		return fields[col]
+	}
+	return ""
+}
+
+// ComparePartition - Compara las claves de particion de dos filas
+func (s WindowSpec) ComparePartition(a, b []string) int {
+	for _, c := range s.PartCols {
+		if r := strings.Compare(field(a, c), field(b, c)); r != 0 {
+			return r
+		}
+	}
+	return 0
+}
+
+// CompareOrder - Compara dos filas segun las columnas de orden
+func (s WindowSpec) CompareOrder(a, b []string) int {
+	for _, o := range s.Order {
+		r := CompareValues(field(a, o.Column), field(b, o.Column))
+		if o.Desc {
+			r = -r
+		}
+		if r != 0 {
+			return r
+		}
+	}
+	return 0
+}
+
+// Less - Orden total: primero por particion, luego por columnas de orden
+// Descripcion: Es el criterio usado tanto por el sort en memoria como por
+//
+//	el merge de runs del sort externo.
+func (s WindowSpec) Less(a, b []string) bool {
+	if r := s.ComparePartition(a, b); r != 0 {
+		return r < 0
+	}
+	return s.CompareOrder(a, b) < 0
+}
+
+// WindowEmitter recorre filas ya ordenadas y calcula la funcion de ventana
+type WindowEmitter struct {
+	spec      WindowSpec
+	prev      []string // Fila anterior (para detectar cambio de particion/empate)
+	rowNumber int
+	rank      int
+	denseRank int
+}
+
+// NewWindowEmitter - Crea un emisor para filas ordenadas con spec.Less
+func NewWindowEmitter(spec WindowSpec) *WindowEmitter {
+	return &WindowEmitter{spec: spec}
+}
+
+// Next - Procesa la siguiente fila ordenada
+// Entrada: line - fila original, fields - campos ya separados
+// Salida: linea a emitir y true, o false si la fila queda fuera del limite
+func (e *WindowEmitter) Next(line string, fields []string) (string, bool) {
+	if e.prev == nil || e.spec.ComparePartition(e.prev, fields) != 0 {
+		// Nueva particion: reiniciar contadores
+		e.rowNumber, e.rank, e.denseRank = 1, 1, 1
+	} else {
+		e.rowNumber++
+		if e.spec.CompareOrder(e.prev, fields) != 0 {
+			e.rank = e.rowNumber
+			e.denseRank++
+		}
+	}
+	e.prev = fields
+
+	value := e.rowNumber
+	switch e.spec.Fn {
+	case "rank":
+		value = e.rank
+	case "dense_rank":
+		value = e.denseRank
+	}
+	if e.spec.Limit > 0 && value > e.spec.Limit {
+		return "", false
+	}
+	if e.spec.EmitValue {
+		return line + ", " + strconv.Itoa(value), true
+	}
+	return line, true
+}
+
+// WindowByKey - Ejecuta top_n_by_key/window completamente en memoria
+// Entrada: inputs - archivos CSV, output - destino, spec - configuracion resuelta
+// Salida: error si falla I/O
+// Descripcion: Version en memoria usada en pruebas y datasets pequenos.
+//
+//	El Worker usa un sort externo con runs en disco.
+func WindowByKey(ctx context.Context, inputs []string, output string, spec WindowSpec) error {
+	type row struct {
+		line   string
+		fields []string
+	}
+	var rows []row
+	for _, in := range inputs {
+		file, err := os.Open(in)
+		if err != nil {
+			continue
+		}
+		scanner := NewScanner(ctx, file)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			rows = append(rows, row{line: line, fields: SplitRow(line)})
+		}
+		file.Close()
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return spec.Less(rows[i].fields, rows[j].fields) })
+
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := NewWriter(ctx, f)
+	emitter := NewWindowEmitter(spec)
+	for _, r := range rows {
+		if out, ok := emitter.Next(r.line, r.fields); ok {
+			w.WriteString(out + "\n")
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,439 @@
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: compiler.go
+Descripcion: Compilador de consultas SQL a DAGs de Mini-Spark.
+             Traduce el AST a nodos read/filter/map/join/aggregate_by_key
+             sobre tablas registradas (CSV/JSONL), empujando filtros
+             de una sola tabla justo despues de la lectura y moviendo
+             la clave de join a la primera columna antes de cada join.
+*/
+
+package query
+
+import (
+	"fmt"
+	"mini-spark/internal/common"
+	"strings"
+)
+
+// column columna del esquema intermedio; puede tener varios nombres
+// (ej: tras un join, "u.id" y "p.user_id" designan la misma clave)
+type column struct {
+	names []string
+}
+
+// stream salida de un nodo del DAG junto con su esquema
+type stream struct {
+	nodeID string
+	schema []column
+}
+
+// primaryNames - Nombres principales del esquema (para DAGNode.Columns)
+func (s stream) primaryNames() []string {
+	out := make([]string, len(s.schema))
+	for i, c := range s.schema {
+		out[i] = c.names[0]
+	}
+	return out
+}
+
+// resolve - Ubica una columna en el esquema
+// Entrada: ref - referencia calificada o no
+// Salida: indice de columna o error si no existe o es ambigua
+func (s stream) resolve(ref ColumnRef) (int, error) {
+	found := -1
+	for i, c := range s.schema {
+		for _, n := range c.names {
+			match := strings.EqualFold(n, ref.String())
+			if !match && ref.Table == "" {
+				// Referencia sin calificar: comparar con el nombre sin tabla
+				if dot := strings.Index(n, "."); dot >= 0 {
+					match = strings.EqualFold(n[dot+1:], ref.Name)
+				}
+			}
+			if match {
+				if found >= 0 && found != i {
+					return 0, fmt.Errorf("columna ambigua: %s", ref)
+				}
+				found = i
+			}
+		}
+	}
+	if found < 0 {
+		return 0, fmt.Errorf("columna desconocida: %s", ref)
+	}
+	return found, nil
+}
+
+// compiler estado de compilacion (nodos, aristas y contador de IDs)
+type compiler struct {
+	tables map[string]common.TableDef
+	dag    common.DAG
+	ids    map[string]int
+}
+
+// newID - Genera un ID de nodo unico a partir de un prefijo
+func (c *compiler) newID(prefix string) string {
+	c.ids[prefix]++
+	if c.ids[prefix] == 1 {
+		return prefix
+	}
+	return fmt.Sprintf("%s_%d", prefix, c.ids[prefix])
+}
+
+// addNode - Agrega un nodo al DAG con aristas desde sus padres
+func (c *compiler) addNode(node common.DAGNode, parents ...string) {
+	c.dag.Nodes = append(c.dag.Nodes, node)
+	for _, p := range parents {
+		c.dag.Edges = append(c.dag.Edges, []string{p, node.ID})
+	}
+}
+
+// CompileSQL - Parsea y compila una consulta a un JobRequest
+// Entrada: sql - consulta, tables - tablas registradas, name - nombre del job,
+//
+//	parallelism - nivel de paralelismo
+//
+// Salida: JobRequest listo para enviar o error de sintaxis/semantica
+func CompileSQL(sql string, tables map[string]common.TableDef, name string, parallelism int) (common.JobRequest, error) {
+	q, err := Parse(sql)
+	if err != nil {
+		return common.JobRequest{}, err
+	}
+	return Compile(q, tables, name, parallelism)
+}
+
+// Compile - Traduce un AST a un DAG de operadores existentes
+// Entrada: q - AST, tables - tablas registradas, name, parallelism
+// Salida: JobRequest o error si alguna tabla/columna no existe o parallelism > 1
+// Descripcion: Orden de etapas generado:
+//
+//	read_* -> filter (pushdown por tabla) -> map(select clave) -> join
+//	-> filter (condiciones entre tablas) -> aggregate_by_key -> map(select)
+//
+//	No hay shuffle entre particiones: join y aggregate_by_key necesitan
+//	todas las filas en una sola tarea, por lo que el job es de 1 particion.
+func Compile(q *Query, tables map[string]common.TableDef, name string, parallelism int) (common.JobRequest, error) {
+	c := &compiler{tables: tables, ids: make(map[string]int)}
+	if name == "" {
+		name = "sql-query"
+	}
+	if parallelism > 1 {
+		return common.JobRequest{}, fmt.Errorf("parallelism %d no soportado: las consultas SQL se ejecutan con 1 particion", parallelism)
+	}
+	parallelism = 1
+
+	// 1. Lectura de todas las tablas involucradas
+	refs := []TableRef{q.From}
+	for _, j := range q.Joins {
+		refs = append(refs, j.Table)
+	}
+	streams := make(map[string]stream)
+	for _, ref := range refs {
+		if _, dup := streams[ref.Alias]; dup {
+			return common.JobRequest{}, fmt.Errorf("alias duplicado: %s", ref.Alias)
+		}
+		s, err := c.readTable(ref)
+		if err != nil {
+			return common.JobRequest{}, err
+		}
+		streams[ref.Alias] = s
+	}
+
+	// 2. Pushdown de condiciones que involucran una sola tabla
+	var pending []Predicate
+	pushed := make(map[string][]string)
+	for _, pred := range q.Where {
+		alias, ok := c.singleTable(pred, streams)
+		if !ok {
+			pending = append(pending, pred)
+			continue
+		}
+		cond, err := predicateString(pred, streams[alias])
+		if err != nil {
+			return common.JobRequest{}, err
+		}
+		pushed[alias] = append(pushed[alias], cond)
+	}
+	for _, ref := range refs {
+		if conds := pushed[ref.Alias]; len(conds) > 0 {
+			streams[ref.Alias] = c.filter(streams[ref.Alias], c.newID("filter_"+ref.Alias), conds)
+		}
+	}
+
+	// 3. Joins encadenados (izquierda = resultado acumulado)
+	current := streams[q.From.Alias]
+	for _, j := range q.Joins {
+		right := streams[j.Table.Alias]
+		leftKey, rightKey, err := joinKeys(j, current, right)
+		if err != nil {
+			return common.JobRequest{}, err
+		}
+		current, err = c.join(current, right, leftKey, rightKey)
+		if err != nil {
+			return common.JobRequest{}, err
+		}
+	}
+
+	// 4. Condiciones que involucran varias tablas (despues de los joins)
+	if len(pending) > 0 {
+		var conds []string
+		for _, pred := range pending {
+			cond, err := predicateString(pred, current)
+			if err != nil {
+				return common.JobRequest{}, err
+			}
+			conds = append(conds, cond)
+		}
+		current = c.filter(current, c.newID("filter"), conds)
+	}
+
+	// 5. Agregacion y proyeccion final
+	current, aggCols, err := c.aggregate(q, current)
+	if err != nil {
+		return common.JobRequest{}, err
+	}
+	if !q.SelectAll {
+		if _, err := c.project(q, current, aggCols); err != nil {
+			return common.JobRequest{}, err
+		}
+	}
+
+	return common.JobRequest{Name: name, DAG: c.dag, Parallelism: parallelism}, nil
+}
+
+// readTable - Crea el nodo de lectura de una tabla registrada
+func (c *compiler) readTable(ref TableRef) (stream, error) {
+	def, ok := c.tables[ref.Name]
+	if !ok {
+		return stream{}, fmt.Errorf("tabla no registrada: %s", ref.Name)
+	}
+	if len(def.Columns) == 0 {
+		return stream{}, fmt.Errorf("tabla %s no declara columnas", ref.Name)
+	}
+	op := "read_csv"
+	if def.Format == "jsonl" {
+		op = "read_jsonl"
+	}
+	node := common.DAGNode{ID: c.newID("read_" + ref.Alias), Op: op, Path: def.Path}
+	if op == "read_jsonl" {
+		// read_jsonl necesita el esquema para convertir objetos a filas
+		node.Columns = def.Columns
+	}
+	c.addNode(node)
+
+	s := stream{nodeID: node.ID}
+	for _, col := range def.Columns {
+		s.schema = append(s.schema, column{names: []string{ref.Alias + "." + col}})
+	}
+	return s, nil
+}
+
+// singleTable - Determina si un predicado usa columnas de una sola tabla
+func (c *compiler) singleTable(pred Predicate, streams map[string]stream) (string, bool) {
+	cols := []ColumnRef{pred.Left}
+	if pred.Right != nil {
+		cols = append(cols, *pred.Right)
+	}
+	alias := ""
+	for _, col := range cols {
+		owner := ""
+		for a, s := range streams {
+			if _, err := s.resolve(col); err == nil {
+				if owner != "" {
+					return "", false // Columna presente en varias tablas
+				}
+				owner = a
+			}
+		}
+		if owner == "" || (alias != "" && alias != owner) {
+			return "", false
+		}
+		alias = owner
+	}
+	return alias, alias != ""
+}
+
+// predicateString - Traduce un predicado al formato de DAGNode.Where
+func predicateString(pred Predicate, s stream) (string, error) {
+	li, err := s.resolve(pred.Left)
+	if err != nil {
+		return "", err
+	}
+	left := s.schema[li].names[0]
+	switch {
+	case pred.Right != nil:
+		ri, err := s.resolve(*pred.Right)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s %s %s", left, pred.Operator, s.schema[ri].names[0]), nil
+	case pred.Quoted:
+		return fmt.Sprintf("%s %s '%s'", left, pred.Operator, pred.Literal), nil
+	}
+	return fmt.Sprintf("%s %s %s", left, pred.Operator, pred.Literal), nil
+}
+
+// filter - Agrega un nodo filter declarativo
+func (c *compiler) filter(in stream, id string, conds []string) stream {
+	c.addNode(common.DAGNode{ID: id, Op: "filter", Columns: in.primaryNames(), Where: conds}, in.nodeID)
+	return stream{nodeID: id, schema: in.schema}
+}
+
+// joinKeys - Identifica que lado del ON pertenece a cada entrada
+func joinKeys(j JoinClause, left, right stream) (int, int, error) {
+	li, lerr := left.resolve(j.Left)
+	ri, rerr := right.resolve(j.Right)
+	if lerr == nil && rerr == nil {
+		return li, ri, nil
+	}
+	// ON escrito al reves: b.x = a.y
+	li, lerr = left.resolve(j.Right)
+	ri, rerr = right.resolve(j.Left)
+	if lerr == nil && rerr == nil {
+		return li, ri, nil
+	}
+	return 0, 0, fmt.Errorf("condicion de JOIN invalida: %s = %s", j.Left, j.Right)
+}
+
+// keyFirst - Proyecta la columna clave a la primera posicion
+// Descripcion: El operador join usa la primera columna como clave.
+//
+//	Si la clave ya es la primera columna no se agrega nodo.
+func (c *compiler) keyFirst(in stream, key int) stream {
+	if key == 0 {
+		return in
+	}
+	order := []column{in.schema[key]}
+	selectCols := []string{in.schema[key].names[0]}
+	for i, col := range in.schema {
+		if i != key {
+			order = append(order, col)
+			selectCols = append(selectCols, col.names[0])
+		}
+	}
+	id := c.newID("key_" + strings.ReplaceAll(in.schema[key].names[0], ".", "_"))
+	c.addNode(common.DAGNode{ID: id, Op: "map", Columns: in.primaryNames(), Select: selectCols}, in.nodeID)
+	return stream{nodeID: id, schema: order}
+}
+
+// join - Agrega nodos de reordenamiento y el nodo join
+// Descripcion: Salida del join: clave, columnas izquierdas, columnas derechas.
+func (c *compiler) join(left, right stream, leftKey, rightKey int) (stream, error) {
+	left = c.keyFirst(left, leftKey)
+	right = c.keyFirst(right, rightKey)
+
+	id := c.newID("join")
+	key := column{names: append(append([]string{}, left.schema[0].names...), right.schema[0].names...)}
+	out := stream{nodeID: id, schema: []column{key}}
+	out.schema = append(out.schema, left.schema[1:]...)
+	out.schema = append(out.schema, right.schema[1:]...)
+
+	// Orden de aristas = orden de inputs (izquierda primero)
+	c.addNode(common.DAGNode{ID: id, Op: "join"}, left.nodeID, right.nodeID)
+	return out, nil
+}
+
+// aggregate - Agrega el nodo aggregate_by_key si hay GROUP BY/agregados
+// Salida: esquema de salida e indice en ese esquema de cada item
+//
+//	agregado del SELECT (por posicion en q.Select)
+func (c *compiler) aggregate(q *Query, in stream) (stream, map[int]int, error) {
+	hasAgg := false
+	for _, item := range q.Select {
+		if item.Func != "" {
+			hasAgg = true
+		}
+	}
+	if !hasAgg && len(q.GroupBy) == 0 {
+		return in, nil, nil
+	}
+	if len(q.GroupBy) == 0 {
+		return in, nil, fmt.Errorf("agregados sin GROUP BY no soportados")
+	}
+	if q.SelectAll {
+		return in, nil, fmt.Errorf("SELECT * no es valido con GROUP BY")
+	}
+
+	out := stream{nodeID: c.newID("aggregate")}
+	aggCols := make(map[int]int)
+	var groupBy, aggs []string
+	for _, g := range q.GroupBy {
+		i, err := in.resolve(g)
+		if err != nil {
+			return in, nil, err
+		}
+		groupBy = append(groupBy, in.schema[i].names[0])
+		out.schema = append(out.schema, in.schema[i])
+	}
+	for idx, item := range q.Select {
+		if item.Func == "" {
+			// Columnas simples deben estar en GROUP BY
+			if _, err := out.resolve(item.Col); err != nil {
+				return in, nil, fmt.Errorf("columna %s debe aparecer en GROUP BY", item.Col)
+			}
+			continue
+		}
+		arg := "*"
+		if !item.Star {
+			i, err := in.resolve(item.Col)
+			if err != nil {
+				return in, nil, err
+			}
+			arg = in.schema[i].names[0]
+		}
+		expr := fmt.Sprintf("%s(%s)", item.Func, arg)
+		aggs = append(aggs, expr)
+		names := []string{expr}
+		if item.Alias != "" {
+			names = []string{item.Alias, expr}
+		}
+		aggCols[idx] = len(out.schema)
+		out.schema = append(out.schema, column{names: names})
+	}
+
+	c.addNode(common.DAGNode{
+		ID: out.nodeID, Op: "aggregate_by_key", Columns: in.primaryNames(),
+		GroupBy: groupBy, Aggregates: aggs,
+	}, in.nodeID)
+	return out, aggCols, nil
+}
+
+// project - Agrega la proyeccion final si el SELECT no coincide con el esquema
+// Entrada: aggCols - indice de cada agregado del SELECT en el esquema de
+//
+//	aggregate (el argumento del agregado ya no existe en esa salida)
+func (c *compiler) project(q *Query, in stream, aggCols map[int]int) (stream, error) {
+	var selectCols []string
+	var schema []column
+	for idx, item := range q.Select {
+		var i int
+		if item.Func != "" {
+			col, ok := aggCols[idx]
+			if !ok {
+				return in, fmt.Errorf("agregado sin GROUP BY: %s", item.Func)
+			}
+			i = col
+		} else {
+			var err error
+			if i, err = in.resolve(item.Col); err != nil {
+				return in, err
+			}
+		}
+		selectCols = append(selectCols, in.schema[i].names[0])
+		schema = append(schema, in.schema[i])
+	}
+
+	// Evitar un nodo extra si la salida ya tiene exactamente ese orden
+	same := len(selectCols) == len(in.schema)
+	for i := 0; same && i < len(selectCols); i++ {
+		same = selectCols[i] == in.schema[i].names[0]
+	}
+	if same {
+		return in, nil
+	}
+
+	id := c.newID("select")
+	c.addNode(common.DAGNode{ID: id, Op: "map", Columns: in.primaryNames(), Select: selectCols}, in.nodeID)
+	return stream{nodeID: id, schema: schema}, nil
+}
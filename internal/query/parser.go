@@ -0,0 +1,430 @@
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: parser.go
+Descripcion: Analizador lexico y sintactico del front-end SQL.
+             Soporta un subconjunto de SELECT: proyeccion, agregados,
+             FROM con alias, JOIN ... ON igualdad, WHERE con condiciones
+             unidas por AND y GROUP BY. Produce un AST que luego se
+             compila a un DAG de Mini-Spark.
+*/
+
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// --- AST ---
+
+// ColumnRef referencia a columna, opcionalmente calificada (t.col)
+type ColumnRef struct {
+	Table string // Alias o nombre de tabla ("" si no se califico)
+	Name  string // Nombre de columna
+}
+
+// String - Forma textual "t.col" o "col"
+func (c ColumnRef) String() string {
+	if c.Table == "" {
+		return c.Name
+	}
+	return c.Table + "." + c.Name
+}
+
+// SelectItem elemento de la lista SELECT (columna o agregado)
+type SelectItem struct {
+	Func  string    // "" para columna simple; sum|count|min|max|avg para agregados
+	Star  bool      // true para count(*)
+	Col   ColumnRef // Columna referenciada
+	Alias string    // Alias opcional (AS)
+}
+
+// TableRef tabla en FROM/JOIN
+type TableRef struct {
+	Name  string // Nombre registrado
+	Alias string // Alias (igual al nombre si no se declara)
+}
+
+// JoinClause JOIN <tabla> ON <col> = <col>
+type JoinClause struct {
+	Table TableRef
+	Left  ColumnRef
+	Right ColumnRef
+}
+
+// Predicate condicion simple de WHERE
+type Predicate struct {
+	Left     ColumnRef
+	Operator string
+	Right    *ColumnRef // Columna derecha (nil si es literal)
+	Literal  string     // Literal derecho
+	Quoted   bool       // true si el literal era texto entre comillas
+}
+
+// Query AST completo de una consulta SELECT
+type Query struct {
+	SelectAll bool
+	Select    []SelectItem
+	From      TableRef
+	Joins     []JoinClause
+	Where     []Predicate
+	GroupBy   []ColumnRef
+}
+
+// --- LEXER ---
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokSymbol
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// tokenize - Divide la consulta en tokens
+// Entrada: sql - texto de la consulta
+// Salida: slice de tokens o error si hay caracteres invalidos
+func tokenize(sql string) ([]token, error) {
+	var tokens []token
+	runes := []rune(sql)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{tokIdent, string(runes[start:i])})
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{tokNumber, string(runes[start:i])})
+		case r == '\'':
+			start := i + 1
+			i++
+			for i < len(runes) && runes[i] != '\'' {
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("literal de texto sin cerrar")
+			}
+			tokens = append(tokens, token{tokString, string(runes[start:i])})
+			i++
+		default:
+			// Simbolos de dos caracteres primero
+			if i+1 < len(runes) {
+				two := string(runes[i : i+2])
+				if two == "<=" || two == ">=" || two == "!=" || two == "<>" {
+					tokens = append(tokens, token{tokSymbol, two})
+					i += 2
+					continue
+				}
+			}
+			if strings.ContainsRune(",()*=<>;", r) {
+				tokens = append(tokens, token{tokSymbol, string(r)})
+				i++
+				continue
+			}
+			return nil, fmt.Errorf("caracter inesperado: %q", r)
+		}
+	}
+	return append(tokens, token{kind: tokEOF}), nil
+}
+
+// --- PARSER ---
+
+// reservedWords palabras que no pueden usarse como alias implicito
+var reservedWords = map[string]bool{
+	"SELECT": true, "FROM": true, "JOIN": true, "INNER": true, "ON": true,
+	"WHERE": true, "AND": true, "GROUP": true, "BY": true, "AS": true,
+	"ORDER": true, "LIMIT": true, "OR": true,
+}
+
+// aggregateFuncs funciones de agregacion soportadas
+var aggregateFuncs = map[string]bool{"sum": true, "count": true, "min": true, "max": true, "avg": true}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// Parse - Convierte texto SQL en un AST
+// Entrada: sql - consulta SELECT
+// Salida: puntero a Query o error de sintaxis
+func Parse(sql string) (*Query, error) {
+	tokens, err := tokenize(sql)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	q, err := p.parseQuery()
+	if err != nil {
+		return nil, err
+	}
+	p.acceptSymbol(";")
+	if p.peek().kind != tokEOF {
+		return nil, fmt.Errorf("token inesperado: %s", p.peek().text)
+	}
+	return q, nil
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// isKeyword - true si el token actual es la palabra clave kw
+func (p *parser) isKeyword(kw string) bool {
+	t := p.peek()
+	return t.kind == tokIdent && strings.EqualFold(t.text, kw)
+}
+
+func (p *parser) acceptKeyword(kw string) bool {
+	if p.isKeyword(kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectKeyword(kw string) error {
+	if !p.acceptKeyword(kw) {
+		return fmt.Errorf("se esperaba %s cerca de '%s'", kw, p.peek().text)
+	}
+	return nil
+}
+
+func (p *parser) acceptSymbol(sym string) bool {
+	t := p.peek()
+	if t.kind == tokSymbol && t.text == sym {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectSymbol(sym string) error {
+	if !p.acceptSymbol(sym) {
+		return fmt.Errorf("se esperaba '%s' cerca de '%s'", sym, p.peek().text)
+	}
+	return nil
+}
+
+// parseQuery - SELECT ... FROM ... [JOIN ...] [WHERE ...] [GROUP BY ...]
+func (p *parser) parseQuery() (*Query, error) {
+	q := &Query{}
+	if err := p.expectKeyword("SELECT"); err != nil {
+		return nil, err
+	}
+	if p.acceptSymbol("*") {
+		q.SelectAll = true
+	} else {
+		for {
+			item, err := p.parseSelectItem()
+			if err != nil {
+				return nil, err
+			}
+			q.Select = append(q.Select, item)
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+	}
+
+	if err := p.expectKeyword("FROM"); err != nil {
+		return nil, err
+	}
+	from, err := p.parseTableRef()
+	if err != nil {
+		return nil, err
+	}
+	q.From = from
+
+	for p.isKeyword("JOIN") || p.isKeyword("INNER") {
+		p.acceptKeyword("INNER")
+		if err := p.expectKeyword("JOIN"); err != nil {
+			return nil, err
+		}
+		tbl, err := p.parseTableRef()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectKeyword("ON"); err != nil {
+			return nil, err
+		}
+		left, err := p.parseColumnRef()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectSymbol("="); err != nil {
+			return nil, fmt.Errorf("JOIN solo soporta igualdad: %v", err)
+		}
+		right, err := p.parseColumnRef()
+		if err != nil {
+			return nil, err
+		}
+		q.Joins = append(q.Joins, JoinClause{Table: tbl, Left: left, Right: right})
+	}
+
+	if p.acceptKeyword("WHERE") {
+		for {
+			pred, err := p.parsePredicate()
+			if err != nil {
+				return nil, err
+			}
+			q.Where = append(q.Where, pred)
+			if p.isKeyword("OR") {
+				return nil, fmt.Errorf("OR no soportado en WHERE")
+			}
+			if !p.acceptKeyword("AND") {
+				break
+			}
+		}
+	}
+
+	if p.acceptKeyword("GROUP") {
+		if err := p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		for {
+			col, err := p.parseColumnRef()
+			if err != nil {
+				return nil, err
+			}
+			q.GroupBy = append(q.GroupBy, col)
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+	}
+
+	if p.isKeyword("ORDER") || p.isKeyword("LIMIT") {
+		return nil, fmt.Errorf("%s no soportado (use top_n_by_key en el DAG)", strings.ToUpper(p.peek().text))
+	}
+	return q, nil
+}
+
+// parseSelectItem - columna o agregado con alias opcional
+func (p *parser) parseSelectItem() (SelectItem, error) {
+	var item SelectItem
+	t := p.peek()
+	if t.kind == tokIdent && aggregateFuncs[strings.ToLower(t.text)] && p.tokens[p.pos+1].text == "(" {
+		p.pos += 2
+		item.Func = strings.ToLower(t.text)
+		if p.acceptSymbol("*") {
+			if item.Func != "count" {
+				return item, fmt.Errorf("%s(*) no soportado", item.Func)
+			}
+			item.Star = true
+		} else {
+			col, err := p.parseColumnRef()
+			if err != nil {
+				return item, err
+			}
+			item.Col = col
+		}
+		if err := p.expectSymbol(")"); err != nil {
+			return item, err
+		}
+	} else {
+		col, err := p.parseColumnRef()
+		if err != nil {
+			return item, err
+		}
+		item.Col = col
+	}
+	if p.acceptKeyword("AS") {
+		alias := p.next()
+		if alias.kind != tokIdent {
+			return item, fmt.Errorf("alias invalido: %s", alias.text)
+		}
+		item.Alias = alias.text
+	}
+	return item, nil
+}
+
+// parseTableRef - nombre de tabla con alias opcional
+func (p *parser) parseTableRef() (TableRef, error) {
+	t := p.next()
+	if t.kind != tokIdent || reservedWords[strings.ToUpper(t.text)] {
+		return TableRef{}, fmt.Errorf("se esperaba nombre de tabla, se obtuvo '%s'", t.text)
+	}
+	ref := TableRef{Name: t.text, Alias: t.text}
+	p.acceptKeyword("AS")
+	if a := p.peek(); a.kind == tokIdent && !reservedWords[strings.ToUpper(a.text)] {
+		ref.Alias = a.text
+		p.pos++
+	}
+	return ref, nil
+}
+
+// parseColumnRef - identificador "col" o "tabla.col"
+func (p *parser) parseColumnRef() (ColumnRef, error) {
+	t := p.next()
+	if t.kind != tokIdent || reservedWords[strings.ToUpper(t.text)] {
+		return ColumnRef{}, fmt.Errorf("se esperaba columna, se obtuvo '%s'", t.text)
+	}
+	if dot := strings.Index(t.text, "."); dot >= 0 {
+		return ColumnRef{Table: t.text[:dot], Name: t.text[dot+1:]}, nil
+	}
+	return ColumnRef{Name: t.text}, nil
+}
+
+// parsePredicate - <columna> <op> <columna|numero|'texto'>
+func (p *parser) parsePredicate() (Predicate, error) {
+	left, err := p.parseColumnRef()
+	if err != nil {
+		return Predicate{}, err
+	}
+	op := p.next()
+	switch op.text {
+	case "=", "!=", "<>", "<", "<=", ">", ">=":
+	default:
+		return Predicate{}, fmt.Errorf("operador invalido: %s", op.text)
+	}
+	if op.kind != tokSymbol {
+		return Predicate{}, fmt.Errorf("operador invalido: %s", op.text)
+	}
+	pred := Predicate{Left: left, Operator: op.text}
+	if pred.Operator == "<>" {
+		pred.Operator = "!="
+	}
+
+	right := p.peek()
+	switch right.kind {
+	case tokNumber:
+		pred.Literal = right.text
+		p.pos++
+	case tokString:
+		pred.Literal, pred.Quoted = right.text, true
+		p.pos++
+	case tokIdent:
+		col, err := p.parseColumnRef()
+		if err != nil {
+			return Predicate{}, err
+		}
+		pred.Right = &col
+	default:
+		return Predicate{}, fmt.Errorf("valor invalido en WHERE: %s", right.text)
+	}
+	return pred, nil
+}
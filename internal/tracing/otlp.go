@@ -0,0 +1,222 @@
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: otlp.go
+Descripcion: Exportacion de spans en OTLP/JSON.
+             Cada lote es un ExportTraceServiceRequest: se agrega como una
+             linea a un archivo (formato del file exporter de OpenTelemetry)
+             o se envia por HTTP a un collector (POST /v1/traces).
+*/
+
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- Formato OTLP/JSON ---
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"` // 0 = UNSET, 1 = OK, 2 = ERROR
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"` // int64 como texto (OTLP/JSON)
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+}
+
+// spanKindInternal SPAN_KIND_INTERNAL: todos los spans son operaciones internas
+const spanKindInternal = 1
+
+// attributeValue - Convierte un atributo al tipo OTLP correspondiente
+func attributeValue(v interface{}) otlpValue {
+	switch x := v.(type) {
+	case string:
+		return otlpValue{StringValue: &x}
+	case int:
+		s := strconv.Itoa(x)
+		return otlpValue{IntValue: &s}
+	case int64:
+		s := strconv.FormatInt(x, 10)
+		return otlpValue{IntValue: &s}
+	case float64:
+		return otlpValue{DoubleValue: &x}
+	case bool:
+		return otlpValue{BoolValue: &x}
+	}
+	s := fmt.Sprint(v)
+	return otlpValue{StringValue: &s}
+}
+
+// attributes - Atributos ordenados por clave
+func attributes(attrs map[string]interface{}) []otlpKeyValue {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	kvs := make([]otlpKeyValue, 0, len(keys))
+	for _, k := range keys {
+		kvs = append(kvs, otlpKeyValue{Key: k, Value: attributeValue(attrs[k])})
+	}
+	return kvs
+}
+
+// unixNano - Timestamp en nanosegundos como texto (OTLP/JSON)
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// MarshalOTLP - Serializa un lote de spans como ExportTraceServiceRequest
+// Entrada: service - service.name del recurso, spans - spans terminados
+func MarshalOTLP(service string, spans []Span) ([]byte, error) {
+	out := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		span := otlpSpan{
+			TraceID:           s.Context.TraceID,
+			SpanID:            s.Context.SpanID,
+			ParentSpanID:      s.ParentID,
+			Name:              s.Name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: unixNano(s.Start),
+			EndTimeUnixNano:   unixNano(s.End),
+			Attributes:        attributes(s.Attributes),
+			Status:            otlpStatus{Code: 1},
+		}
+		if s.Error != "" {
+			span.Status = otlpStatus{Code: 2, Message: s.Error}
+		}
+		out = append(out, span)
+	}
+	return json.Marshal(otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: attributes(map[string]interface{}{"service.name": service})},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: "mini-spark"}, Spans: out}},
+	}}})
+}
+
+// --- Exporters ---
+
+// FileExporter agrega cada lote como una linea JSON a un archivo
+type FileExporter struct {
+	Path string
+	mu   sync.Mutex
+}
+
+// Export - Agrega el lote al archivo (lo crea si no existe)
+func (e *FileExporter) Export(service string, spans []Span) error {
+	data, err := MarshalOTLP(service, spans)
+	if err != nil {
+		return err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	file, err := os.OpenFile(e.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
+
+// CollectorExporter envia cada lote a un collector OTLP/HTTP
+type CollectorExporter struct {
+	URL    string       // Endpoint completo, ej: http://localhost:4318/v1/traces
+	Client *http.Client // nil = cliente con timeout de 5s
+}
+
+// Export - POST del lote en JSON al collector
+func (e *CollectorExporter) Export(service string, spans []Span) error {
+	data, err := MarshalOTLP(service, spans)
+	if err != nil {
+		return err
+	}
+	client := e.Client
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	resp, err := client.Post(e.URL, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("collector respondio %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// NewExporter - Crea el exporter indicado por spec
+// Entrada: spec - "" (sin exportar), http(s)://host[:port][/ruta] (collector;
+//
+//	sin ruta usa /v1/traces) o file:<ruta> / <ruta> (archivo JSON lines)
+//
+// Salida: exporter (nil si spec esta vacio) o error si la URL es invalida
+func NewExporter(spec string) (Exporter, error) {
+	switch {
+	case spec == "":
+		return nil, nil
+	case strings.HasPrefix(spec, "http://"), strings.HasPrefix(spec, "https://"):
+		u, err := url.Parse(spec)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("URL de collector invalida: %s", spec)
+		}
+		if u.Path == "" || u.Path == "/" {
+			u.Path = "/v1/traces"
+		}
+		return &CollectorExporter{URL: u.String()}, nil
+	default:
+		return &FileExporter{Path: strings.TrimPrefix(spec, "file:")}, nil
+	}
+}
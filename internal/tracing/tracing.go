@@ -0,0 +1,167 @@
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: tracing.go
+Descripcion: Trazas distribuidas entre Master y Workers.
+             Un job es una traza: el Master abre el span raiz al recibirlo
+             y un span por tarea; el contexto (traceparent W3C) viaja en
+             la tarea hasta el Worker, que agrega sus spans de ejecucion y
+             reporte. Los spans se exportan en OTLP/JSON (otlp.go) a un
+             archivo o a un collector local.
+*/
+
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"mini-spark/internal/utils"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SpanContext identificadores que se propagan entre procesos
+type SpanContext struct {
+	TraceID string // 16 bytes en hex (32 caracteres)
+	SpanID  string // 8 bytes en hex (16 caracteres)
+}
+
+// randomHex - n bytes aleatorios en hexadecimal
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// NewTrace - Contexto del span raiz de una traza nueva
+func NewTrace() SpanContext {
+	return SpanContext{TraceID: randomHex(16), SpanID: randomHex(8)}
+}
+
+// Child - Contexto de un span hijo (misma traza, nuevo SpanID)
+func (c SpanContext) Child() SpanContext {
+	return SpanContext{TraceID: c.TraceID, SpanID: randomHex(8)}
+}
+
+// IsValid - true si el contexto tiene ambos identificadores
+func (c SpanContext) IsValid() bool {
+	return len(c.TraceID) == 32 && len(c.SpanID) == 16
+}
+
+// Traceparent - Contexto en formato W3C: 00-<trace-id>-<span-id>-01
+func (c SpanContext) Traceparent() string {
+	if !c.IsValid() {
+		return ""
+	}
+	return "00-" + c.TraceID + "-" + c.SpanID + "-01"
+}
+
+// ParseTraceparent - Lee un contexto en formato W3C
+// Salida: contexto y false si el texto no es un traceparent valido
+func ParseTraceparent(s string) (SpanContext, bool) {
+	parts := strings.Split(s, "-")
+	if len(parts) != 4 || len(parts[0]) != 2 || len(parts[3]) != 2 {
+		return SpanContext{}, false
+	}
+	ctx := SpanContext{TraceID: parts[1], SpanID: parts[2]}
+	if !ctx.IsValid() || !isHex(ctx.TraceID) || !isHex(ctx.SpanID) {
+		return SpanContext{}, false
+	}
+	return ctx, true
+}
+
+func isHex(s string) bool {
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// Span operacion con inicio y fin dentro de una traza
+type Span struct {
+	Context    SpanContext            // Identificadores del span
+	ParentID   string                 // SpanID del padre ("" = raiz)
+	Name       string                 // Nombre de la operacion
+	Start      time.Time              // Inicio
+	End        time.Time              // Fin
+	Attributes map[string]interface{} // Atributos (string, int, int64, float64, bool)
+	Error      string                 // Mensaje si la operacion fallo
+}
+
+// NewSpan - Span hijo de parent que empieza en start
+func NewSpan(name string, parent SpanContext, start time.Time) Span {
+	return Span{Context: parent.Child(), ParentID: parent.SpanID, Name: name, Start: start, Attributes: map[string]interface{}{}}
+}
+
+// Exporter destino de los spans terminados
+type Exporter interface {
+	Export(service string, spans []Span) error
+}
+
+// batchSize spans acumulados que disparan una exportacion
+const batchSize = 64
+
+// Tracer acumula los spans de un proceso y los exporta por lotes
+// Sin exporter los spans se descartan (la propagacion de contexto sigue activa).
+type Tracer struct {
+	Service  string   // Nombre del servicio (resource service.name)
+	exporter Exporter // nil = trazas deshabilitadas
+
+	mu      sync.Mutex
+	pending []Span
+}
+
+// NewTracer - Constructor del tracer de un proceso
+// Entrada: service - nombre del servicio, exporter - destino (nil = descartar)
+func NewTracer(service string, exporter Exporter) *Tracer {
+	return &Tracer{Service: service, exporter: exporter}
+}
+
+// Enabled - true si los spans se exportan
+func (t *Tracer) Enabled() bool {
+	return t != nil && t.exporter != nil
+}
+
+// Record - Registra un span terminado (End vacio = ahora)
+// Nota: No bloquea; un lote lleno se exporta en otra goroutine
+func (t *Tracer) Record(span Span) {
+	if !t.Enabled() || !span.Context.IsValid() {
+		return
+	}
+	if span.End.IsZero() {
+		span.End = time.Now()
+	}
+	t.mu.Lock()
+	t.pending = append(t.pending, span)
+	full := len(t.pending) >= batchSize
+	t.mu.Unlock()
+	if full {
+		go t.Flush()
+	}
+}
+
+// Flush - Exporta los spans acumulados
+func (t *Tracer) Flush() error {
+	if !t.Enabled() {
+		return nil
+	}
+	t.mu.Lock()
+	spans := t.pending
+	t.pending = nil
+	t.mu.Unlock()
+	if len(spans) == 0 {
+		return nil
+	}
+	err := t.exporter.Export(t.Service, spans)
+	if err != nil {
+		utils.LogJSON("WARN", "No se pudieron exportar spans", map[string]interface{}{"spans": len(spans), "error": err.Error()})
+	}
+	return err
+}
+
+// FlushLoop - Exporta los spans acumulados cada interval
+// Salida: ninguna (void), loop infinito
+func (t *Tracer) FlushLoop(interval time.Duration) {
+	for {
+		time.Sleep(interval)
+		t.Flush()
+	}
+}
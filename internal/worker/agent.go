@@ -11,28 +11,53 @@ package worker
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"mini-spark/internal/common"
+	"mini-spark/internal/metrics"
+	"mini-spark/internal/operators"
+	"mini-spark/internal/tracing"
 	"net/http"
-	"runtime"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 )
 
-const maxConcurrentTasks = 10 // Maximo de tareas concurrentes permitidas
+const maxConcurrentTasks = common.DefaultWorkerSlots // Maximo de tareas concurrentes por defecto (ver SetSlots)
+
+// busyRetryAfter espera sugerida al Master cuando el worker rechaza una tarea
+const busyRetryAfter = 500 * time.Millisecond
 
 // Worker representa un nodo trabajador del cluster
 type Worker struct {
-	ID          string // UUID unico del worker
-	Port        int    // Puerto HTTP donde escucha el worker
-	MasterURL   string // URL del nodo Master (http://host:port)
-	OutputDir   string // Directorio para archivos temporales de salida
-	ActiveTasks int32  // Contador atomico de tareas en ejecucion
+	ID          string        // UUID unico del worker
+	Port        int           // Puerto HTTP donde escucha el worker
+	MasterURL   string        // URL del nodo Master (http://host:port)
+	OutputDir   string        // Directorio para archivos temporales de salida
+	ActiveTasks int32         // Contador atomico de tareas en ejecucion
 	sem         chan struct{} // Semaforo para limitar concurrencia
+	cpu         cpuSampler    // Uso de CPU entre heartbeats
+
+	Metrics *metrics.Registry // Metricas Prometheus expuestas en GET /metrics
+	stats   *workerMetrics    // Series que se actualizan al ejecutar tareas
+	Tracer  *tracing.Tracer   // Spans de ejecucion y reporte (sin exporter se descartan)
+
+	Address string            // Direccion anunciada al Master (host:port o URL); "" = IP de origen
+	Labels  map[string]string // Etiquetas anunciadas al Master (zona, disco, ...)
+
+	cancelMu sync.Mutex                    // Protege cancels
+	cancels  map[string]context.CancelFunc // Tareas en ejecucion cancelables: TaskID -> cancel
+
+	draining  int32         // 1 si el worker esta en decommission (no acepta tareas)
+	drainOnce sync.Once     // El drenado se inicia una sola vez
+	drained   chan struct{} // Se cierra al terminar de drenar y desregistrarse
 }
 
 // NewWorker - Constructor del nodo Worker
@@ -40,13 +65,30 @@ type Worker struct {
 // Salida: puntero a instancia Worker inicializada
 // Descripcion: Crea worker con UUID autogenerado, inicializa contadores a 0.
 func NewWorker(port int, masterURL, outputDir string) *Worker {
-	return &Worker{
+	w := &Worker{
 		ID:        uuid.New().String(),
 		Port:      port,
 		MasterURL: masterURL,
 		OutputDir: outputDir,
 		sem:       make(chan struct{}, maxConcurrentTasks),
+		cancels:   make(map[string]context.CancelFunc),
+		drained:   make(chan struct{}),
 	}
+	w.Metrics, w.stats = newWorkerMetrics(w)
+	w.Tracer = tracing.NewTracer("mini-spark-worker", nil)
+	return w
+}
+
+// SetSlots - Configura el maximo de tareas concurrentes
+// Entrada: slots - tareas concurrentes (<= 0 usa el valor por defecto)
+// Descripcion: Debe llamarse antes de Start; el valor se anuncia al
+//
+//	Master en el registro.
+func (w *Worker) SetSlots(slots int) {
+	if slots <= 0 {
+		slots = maxConcurrentTasks
+	}
+	w.sem = make(chan struct{}, slots)
 }
 
 // Start - Inicia el worker y lo conecta al cluster
@@ -59,18 +101,25 @@ func (w *Worker) Start() {
 	// 1. Iniciar Servidor HTTP en goroutine separada
 	go func() {
 		http.HandleFunc("/task", w.TaskHandler)
+		http.HandleFunc("/task/cancel", w.CancelHandler)
+		http.HandleFunc("/decommission", w.DecommissionHandler)
+		http.Handle("/metrics", w.Metrics)
 		addr := fmt.Sprintf(":%d", w.Port)
 		if err := http.ListenAndServe(addr, nil); err != nil {
 			log.Fatalf("Fallo al iniciar worker: %v", err)
 		}
 	}()
 
-	// 2. Registro en Master con retry
+	// 2. Registro en Master con retry (un rechazo por incompatibilidad es definitivo)
 	for {
-		if w.register() == nil {
+		err := w.register()
+		if err == nil {
 			fmt.Println("[WORKER] Registrado en Master")
 			break
 		}
+		if errors.Is(err, ErrIncompatible) {
+			log.Fatalf("[WORKER] %v", err)
+		}
 		time.Sleep(2 * time.Second) // Retry cada 2s si falla
 	}
 
@@ -78,42 +127,65 @@ func (w *Worker) Start() {
 	w.sendHeartbeat()
 }
 
+// ErrIncompatible el Master rechazo el registro (version u operadores)
+var ErrIncompatible = errors.New("worker incompatible con el Master")
+
+// registration - Datos de registro (handshake) del worker
+// Descripcion: Anuncia direccion, version del build y del protocolo,
+//
+//	operadores y UDFs que sabe ejecutar, slots y etiquetas.
+func (w *Worker) registration() common.RegisterRequest {
+	return common.RegisterRequest{
+		ID:              w.ID,
+		Port:            w.Port,
+		Slots:           cap(w.sem),
+		Address:         w.Address,
+		Version:         common.Version,
+		ProtocolVersion: common.ProtocolVersion,
+		Operators:       common.SupportedOpNames(),
+		UDFs:            operators.UDFNames(),
+		Labels:          w.Labels,
+	}
+}
+
 // register - Envia peticion de registro al Master
 // Entrada: ninguna
-// Salida: error si falla conexion o HTTP
-// Descripcion: Serializa RegisterRequest con ID y puerto, lo envia
+// Salida: error si falla conexion o HTTP; ErrIncompatible (envuelto con el
 //
-//	via POST a /register del Master.
+//	motivo) si el Master responde 409
+//
+// Descripcion: Serializa el handshake (registration) y lo envia via POST
+//
+//	a /register del Master.
 func (w *Worker) register() error {
-	req := common.RegisterRequest{ID: w.ID, Port: w.Port}
-	data, _ := json.Marshal(req)
+	data, _ := json.Marshal(w.registration())
 	resp, err := http.Post(w.MasterURL+"/register", "application/json", bytes.NewBuffer(data))
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
-	return nil
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	msg, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode == http.StatusConflict {
+		return fmt.Errorf("%w: %s", ErrIncompatible, strings.TrimSpace(string(msg)))
+	}
+	return fmt.Errorf("registro rechazado (%d): %s", resp.StatusCode, strings.TrimSpace(string(msg)))
 }
 
 // sendHeartbeat - Loop infinito de envio de heartbeats al Master
 // Entrada: ninguna
 // Salida: ninguna (void), loop infinito
-// Descripcion: Cada 3 segundos captura metricas del runtime Go (goroutines,
+// Descripcion: Cada 3 segundos captura metricas (CPU del proceso y de la
 //
-//	memoria, tareas activas) y las envia al Master via POST /heartbeat.
+//	maquina, RSS, carga, disco libre, tareas activas; ver SystemMetrics)
+//	y las envia al Master via POST /heartbeat.
 //	Permite al Master detectar workers caidos.
 func (w *Worker) sendHeartbeat() {
 	for {
-		// Capturar metricas del runtime
-		var m runtime.MemStats
-		runtime.ReadMemStats(&m)
-		metrics := common.SystemMetrics{
-			CPUPercent:  float64(runtime.NumGoroutine()),       // Proxy: num de goroutines
-			MemoryUsage: m.Alloc,                               // Bytes alocados en heap
-			ActiveTasks: int(atomic.LoadInt32(&w.ActiveTasks)), // Tareas concurrentes
-		}
-		// Construir request de heartbeat
-		req := common.HeartbeatRequest{ID: w.ID, Metrics: metrics}
+		// Capturar metricas del proceso y de la maquina
+		req := common.HeartbeatRequest{ID: w.ID, Metrics: w.SystemMetrics()}
 		data, _ := json.Marshal(req)
 
 		// Enviar POST al Master
@@ -129,35 +201,95 @@ func (w *Worker) sendHeartbeat() {
 
 // TaskHandler - Handler HTTP para recibir tareas del Master
 // Entrada: rw - response writer, r - request con Task JSON
-// Salida: HTTP 200 OK o 400 Bad Request
+// Salida: HTTP 200 OK, 429 Too Many Requests (BusyResponse), 503 si esta
+//
+//	en decommission o 400 Bad Request
+//
 // Descripcion: Decodifica tarea del request. Si hay slots disponibles en el pool
-//  de concurrencia, acepta la tarea y la ejecuta en goroutine separada.
-//  Si no hay slots, rechaza la tarea para que el Master reintente o asigne
-//  a otro worker.
+//
+//	de concurrencia, acepta la tarea y la ejecuta en goroutine separada.
+//	Si no hay slots, rechaza la tarea con su capacidad actual para que el
+//	Master la reencole en otro worker.
 func (w *Worker) TaskHandler(rw http.ResponseWriter, r *http.Request) {
 	var task common.Task
 	if err := json.NewDecoder(r.Body).Decode(&task); err != nil {
 		http.Error(rw, "Bad Request", http.StatusBadRequest)
 		return
 	}
+	// En decommission no se aceptan tareas nuevas
+	if atomic.LoadInt32(&w.draining) == 1 {
+		http.Error(rw, "Worker en decommission", http.StatusServiceUnavailable)
+		return
+	}
 	
 	// Intentar adquirir un slot en el pool
 	select {
 	case w.sem <- struct{}{}: // Adquirir token
 		// Slot disponible, aceptamos la tarea
+		// Registrar como cancelable antes de aceptar: un cancel que llegue
+		// justo despues del 200 encuentra la tarea
+		ctx, untrack := w.trackTask(task.ID)
 		rw.WriteHeader(http.StatusOK)
-		
+
 		go func() {
 			defer func() { <-w.sem }() // Liberar token al terminar
-			w.ExecuteTask(task)
+			defer untrack()
+			w.runTask(ctx, task)
 		}()
 	default:
 		// Pool lleno, rechazamos la tarea para que el Master reintente o asigne a otro
-		w.sem <- struct{}{} 
-		rw.WriteHeader(http.StatusOK)
-		go func() {
-			defer func() { <-w.sem }()
-			w.ExecuteTask(task)
-		}()
+		fmt.Printf("[WORKER %d] Sin slots libres, rechazando tarea %s\n", w.Port, task.ID)
+		w.stats.rejected.Inc()
+		busy := common.BusyResponse{
+			WorkerID:     w.ID,
+			Slots:        cap(w.sem),
+			ActiveTasks:  len(w.sem),
+			RetryAfterMs: int(busyRetryAfter.Milliseconds()),
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.Header().Set("Retry-After", "1")
+		rw.WriteHeader(http.StatusTooManyRequests)
+		json.NewEncoder(rw).Encode(busy)
+	}
+}
+
+// CancelHandler - Handler HTTP para cancelar una tarea en ejecucion
+// Entrada: rw - response writer, r - request con CancelRequest JSON
+// Salida: HTTP 200 OK si la tarea estaba en ejecucion, 404 si no, 400 si JSON invalido
+// Descripcion: El Master la usa al vencer el timeout de una tarea o cuando
+//
+//	otro intento de la particion gano. El operador deja de leer filas,
+//	la tarea no reporta resultado y su salida se descarta; el slot se
+//	libera cuando el operador retorna.
+func (w *Worker) CancelHandler(rw http.ResponseWriter, r *http.Request) {
+	var req common.CancelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(rw, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	w.cancelMu.Lock()
+	cancel, ok := w.cancels[req.TaskID]
+	w.cancelMu.Unlock()
+	if !ok {
+		http.Error(rw, "Tarea no encontrada", http.StatusNotFound)
+		return
+	}
+	fmt.Printf("[WORKER %d] Cancelando tarea %s (%s)\n", w.Port, req.TaskID, req.Reason)
+	cancel()
+	rw.WriteHeader(http.StatusOK)
+}
+
+// trackTask - Registra una tarea como cancelable
+// Salida: contexto cancelado por CancelHandler y funcion para desregistrarla
+func (w *Worker) trackTask(taskID string) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancelMu.Lock()
+	w.cancels[taskID] = cancel
+	w.cancelMu.Unlock()
+	return ctx, func() {
+		w.cancelMu.Lock()
+		delete(w.cancels, taskID)
+		w.cancelMu.Unlock()
+		cancel()
 	}
 }
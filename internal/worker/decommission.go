@@ -0,0 +1,100 @@
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: decommission.go
+Descripcion: Retiro ordenado (decommission) del Worker.
+             Al recibir la orden (del Master o por SIGTERM) deja de
+             aceptar tareas, espera a que terminen las que tiene en
+             ejecucion (sus resultados se reportan normalmente) y se
+             desregistra del Master, que traspasa sus salidas.
+*/
+
+package worker
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mini-spark/internal/common"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// drainPollInterval periodo de revision de tareas en ejecucion al drenar
+const drainPollInterval = 200 * time.Millisecond
+
+// DecommissionHandler - Handler HTTP para iniciar el retiro ordenado
+// Entrada: rw - response writer, r - request POST (enviado por el Master)
+// Salida: HTTP 202 Accepted con las tareas aun en ejecucion
+// Descripcion: Idempotente; el drenado continua en segundo plano.
+func (w *Worker) DecommissionHandler(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(rw, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Decommission()
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(rw).Encode(map[string]int{"active_tasks": len(w.sem)})
+}
+
+// Decommission - Inicia el drenado del worker (una sola vez)
+// Descripcion: Rechaza tareas nuevas (503) y lanza drain en una goroutine.
+func (w *Worker) Decommission() {
+	w.drainOnce.Do(func() {
+		atomic.StoreInt32(&w.draining, 1)
+		fmt.Printf("[WORKER %d] Decommission: drenando %d tareas\n", w.Port, len(w.sem))
+		go w.drain()
+	})
+}
+
+// RequestDecommission - Pide al Master el retiro ordenado de este worker
+// Entrada: ninguna
+// Salida: ninguna (void)
+// Descripcion: Usado al recibir SIGTERM: avisa al Master (que deja de
+//
+//	asignarle tareas) y drena aunque el Master no responda.
+func (w *Worker) RequestDecommission() {
+	url := fmt.Sprintf("%s/api/v1/workers/%s/decommission", w.MasterURL, w.ID)
+	if resp, err := http.Post(url, "application/json", bytes.NewBuffer(nil)); err == nil {
+		resp.Body.Close()
+	} else {
+		fmt.Printf("[WORKER %d] No se pudo avisar decommission al Master: %v\n", w.Port, err)
+	}
+	w.Decommission()
+}
+
+// Drained - Canal que se cierra cuando el worker termino de drenar
+func (w *Worker) Drained() <-chan struct{} {
+	return w.drained
+}
+
+// drain - Espera las tareas en ejecucion y se desregistra
+// Salida: ninguna (void), cierra w.drained al terminar
+func (w *Worker) drain() {
+	for len(w.sem) > 0 {
+		time.Sleep(drainPollInterval)
+	}
+	if err := w.deregister(); err != nil {
+		fmt.Printf("[WORKER %d] Error desregistrando: %v\n", w.Port, err)
+	}
+	fmt.Printf("[WORKER %d] Decommission completado\n", w.Port)
+	close(w.drained)
+}
+
+// deregister - Envia la baja al Master (hasta 3 intentos)
+// Salida: error si el Master no pudo ser contactado
+func (w *Worker) deregister() error {
+	data, _ := json.Marshal(common.DeregisterRequest{ID: w.ID})
+	var err error
+	for i := 0; i < 3; i++ {
+		var resp *http.Response
+		resp, err = http.Post(w.MasterURL+"/deregister", "application/json", bytes.NewBuffer(data))
+		if err == nil {
+			resp.Body.Close()
+			return nil
+		}
+		time.Sleep(time.Second)
+	}
+	return err
+}
@@ -12,12 +12,18 @@ package worker
 import (
 	"bufio"
 	"bytes"
+	"container/heap"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"mini-spark/internal/common"
 	"mini-spark/internal/operators"
 	"net/http"
 	"os"
+	"runtime"
+	"sort"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -27,98 +33,249 @@ import (
 // Usado por reduce_by_key para evitar OOM en datasets grandes
 var SpillThreshold = 1000
 
+// MergeFanIn - Maximo de runs abiertos a la vez en el merge del sort externo
+// Con mas runs se mezclan por tandas en runs mas grandes (merge multi-pasada)
+var MergeFanIn = 64
+
 // ExecuteTask - Ejecuta una tarea asignada por el Master
 // Entrada: task - objeto Task con operacion, inputs y parametros
 // Salida: ninguna (void), reporta resultado al Master
+// Descripcion: Registra la tarea como cancelable y la ejecuta (ver runTask).
+func (w *Worker) ExecuteTask(task common.Task) {
+	ctx, untrack := w.trackTask(task.ID)
+	defer untrack()
+	w.runTask(ctx, task)
+}
+
+// runTask - Ejecuta una tarea ya registrada en trackTask
+// Entrada: ctx - cancelacion de la tarea, task - tarea a ejecutar
+// Salida: ninguna (void), reporta resultado al Master
 // Descripcion: Incrementa contador de tareas, ejecuta operador correspondiente,
 //
-//	captura errores, y reporta completado/fallido al Master.
-//	Soporta: read_csv, map, flat_map, reduce_by_key, join.
-func (w *Worker) ExecuteTask(task common.Task) {
+//	captura errores, y reporta completado/fallido al Master. Cada intento
+//	escribe en su propio archivo (incluye task.ID): un intento perdedor o
+//	vencido nunca pisa ni borra la salida de otro. Si el Master cancela la
+//	tarea (/task/cancel) el operador deja de leer filas y la tarea retorna
+//	sin reportar; el slot se libera recien cuando el operador termina.
+//	Soporta: read_csv, read_jsonl, map, flat_map, filter, reduce_by_key, aggregate_by_key,
+//	group_by_key, top_n_by_key, window, join.
+func (w *Worker) runTask(ctx context.Context, task common.Task) {
 	// Incrementar contador atomico de tareas activas
 	atomic.AddInt32(&w.ActiveTasks, 1)
 	defer atomic.AddInt32(&w.ActiveTasks, -1)
 
-	fmt.Printf("[WORKER %d] Ejecutando %s (Part: %d, Op: %s)\n", w.Port, task.NodeID, task.PartitionID, task.Op)	// Construir path de archivo de salida
-	outputFile := fmt.Sprintf("%s/%s_%s_part%d.txt", w.OutputDir, task.JobID, task.NodeID, task.PartitionID)
-	
-	var err error
-	// Ejecutar operador segun tipo de tarea
-	switch task.Op {
-	case "read_csv", "read_jsonl":
+	fmt.Printf("[WORKER %d] Ejecutando %s (Part: %d, Op: %s)\n", w.Port, task.NodeID, task.PartitionID, task.Op)
+	// Construir path de archivo de salida, propio del intento
+	outputFile := fmt.Sprintf("%s/%s_%s_part%d_%s.txt", w.OutputDir, task.JobID, task.NodeID, task.PartitionID, task.ID)
+	if ctx.Err() != nil {
+		// Cancelada entre la aceptacion (TaskHandler) y el inicio de la goroutine
+		fmt.Printf("[WORKER %d] Tarea %s cancelada antes de iniciar\n", w.Port, task.ID)
+		return
+	}
 
-		originalPath := task.Args[0]
-        
-		// Si hay mas de 1 partición, intentamos buscar el archivo fragmentado
-		if task.TotalPartitions > 1 {
-				
-				ext := ".csv"
-				if strings.HasSuffix(originalPath, ".jsonl") {
-						ext = ".jsonl"
-				} else if strings.HasSuffix(originalPath, ".txt") {
-						ext = ".txt"
-				}
-				
-				baseName := strings.TrimSuffix(originalPath, ext)
-				partitionedPath := fmt.Sprintf("%s_part%d%s", baseName, task.PartitionID, ext)
-				
-				// Verificamos si existe el archivo particionado
-				if _, e := os.Stat(partitionedPath); e == nil {
-						fmt.Printf("[WORKER] Usando partición física: %s\n", partitionedPath)
-						err = operators.ReadCSV(partitionedPath, outputFile)
-				} else {
-						// Si no existe, advertimos y leemos el original (fallback)
-						fmt.Printf("[WORKER] WARN: No existe %s, leyendo original completo.\n", partitionedPath)
-						err = operators.ReadCSV(originalPath, outputFile)
-				}
-		} else {
-				// Si no hay paralelismo, leemos el archivo entero tal cual
-				err = operators.ReadCSV(originalPath, outputFile)
-		}
+	// El operador escribe en un temporal: un lector nunca ve una salida a medias
+	tmpFile := outputFile + ".tmp"
+	started := time.Now()
+	run := runOperatorMeasured(ctx, task, tmpFile)
+	if ctx.Err() != nil {
+		// Cancelada por el Master: el operador dejo de leer filas; no se reporta
+		fmt.Printf("[WORKER %d] Tarea %s cancelada, descartando salida\n", w.Port, task.ID)
+		w.traceTask(task, "execute", started, "tarea cancelada", nil)
+		os.Remove(tmpFile)
+		return
+	}
+	err := run.err
+	if err == nil {
+		err = os.Rename(tmpFile, outputFile)
+	} else {
+		os.Remove(tmpFile)
+	}
+
+	// Determinar estado de la tarea
+	status := "COMPLETED"
+	errorMsg := ""
+	errorClass := ""
+	if err != nil {
+		status = "FAILED"
+		errorMsg = err.Error()
+		errorClass = classifyError(err)
+		fmt.Printf("Error (%s): %v\n", errorClass, err)
+	}
+	usage := taskUsage(task, outputFile, run)
+	w.stats.observeTask(task, status, run.wall, usage)
+	w.traceTask(task, "execute", started, errorMsg, map[string]interface{}{
+		"task.records_in":  usage.RecordsIn,
+		"task.records_out": usage.RecordsOut,
+		"task.spills":      usage.SpillCount,
+		"task.cpu_secs":    usage.CPUSecs,
+	})
+	// Reportar resultado al Master
+	reported := time.Now()
+	w.reportCompletion(task, status, outputFile, errorMsg, errorClass, usage)
+	w.traceTask(task, "report_result", reported, "", map[string]interface{}{"task.status": status})
+}
 
+// operatorRun resultado de un operador con el tiempo y la CPU que consumio
+type operatorRun struct {
+	err     error
+	wall    time.Duration
+	cpu     time.Duration
+	spills  int                    // Archivos de spill o runs ordenados escritos
+	records operators.RecordCounts // Registros leidos y escritos por el operador
+}
+
+// runOperatorMeasured - Ejecuta runOperator midiendo su duracion y su CPU
+// Descripcion: Fija la goroutine a un hilo del sistema para que la CPU del
+//
+//	hilo corresponda solo a esta tarea (las tareas concurrentes usan otros).
+//	Los registros los cuenta el operador mientras lee y escribe.
+func runOperatorMeasured(ctx context.Context, task common.Task, outputFile string) operatorRun {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	var run operatorRun
+	ctx = operators.WithRecordCounts(ctx, &run.records)
+	cpuStart, cpuOK := threadCPUTime()
+	start := time.Now()
+	run.err = runOperator(ctx, task, outputFile)
+	run.wall = time.Since(start)
+	if cpuEnd, ok := threadCPUTime(); ok && cpuOK {
+		run.cpu = cpuEnd - cpuStart
+	}
+	run.spills = takeSpills(outputFile)
+	return run
+}
 
+// classifyError - Clasifica un fallo de tarea para la politica de reintentos
+// Entrada: err - error del operador
+// Salida: FATAL si reintentar no puede corregirlo (tarea mal definida,
+//
+//	archivo de entrada inexistente o sin permisos), RETRYABLE en otro caso
+func classifyError(err error) string {
+	var spec *operators.SpecError
+	if errors.As(err, &spec) || errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrPermission) {
+		return common.ErrorClassFatal
+	}
+	return common.ErrorClassRetryable
+}
 
+// runOperator - Ejecuta el operador de una tarea
+// Entrada: ctx - cancelacion de la tarea, task - tarea a ejecutar, outputFile - archivo de salida
+// Salida: error si el operador falla (ctx.Err() si se cancelo)
+func runOperator(ctx context.Context, task common.Task, outputFile string) (err error) {
+	// Ejecutar operador segun tipo de tarea
+	switch task.Op {
+	case "read_csv", "read_jsonl":
+		err = readSource(ctx, task, sourcePath(task), outputFile)
 	case "map":
-		err = operators.Map(task.InputFiles, outputFile, task.Fn)
+		if len(task.Select) > 0 {
+			// Proyeccion declarativa de columnas
+			err = operators.Project(ctx, task.InputFiles, outputFile, task.Select, task.Columns)
+		} else {
+			err = operators.Map(ctx, task.InputFiles, outputFile, task.Fn)
+		}
+	case "filter":
+		if len(task.Where) > 0 {
+			// Condiciones declarativas sobre columnas
+			err = operators.FilterWhere(ctx, task.InputFiles, outputFile, task.Where, task.Columns)
+		} else {
+			err = operators.Filter(ctx, task.InputFiles, outputFile, task.Fn)
+		}
 	case "flat_map":
-		err = operators.FlatMap(task.InputFiles, outputFile, task.Fn)
+		err = operators.FlatMap(ctx, task.InputFiles, outputFile, task.Fn)
 	case "reduce_by_key":
 		// Usar implementacion con spill para manejar datasets grandes
-		err = opReduceByKeyWithSpill(task.InputFiles, outputFile)
+		err = opReduceByKeyWithSpill(ctx, task.InputFiles, outputFile)
+	case "aggregate_by_key", "group_by_key":
+		// Agregados multiples por clave, tambien con spill a disco
+		err = opAggregateByKeyWithSpill(ctx, task, outputFile)
+	case "top_n_by_key", "window":
+		// Ranking por clave con sort externo (runs en disco + merge)
+		err = opWindowWithExternalSort(ctx, task, outputFile)
 	case "join":
 		if len(task.InputFiles) >= 2 {
-			err = operators.Join(task.InputFiles[0], task.InputFiles[1], outputFile)
+			err = operators.Join(ctx, task.InputFiles[0], task.InputFiles[1], outputFile)
 		} else {
-			err = fmt.Errorf("join requiere 2 inputs")
+			err = &operators.SpecError{Msg: "join requiere 2 inputs"}
 		}
 	default:
-		err = fmt.Errorf("operación desconocida: %s", task.Op)
+		err = &operators.SpecError{Msg: fmt.Sprintf("operación desconocida: %s", task.Op)}
 	}
+	return err
+}
 
-	// Determinar estado de la tarea
-	status := "COMPLETED"
-	errorMsg := ""
-	if err != nil {
-		status = "FAILED"
-		errorMsg = err.Error()
-		fmt.Printf("Error: %v\n", err)
+// sourcePath - Archivo que lee una tarea source
+// Descripcion: Con mas de 1 particion se busca el archivo fragmentado
+//
+//	<base>_part<N><ext>; si no existe se lee el original completo.
+func sourcePath(task common.Task) string {
+	path, partitioned := partitionedSource(task)
+	if partitioned {
+		fmt.Printf("[WORKER] Usando partición física: %s\n", path)
+	} else if task.TotalPartitions > 1 {
+		fmt.Printf("[WORKER] WARN: No existe el fragmento %d de %s, leyendo original completo.\n", task.PartitionID, path)
 	}
-	// Reportar resultado al Master
-	w.reportCompletion(task, status, outputFile, errorMsg)
+	return path
+}
+
+// partitionedSource - Fragmento de la particion si existe, o el archivo original
+// Salida: ruta a leer, true si es el fragmento <base>_part<N><ext>
+func partitionedSource(task common.Task) (string, bool) {
+	originalPath := task.Args[0]
+	// Si no hay paralelismo, leemos el archivo entero tal cual
+	if task.TotalPartitions <= 1 {
+		return originalPath, false
+	}
+	ext := ".csv"
+	if strings.HasSuffix(originalPath, ".jsonl") {
+		ext = ".jsonl"
+	} else if strings.HasSuffix(originalPath, ".txt") {
+		ext = ".txt"
+	}
+	baseName := strings.TrimSuffix(originalPath, ext)
+	partitionedPath := fmt.Sprintf("%s_part%d%s", baseName, task.PartitionID, ext)
+	if _, err := os.Stat(partitionedPath); err != nil {
+		return originalPath, false
+	}
+	return partitionedPath, true
+}
+
+// readSource - Lee un archivo fuente segun el tipo de nodo
+// Entrada: task - tarea source, path - archivo a leer, outputFile - destino
+// Salida: error si falla lectura/escritura
+// Descripcion: read_jsonl con esquema de columnas convierte cada objeto
+//
+//	a fila CSV; en otro caso el archivo se copia tal cual.
+func readSource(ctx context.Context, task common.Task, path, outputFile string) error {
+	if task.Op == "read_jsonl" && len(task.Columns) > 0 {
+		return operators.ReadJSONL(ctx, path, outputFile, task.Columns)
+	}
+	return operators.ReadCSV(ctx, path, outputFile)
 }
 
 // reportCompletion - Envia resultado de tarea al Master
-// Entrada: task - tarea ejecutada, status - COMPLETED|FAILED, resPath - archivo salida, err - error
+// Entrada: task - tarea ejecutada, status - COMPLETED|FAILED, resPath - archivo salida, err - error,
+//
+//	errClass - clasificacion del fallo (RETRYABLE|FATAL), usage - recursos consumidos
+//
 // Salida: ninguna (void)
 // Descripcion: Construye TaskResult y lo envia via POST a /task/complete.
 //
-//	Reintenta hasta 3 veces si falla la conexion.
-func (w *Worker) reportCompletion(task common.Task, status, resPath, err string) {
-	res := common.TaskResult{ID: task.ID, JobID: task.JobID, NodeID: task.NodeID, PartitionID: task.PartitionID, Status: status, Result: resPath, ErrorMsg: err}
+//	Reintenta hasta 3 veces si falla la conexion. Si el Master responde
+//	410 Gone (otro intento gano) elimina el archivo de salida de este
+//	intento; resPath es siempre propio del intento (ver runTask).
+func (w *Worker) reportCompletion(task common.Task, status, resPath, err, errClass string, usage *common.TaskUsage) {
+	res := common.TaskResult{ID: task.ID, JobID: task.JobID, NodeID: task.NodeID, PartitionID: task.PartitionID, Status: status, Result: resPath, ErrorMsg: err, ErrorClass: errClass, WorkerID: w.ID, Usage: usage, Attempt: task.Attempt}
 	data, _ := json.Marshal(res)
 	// Reintentar hasta 3 veces
 	for i := 0; i < 3; i++ {
-		if _, e := http.Post(w.MasterURL+"/task/complete", "application/json", bytes.NewBuffer(data)); e == nil {
+		resp, e := http.Post(w.MasterURL+"/task/complete", "application/json", bytes.NewBuffer(data))
+		if e == nil {
+			resp.Body.Close()
+			// 410 Gone: otro intento de la particion gano, descartar salida
+			if resp.StatusCode == http.StatusGone {
+				fmt.Printf("[WORKER %d] Resultado descartado por el Master: %s\n", w.Port, task.ID)
+				os.Remove(resPath)
+			}
 			return
 		}
 		time.Sleep(1 * time.Second)
@@ -136,9 +293,14 @@ func (w *Worker) reportCompletion(task common.Task, status, resPath, err string)
 //	Fase 1: Lee inputs, acumula en mapa, hace spill a disco si supera threshold
 //	Fase 2: Merge de archivos spill + mapa en memoria
 //	Fase 3: Escribe resultado final agregado
-func opReduceByKeyWithSpill(inputs []string, outputFile string) error {
+func opReduceByKeyWithSpill(ctx context.Context, inputs []string, outputFile string) error {
 	counts := make(map[string]int)
 	var spillFiles []string
+	defer func() {
+		for _, s := range spillFiles {
+			os.Remove(s) // Spills pendientes si la tarea se cancela o falla
+		}
+	}()
 
 	// Fase 1: Lectura y Spill parcial
 	for _, in := range inputs {
@@ -146,7 +308,7 @@ func opReduceByKeyWithSpill(inputs []string, outputFile string) error {
 		if err != nil {
 			continue
 		}
-		scanner := bufio.NewScanner(file)
+		scanner := operators.NewScanner(ctx, file)
 		for scanner.Scan() {
 			// Incrementar contador
 			counts[scanner.Text()]++
@@ -157,12 +319,16 @@ func opReduceByKeyWithSpill(inputs []string, outputFile string) error {
 				if err := dumpSpill(counts, spillName); err != nil {
 					return err
 				}
+				recordSpill(outputFile, spillName)
 				spillFiles = append(spillFiles, spillName)
 				counts = make(map[string]int) // Liberar memoria
 				fmt.Printf("   -> Spill to disk: %s\n", spillName)
 			}
 		}
 		file.Close()
+		if err := scanner.Err(); err != nil {
+			return err
+		}
 	}
 
 	// Fase 2: Merge (Memoria + Archivos Spill)
@@ -171,7 +337,7 @@ func opReduceByKeyWithSpill(inputs []string, outputFile string) error {
 		if err != nil {
 			continue
 		}
-		scanner := bufio.NewScanner(file)
+		scanner := operators.NewSpillScanner(ctx, file)
 		for scanner.Scan() {
 			// Parsear linea "clave,contador" de archivo spill
 			parts := strings.SplitN(scanner.Text(), ",", 2)
@@ -183,6 +349,9 @@ func opReduceByKeyWithSpill(inputs []string, outputFile string) error {
 			}
 		}
 		file.Close()
+		if err := scanner.Err(); err != nil {
+			return err
+		}
 		os.Remove(spill) // Borrar archivo temporal
 	}
 
@@ -192,7 +361,7 @@ func opReduceByKeyWithSpill(inputs []string, outputFile string) error {
 		return err
 	}
 	defer f.Close()
-	w := bufio.NewWriter(f)
+	w := operators.NewWriter(ctx, f)
 	// Escribir resultado agregado "clave, contador"
 	for k, v := range counts {
 		w.WriteString(fmt.Sprintf("%s, %d\n", k, v))
@@ -220,3 +389,377 @@ func dumpSpill(data map[string]int, filename string) error {
 	}
 	return w.Flush()
 }
+
+// --- AGREGACION MULTIPLE CON SPILL ---
+
+// opAggregateByKeyWithSpill - group_by/aggregate_by_key con gestion de memoria
+// Entrada: task - tarea con GroupBy/Aggregates/Columns, outputFile - destino
+// Salida: error si la configuracion es invalida o falla I/O
+// Descripcion: Mismo esquema de fases que opReduceByKeyWithSpill:
+//
+//	Fase 1: Acumula estados parciales por grupo, spill si supera threshold
+//	Fase 2: Merge de estados parciales de los archivos spill
+//	Fase 3: Escribe una fila por grupo "k1, k2, agg1, agg2"
+func opAggregateByKeyWithSpill(ctx context.Context, task common.Task, outputFile string) error {
+	keyCols, specs, err := operators.PrepareAggregation(task.Op, task.GroupBy, task.Aggregates, task.Columns)
+	if err != nil {
+		return err
+	}
+
+	groups := make(map[string]operators.GroupState)
+	var spillFiles []string
+	defer func() {
+		for _, s := range spillFiles {
+			os.Remove(s) // Spills pendientes si la tarea se cancela o falla
+		}
+	}()
+
+	// Fase 1: Lectura y Spill parcial
+	for _, in := range task.InputFiles {
+		file, err := os.Open(in)
+		if err != nil {
+			continue
+		}
+		scanner := operators.NewScanner(ctx, file)
+		for scanner.Scan() {
+			if strings.TrimSpace(scanner.Text()) == "" {
+				continue
+			}
+			fields := operators.SplitRow(scanner.Text())
+			k, _, ok := operators.GroupKey(fields, keyCols)
+			if !ok {
+				continue
+			}
+			g, exists := groups[k]
+			if !exists {
+				g = operators.NewGroupState(len(specs))
+				groups[k] = g
+			}
+			g.Add(specs, fields)
+
+			// CHEQUEO DE MEMORIA: Si hay demasiados grupos, hacer spill
+			if len(groups) >= SpillThreshold {
+				spillName := fmt.Sprintf("%s_spill_%d.tmp", outputFile, len(spillFiles))
+				if err := dumpAggSpill(groups, spillName); err != nil {
+					file.Close()
+					return err
+				}
+				recordSpill(outputFile, spillName)
+				spillFiles = append(spillFiles, spillName)
+				groups = make(map[string]operators.GroupState) // Liberar memoria
+				fmt.Printf("   -> Spill to disk: %s\n", spillName)
+			}
+		}
+		file.Close()
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+
+	// Fase 2: Merge (Memoria + Archivos Spill)
+	for _, spill := range spillFiles {
+		file, err := os.Open(spill)
+		if err != nil {
+			continue
+		}
+		scanner := operators.NewSpillScanner(ctx, file)
+		for scanner.Scan() {
+			k, partial, err := operators.DecodeSpillLine(scanner.Text())
+			if err != nil {
+				continue
+			}
+			if g, ok := groups[k]; ok {
+				g.Merge(partial)
+			} else {
+				groups[k] = partial
+			}
+		}
+		file.Close()
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+		os.Remove(spill) // Borrar archivo temporal
+	}
+
+	// Fase 3: Escritura Final
+	return operators.WriteGroups(ctx, outputFile, specs, groups)
+}
+
+// dumpAggSpill - Escribe estados parciales de grupos a archivo temporal
+// Entrada: groups - mapa clave->estado parcial, filename - archivo destino
+// Salida: error si falla escritura
+// Descripcion: Una linea JSON por grupo para poder mezclar sum/count/min/max
+//
+//	sin perder precision al recargar el spill.
+func dumpAggSpill(groups map[string]operators.GroupState, filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	for k, g := range groups {
+		line, err := operators.EncodeSpillLine(k, g)
+		if err != nil {
+			return err
+		}
+		w.WriteString(line + "\n")
+	}
+	return w.Flush()
+}
+
+// --- VENTANAS CON SORT EXTERNO ---
+
+// opWindowWithExternalSort - top_n_by_key/window sin cargar todo en memoria
+// Entrada: task - tarea con PartitionBy/OrderBy/Limit/Fn, outputFile - destino
+// Salida: error si la configuracion es invalida o falla I/O
+// Descripcion: Sort externo en dos fases:
+//
+//	Fase 1: Lee filas en bloques de SpillThreshold, ordena cada bloque
+//	        (particion, orden) y lo escribe como run en disco
+//	Fase 2: Merge k-way de los runs y calculo de rank/row_number en streaming.
+//	        Si hay mas de MergeFanIn runs, antes se mezclan por tandas
+//	        en runs intermedios para no abrir todos los archivos a la vez.
+func opWindowWithExternalSort(ctx context.Context, task common.Task, outputFile string) error {
+	spec, err := operators.PrepareWindow(task.Op, task.Fn, task.PartitionBy, task.OrderBy, task.Columns, task.Limit)
+	if err != nil {
+		return err
+	}
+
+	var runs []string
+	var buffer []string
+	flush := func() error {
+		if len(buffer) == 0 {
+			return nil
+		}
+		runName := fmt.Sprintf("%s_run_%d.tmp", outputFile, len(runs))
+		if err := dumpSortedRun(buffer, spec, runName); err != nil {
+			return err
+		}
+		recordSpill(outputFile, runName)
+		runs = append(runs, runName)
+		buffer = buffer[:0]
+		fmt.Printf("   -> Sorted run to disk: %s\n", runName)
+		return nil
+	}
+	defer func() {
+		for _, r := range runs {
+			os.Remove(r) // Borrar runs temporales
+		}
+	}()
+
+	// Fase 1: Generacion de runs ordenados
+	for _, in := range task.InputFiles {
+		file, err := os.Open(in)
+		if err != nil {
+			continue
+		}
+		scanner := operators.NewScanner(ctx, file)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			buffer = append(buffer, line)
+			if len(buffer) >= SpillThreshold {
+				if err := flush(); err != nil {
+					file.Close()
+					return err
+				}
+			}
+		}
+		file.Close()
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	// Fase 2a: Pasadas intermedias hasta que queden a lo sumo MergeFanIn runs.
+	// Cada tanda es de runs consecutivos, asi se conserva el desempate estable.
+	fanIn := MergeFanIn
+	if fanIn < 2 {
+		fanIn = 2
+	}
+	for pass := 0; len(runs) > fanIn; pass++ {
+		var merged []string
+		for start := 0; start < len(runs); start += fanIn {
+			group := runs[start:min(start+fanIn, len(runs))]
+			runName := fmt.Sprintf("%s_run_p%d_%d.tmp", outputFile, pass+1, len(merged))
+			if err := mergeRunsToFile(ctx, group, spec, runName); err != nil {
+				os.Remove(runName)
+				for _, r := range merged {
+					os.Remove(r)
+				}
+				return err
+			}
+			recordSpill(outputFile, runName)
+			for _, r := range group {
+				os.Remove(r)
+			}
+			merged = append(merged, runName)
+		}
+		runs = merged // Los de la pasada anterior ya se borraron
+	}
+
+	// Fase 2b: Merge k-way final + emision
+	f, err := os.Create(outputFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := operators.NewWriter(ctx, f)
+
+	emitter := operators.NewWindowEmitter(spec)
+	err = mergeRuns(ctx, runs, spec, func(line string, fields []string) {
+		if out, ok := emitter.Next(line, fields); ok {
+			w.WriteString(out + "\n")
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// mergeRunsToFile - Mezcla runs ordenados en un unico run intermedio
+// Entrada: runs - archivos a mezclar, spec - criterio de orden, filename - destino
+// Salida: error si falla I/O o se cancelo ctx
+func mergeRunsToFile(ctx context.Context, runs []string, spec operators.WindowSpec, filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	err = mergeRuns(ctx, runs, spec, func(line string, _ []string) {
+		w.WriteString(line + "\n")
+	})
+	if err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// mergeRuns - Merge k-way de runs ordenados
+// Entrada: runs - archivos ordenados, spec - criterio de orden,
+//
+//	emit - recibe cada fila en orden global (estable por orden de run)
+//
+// Salida: error si falla I/O o se cancelo ctx
+// Descripcion: Cada run se cierra apenas se agota; ante un error se
+//
+//	cierran los que sigan abiertos.
+func mergeRuns(ctx context.Context, runs []string, spec operators.WindowSpec, emit func(line string, fields []string)) error {
+	h := &runHeap{spec: spec}
+	defer func() {
+		for _, cur := range h.items {
+			cur.file.Close()
+		}
+	}()
+	for i, r := range runs {
+		file, err := os.Open(r)
+		if err != nil {
+			return err
+		}
+		cur := &runCursor{file: file, scanner: operators.NewSpillScanner(ctx, file), index: i}
+		if !cur.advance() {
+			file.Close()
+			if err := cur.scanner.Err(); err != nil {
+				return err
+			}
+			continue
+		}
+		h.items = append(h.items, cur)
+	}
+	heap.Init(h)
+
+	for h.Len() > 0 && ctx.Err() == nil {
+		cur := h.items[0]
+		emit(cur.line, cur.fields)
+		if cur.advance() {
+			heap.Fix(h, 0)
+			continue
+		}
+		heap.Pop(h)
+		cur.file.Close()
+		if err := cur.scanner.Err(); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// dumpSortedRun - Ordena un bloque de filas y lo escribe a disco
+// Entrada: lines - filas del bloque, spec - criterio de orden, filename - destino
+// Salida: error si falla escritura
+func dumpSortedRun(lines []string, spec operators.WindowSpec, filename string) error {
+	fields := make([][]string, len(lines))
+	for i, l := range lines {
+		fields[i] = operators.SplitRow(l)
+	}
+	idx := make([]int, len(lines))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return spec.Less(fields[idx[a]], fields[idx[b]]) })
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	for _, i := range idx {
+		w.WriteString(lines[i] + "\n")
+	}
+	return w.Flush()
+}
+
+// runCursor posicion de lectura dentro de un run ordenado
+type runCursor struct {
+	file    *os.File
+	scanner *operators.Scanner
+	index   int // Orden del run (desempate estable)
+	line    string
+	fields  []string
+}
+
+// advance - Avanza a la siguiente fila del run; false si se agoto
+func (c *runCursor) advance() bool {
+	if !c.scanner.Scan() {
+		return false
+	}
+	c.line = c.scanner.Text()
+	c.fields = operators.SplitRow(c.line)
+	return true
+}
+
+// runHeap min-heap de cursores ordenado por WindowSpec.Less
+type runHeap struct {
+	spec  operators.WindowSpec
+	items []*runCursor
+}
+
+func (h *runHeap) Len() int { return len(h.items) }
+func (h *runHeap) Less(i, j int) bool {
+	a, b := h.items[i], h.items[j]
+	if h.spec.Less(a.fields, b.fields) {
+		return true
+	}
+	if h.spec.Less(b.fields, a.fields) {
+		return false
+	}
+	return a.index < b.index
+}
+func (h *runHeap) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *runHeap) Push(x interface{}) { h.items = append(h.items, x.(*runCursor)) }
+func (h *runHeap) Pop() interface{} {
+	old := h.items
+	n := len(old)
+	item := old[n-1]
+	h.items = old[:n-1]
+	return item
+}
@@ -0,0 +1,90 @@
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: metrics.go
+Descripcion: Metricas Prometheus del Worker (GET /metrics).
+             Tareas ejecutadas por operacion y estado, su duracion, los
+             rechazos por falta de slots, los spills a disco y los bytes
+             de shuffle leidos, mas gauges de slots y tareas activas.
+*/
+
+package worker
+
+import (
+	"mini-spark/internal/common"
+	"mini-spark/internal/metrics"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Spills del proceso: los operadores externos no conocen al Worker
+var (
+	spillCount int64 // Archivos temporales escritos (spills y runs ordenados)
+	spillTotal int64 // Bytes escritos en esos archivos
+
+	spillMu    sync.Mutex
+	taskSpills = make(map[string]int) // Spills por tarea en curso: Archivo de salida -> Cantidad
+)
+
+// recordSpill - Contabiliza un archivo de spill recien escrito
+// Entrada: outputFile - salida de la tarea que hizo el spill, filename - archivo escrito
+func recordSpill(outputFile, filename string) {
+	atomic.AddInt64(&spillCount, 1)
+	if info, err := os.Stat(filename); err == nil {
+		atomic.AddInt64(&spillTotal, info.Size())
+	}
+	spillMu.Lock()
+	taskSpills[outputFile]++
+	spillMu.Unlock()
+}
+
+// takeSpills - Spills hechos por la tarea que escribe outputFile (y los olvida)
+func takeSpills(outputFile string) int {
+	spillMu.Lock()
+	defer spillMu.Unlock()
+	n := taskSpills[outputFile]
+	delete(taskSpills, outputFile)
+	return n
+}
+
+// workerMetrics series que el Worker actualiza al ejecutar tareas
+type workerMetrics struct {
+	tasks        *metrics.CounterVec   // Tareas terminadas por operacion y estado
+	taskDuration *metrics.HistogramVec // Duracion del operador por operacion
+	shuffleBytes *metrics.CounterVec   // Bytes leidos de salidas de otras etapas
+	rejected     *metrics.Counter      // Tareas rechazadas por falta de slots (429)
+}
+
+// newWorkerMetrics - Registra las metricas del Worker
+func newWorkerMetrics(w *Worker) (*metrics.Registry, *workerMetrics) {
+	reg := metrics.NewRegistry()
+	wm := &workerMetrics{
+		tasks:        reg.NewCounter("minispark_worker_tasks_total", "Tareas ejecutadas por el worker.", "op", "status"),
+		taskDuration: reg.NewHistogram("minispark_worker_task_duration_seconds", "Duracion del operador de cada tarea.", nil, "op"),
+		shuffleBytes: reg.NewCounter("minispark_worker_shuffle_read_bytes_total", "Bytes leidos por tareas que consumen la salida de otra etapa.", "op"),
+		rejected:     reg.NewCounter("minispark_worker_tasks_rejected_total", "Tareas rechazadas por falta de slots.").With(),
+	}
+	reg.NewCounterFunc("minispark_worker_spill_files_total", "Archivos de spill y runs ordenados escritos a disco.", func() float64 {
+		return float64(atomic.LoadInt64(&spillCount))
+	})
+	reg.NewCounterFunc("minispark_worker_spill_bytes_total", "Bytes escritos en archivos de spill y runs ordenados.", func() float64 {
+		return float64(atomic.LoadInt64(&spillTotal))
+	})
+	reg.NewGaugeFunc("minispark_worker_active_tasks", "Tareas en ejecucion.", func() float64 {
+		return float64(atomic.LoadInt32(&w.ActiveTasks))
+	})
+	reg.NewGaugeFunc("minispark_worker_slots", "Tareas concurrentes que acepta el worker.", func() float64 {
+		return float64(cap(w.sem))
+	})
+	return reg, wm
+}
+
+// observeTask - Registra el resultado de una tarea ejecutada
+func (wm *workerMetrics) observeTask(task common.Task, status string, wall time.Duration, usage *common.TaskUsage) {
+	wm.tasks.With(task.Op, status).Inc()
+	wm.taskDuration.With(task.Op).Observe(wall.Seconds())
+	if status == "COMPLETED" && usage != nil && !common.IsSourceOp(task.Op) {
+		wm.shuffleBytes.With(task.Op).Add(float64(usage.InputBytes))
+	}
+}
@@ -0,0 +1,119 @@
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: sysmetrics.go
+Descripcion: Metricas de sistema del Worker.
+             Calcula el uso de CPU del proceso y de la maquina entre dos
+             heartbeats, la memoria residente, la carga media y el
+             espacio libre del directorio de salida. Los valores se leen
+             de /proc en Linux (sysmetrics_linux.go); en otros sistemas
+             solo se reportan las metricas del runtime de Go.
+*/
+
+package worker
+
+import (
+	"mini-spark/internal/common"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// cpuSampler calcula porcentajes de CPU entre muestras consecutivas
+type cpuSampler struct {
+	mu        sync.Mutex
+	last      time.Time // Momento de la muestra anterior
+	procTicks uint64    // Ticks de CPU del proceso (utime + stime)
+	hostTotal uint64    // Ticks totales de la maquina
+	hostIdle  uint64    // Ticks ociosos de la maquina (idle + iowait)
+}
+
+// sample - Porcentajes de CPU del proceso y de la maquina desde la muestra anterior
+// Salida: procPct (0-100 sobre todos los nucleos), hostPct (0-100); 0 en la primera muestra
+func (s *cpuSampler) sample() (procPct, hostPct float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	proc, procOK := processCPUTicks()
+	total, idle, hostOK := hostCPUTicks()
+
+	if !s.last.IsZero() {
+		elapsedTicks := now.Sub(s.last).Seconds() * clockTicks * float64(runtime.NumCPU())
+		if procOK && elapsedTicks > 0 && proc >= s.procTicks {
+			procPct = clampPercent(float64(proc-s.procTicks) / elapsedTicks * 100)
+		}
+		if hostOK && total > s.hostTotal && idle >= s.hostIdle {
+			busy := float64((total - s.hostTotal) - (idle - s.hostIdle))
+			hostPct = clampPercent(busy / float64(total-s.hostTotal) * 100)
+		}
+	}
+	s.last, s.procTicks, s.hostTotal, s.hostIdle = now, proc, total, idle
+	return procPct, hostPct
+}
+
+// clampPercent - Limita un porcentaje a [0, 100]
+func clampPercent(p float64) float64 {
+	if p < 0 {
+		return 0
+	}
+	if p > 100 {
+		return 100
+	}
+	return p
+}
+
+// SystemMetrics - Metricas actuales del worker (las que envia en cada heartbeat)
+// Salida: SystemMetrics con CPU (desde la llamada anterior), RSS, carga
+//
+//	media, disco libre en OutputDir y metricas del runtime de Go
+func (w *Worker) SystemMetrics() common.SystemMetrics {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	procPct, hostPct := w.cpu.sample()
+	metrics := common.SystemMetrics{
+		CPUPercent:     procPct,
+		MemoryUsage:    mem.Alloc,
+		ActiveTasks:    int(atomic.LoadInt32(&w.ActiveTasks)),
+		HostCPUPercent: hostPct,
+		HeapBytes:      mem.Alloc,
+		Goroutines:     runtime.NumGoroutine(),
+		NumCPU:         runtime.NumCPU(),
+	}
+	if rss, ok := processRSS(); ok {
+		metrics.MemoryUsage = rss
+	}
+	if load, ok := loadAverage(); ok {
+		metrics.LoadAvg = load
+	}
+	if free, ok := diskFree(w.OutputDir); ok {
+		metrics.DiskFreeBytes = free
+	}
+	return metrics
+}
+
+// taskUsage - Recursos consumidos por una tarea
+// Entrada: task - tarea ejecutada, output - archivo de salida,
+//
+//	run - duracion, CPU, spills y registros contados por el operador
+func taskUsage(task common.Task, output string, run operatorRun) *common.TaskUsage {
+	usage := &common.TaskUsage{WallSecs: run.wall.Seconds(), CPUSecs: run.cpu.Seconds(), SpillCount: run.spills,
+		RecordsIn: run.records.In, RecordsOut: run.records.Out}
+	inputs := task.InputFiles
+	if common.IsSourceOp(task.Op) && len(task.Args) > 0 {
+		path, _ := partitionedSource(task)
+		inputs = []string{path}
+	}
+	for _, in := range inputs {
+		if info, err := os.Stat(in); err == nil {
+			usage.InputBytes += info.Size()
+		}
+	}
+	if info, err := os.Stat(output); err == nil {
+		usage.OutputBytes = info.Size()
+	}
+	if rss, ok := processRSS(); ok {
+		usage.RSSBytes = rss
+	}
+	return usage
+}
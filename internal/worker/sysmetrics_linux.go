@@ -0,0 +1,139 @@
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: sysmetrics_linux.go
+Descripcion: Lectura de metricas de sistema desde /proc (Linux).
+             CPU del proceso (/proc/self/stat), del hilo actual
+             (/proc/self/task/<tid>/stat) y de la maquina (/proc/stat),
+             memoria residente (/proc/self/statm), carga media
+             (/proc/loadavg) y espacio libre en disco (statfs).
+*/
+
+package worker
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// clockTicks ticks de CPU por segundo (USER_HZ, 100 en Linux)
+const clockTicks = 100
+
+// statCPUTicks - utime + stime de un archivo stat de /proc
+// Descripcion: El nombre del comando (campo 2) puede tener espacios; los
+//
+//	campos se cuentan desde el ultimo ')'. utime y stime son los campos 14 y 15.
+func statCPUTicks(path string) (uint64, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	s := string(data)
+	end := strings.LastIndexByte(s, ')')
+	if end < 0 {
+		return 0, false
+	}
+	fields := strings.Fields(s[end+1:])
+	// fields[0] es el campo 3 (estado): utime = campo 14 -> fields[11]
+	if len(fields) < 13 {
+		return 0, false
+	}
+	utime, err1 := strconv.ParseUint(fields[11], 10, 64)
+	stime, err2 := strconv.ParseUint(fields[12], 10, 64)
+	if err1 != nil || err2 != nil {
+		return 0, false
+	}
+	return utime + stime, true
+}
+
+// processCPUTicks - Ticks de CPU consumidos por el proceso
+func processCPUTicks() (uint64, bool) {
+	return statCPUTicks("/proc/self/stat")
+}
+
+// threadCPUTime - CPU consumida por el hilo del sistema actual
+// Nota: Solo es la CPU de una goroutine si esta fijada con runtime.LockOSThread
+func threadCPUTime() (time.Duration, bool) {
+	ticks, ok := statCPUTicks(fmt.Sprintf("/proc/self/task/%d/stat", syscall.Gettid()))
+	if !ok {
+		return 0, false
+	}
+	return time.Duration(ticks) * time.Second / clockTicks, true
+}
+
+// hostCPUTicks - Ticks totales y ociosos (idle + iowait) de la maquina
+func hostCPUTicks() (total, idle uint64, ok bool) {
+	data, err := os.ReadFile("/proc/stat")
+	if err != nil {
+		return 0, 0, false
+	}
+	line, _, _ := strings.Cut(string(data), "\n")
+	fields := strings.Fields(line)
+	if len(fields) < 5 || fields[0] != "cpu" {
+		return 0, 0, false
+	}
+	for i, f := range fields[1:] {
+		v, err := strconv.ParseUint(f, 10, 64)
+		if err != nil {
+			return 0, 0, false
+		}
+		// guest y guest_nice ya estan incluidos en user y nice
+		if i < 8 {
+			total += v
+		}
+		if i == 3 || i == 4 {
+			idle += v
+		}
+	}
+	return total, idle, true
+}
+
+// processRSS - Memoria residente del proceso en bytes
+func processRSS() (uint64, bool) {
+	data, err := os.ReadFile("/proc/self/statm")
+	if err != nil {
+		return 0, false
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 2 {
+		return 0, false
+	}
+	pages, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return pages * uint64(os.Getpagesize()), true
+}
+
+// loadAverage - Carga media de 1, 5 y 15 minutos
+func loadAverage() ([]float64, bool) {
+	data, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return nil, false
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 3 {
+		return nil, false
+	}
+	load := make([]float64, 3)
+	for i := range load {
+		v, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return nil, false
+		}
+		load[i] = v
+	}
+	return load, true
+}
+
+// diskFree - Bytes disponibles para el usuario en el sistema de archivos de path
+func diskFree(path string) (uint64, bool) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, false
+	}
+	return st.Bavail * uint64(st.Bsize), true
+}
@@ -0,0 +1,23 @@
+//go:build !linux
+
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: sysmetrics_other.go
+Descripcion: Metricas de sistema en plataformas sin /proc.
+             Todas las lecturas reportan "no disponible"; el Worker
+             envia solo las metricas del runtime de Go.
+*/
+
+package worker
+
+import "time"
+
+// clockTicks ticks de CPU por segundo (sin uso fuera de Linux)
+const clockTicks = 100
+
+func processCPUTicks() (uint64, bool)             { return 0, false }
+func threadCPUTime() (time.Duration, bool)        { return 0, false }
+func hostCPUTicks() (total, idle uint64, ok bool) { return 0, 0, false }
+func processRSS() (uint64, bool)                  { return 0, false }
+func loadAverage() ([]float64, bool)              { return nil, false }
+func diskFree(path string) (uint64, bool)         { return 0, false }
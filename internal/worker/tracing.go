@@ -0,0 +1,36 @@
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: tracing.go
+Descripcion: Spans del Worker para las trazas distribuidas.
+             La tarea trae el contexto de su span en el Master
+             (Task.TraceParent); el Worker agrega como hijos la ejecucion
+             del operador (execute) y el envio del resultado (report_result).
+*/
+
+package worker
+
+import (
+	"mini-spark/internal/common"
+	"mini-spark/internal/tracing"
+	"time"
+)
+
+// traceTask - Registra un span del worker como hijo del span de la tarea
+// Entrada: name - operacion, start - inicio (el fin es ahora), errMsg - fallo ("" = ok)
+func (w *Worker) traceTask(task common.Task, name string, start time.Time, errMsg string, attrs map[string]interface{}) {
+	parent, ok := tracing.ParseTraceparent(task.TraceParent)
+	if !ok {
+		return
+	}
+	span := tracing.NewSpan(name, parent, start)
+	span.Error = errMsg
+	span.Attributes["task.id"] = task.ID
+	span.Attributes["task.op"] = task.Op
+	span.Attributes["task.part"] = task.PartitionID
+	span.Attributes["task.attempt"] = task.Attempt
+	span.Attributes["worker.id"] = w.ID
+	for k, v := range attrs {
+		span.Attributes[k] = v
+	}
+	w.Tracer.Record(span)
+}
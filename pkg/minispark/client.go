@@ -0,0 +1,353 @@
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: client.go
+Descripcion: Cliente HTTP del SDK de Mini-Spark.
+             Envia jobs al Master, consulta su estado con polling,
+             obtiene rutas de resultados y transmite el contenido de
+             las salidas linea por linea. Todos los fallos se reportan
+             como errores tipados (ver errors.go).
+*/
+
+package minispark
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mini-spark/internal/common"
+	"mini-spark/internal/jobtemplate"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMasterURL URL del Master en despliegues locales
+const DefaultMasterURL = "http://localhost:8080"
+
+// Client cliente del API REST del Master
+type Client struct {
+	BaseURL string       // URL del Master (http://host:port)
+	HTTP    *http.Client // Cliente HTTP (configurable para timeouts/TLS)
+}
+
+// NewClient - Constructor del cliente
+// Entrada: baseURL - URL del Master ("" para DefaultMasterURL)
+// Salida: puntero a Client listo para usar
+func NewClient(baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultMasterURL
+	}
+	return &Client{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		HTTP:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// submitResponse respuesta de los endpoints de envio
+type submitResponse struct {
+	JobID  string `json:"job_id"`
+	Status string `json:"status"`
+}
+
+// do - Ejecuta una peticion y decodifica la respuesta JSON en out
+// Salida: *APIError si el Master responde con codigo fuera de 2xx
+func (c *Client) do(ctx context.Context, method, path string, body []byte, out interface{}) error {
+	return c.doOn(ctx, "", method, path, body, out)
+}
+
+// doOn - Como do, para una peticion sobre un job o un worker
+// Entrada: resource - ResourceJob | ResourceWorker, se guarda en el *APIError
+//
+//	para que un 404 se distinga como ErrJobNotFound o ErrWorkerNotFound
+func (c *Client) doOn(ctx context.Context, resource, method, path string, body []byte, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("error conectando con Master: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(resp.Body)
+		return &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(msg)), Resource: resource}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Submit - Valida y envia un JobRequest
+// Entrada: ctx - contexto, req - job a enviar
+// Salida: ID del job o error (*ValidationError, *APIError)
+func (c *Client) Submit(ctx context.Context, req JobRequest) (string, error) {
+	if err := common.ValidateJobRequest(req); err != nil {
+		return "", &ValidationError{Reason: err.Error()}
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	return c.SubmitJSON(ctx, data)
+}
+
+// SubmitJSON - Envia una definicion de job ya serializada (ej: archivo jobs/*.json)
+func (c *Client) SubmitJSON(ctx context.Context, data []byte) (string, error) {
+	var res submitResponse
+	if err := c.do(ctx, http.MethodPost, "/api/v1/jobs", data, &res); err != nil {
+		return "", err
+	}
+	return res.JobID, nil
+}
+
+// SubmitTemplate - Envia una plantilla de job con sus parametros
+// Entrada: ctx, tmpl - JSON de la plantilla (placeholders ${param} y
+//
+//	declaracion "params"), params - valores (string o tipos JSON)
+//
+// Salida: ID del job o error (*ValidationError si la plantilla no renderiza
+//
+//	un DAG valido, *APIError)
+//
+// Descripcion: Renderiza localmente para fallar antes de contactar al
+//
+//	Master; el Master vuelve a renderizar y guarda los parametros con el job.
+func (c *Client) SubmitTemplate(ctx context.Context, tmpl []byte, params map[string]interface{}) (string, error) {
+	req, _, err := jobtemplate.Render(tmpl, params)
+	if err != nil {
+		return "", &ValidationError{Reason: err.Error()}
+	}
+	if err := common.ValidateJobRequest(req); err != nil {
+		return "", &ValidationError{Reason: err.Error()}
+	}
+	data, err := json.Marshal(common.TemplateSubmitRequest{Template: tmpl, Params: params})
+	if err != nil {
+		return "", err
+	}
+	return c.SubmitJSON(ctx, data)
+}
+
+// Run - Construye el job de un Dataset y lo envia
+// Entrada: ctx, d - Dataset final, name - nombre, parallelism - particiones
+// Salida: ID del job o error
+func (c *Client) Run(ctx context.Context, d *Dataset, name string, parallelism int) (string, error) {
+	req, err := d.Job(name, parallelism)
+	if err != nil {
+		return "", err
+	}
+	return c.Submit(ctx, req)
+}
+
+// SubmitSQL - Envia una consulta SQL para compilarla y ejecutarla
+func (c *Client) SubmitSQL(ctx context.Context, req SQLRequest) (string, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	var res submitResponse
+	if err := c.do(ctx, http.MethodPost, "/api/v1/sql", data, &res); err != nil {
+		return "", err
+	}
+	return res.JobID, nil
+}
+
+// RegisterTable - Registra una tabla para consultas SQL
+func (c *Client) RegisterTable(ctx context.Context, def TableDef) (TableDef, error) {
+	data, err := json.Marshal(def)
+	if err != nil {
+		return def, err
+	}
+	var out TableDef
+	err = c.do(ctx, http.MethodPost, "/api/v1/tables", data, &out)
+	return out, err
+}
+
+// Tables - Lista las tablas registradas
+func (c *Client) Tables(ctx context.Context) ([]TableDef, error) {
+	var out []TableDef
+	err := c.do(ctx, http.MethodGet, "/api/v1/tables", nil, &out)
+	return out, err
+}
+
+// Status - Consulta el estado actual de un job
+// Salida: JobStatusResponse o error (errors.Is(err, ErrJobNotFound) si no existe)
+func (c *Client) Status(ctx context.Context, jobID string) (*JobStatusResponse, error) {
+	var out JobStatusResponse
+	if err := c.doOn(ctx, ResourceJob, http.MethodGet, "/api/v1/jobs/"+jobID, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Jobs - Lista los jobs del Master, mas recientes primero
+// Entrada: status - filtra por estado (RUNNING, COMPLETED, FAILED, CANCELLED; "" = todos)
+// Salida: resumen de cada job (sin DAG, particiones ni metricas) o error
+func (c *Client) Jobs(ctx context.Context, status string) ([]JobStatusResponse, error) {
+	path := "/api/v1/jobs"
+	if status != "" {
+		path += "?status=" + status
+	}
+	var out common.JobListResponse
+	if err := c.do(ctx, http.MethodGet, path, nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Jobs, nil
+}
+
+// Wait - Hace polling del estado hasta que el job termina
+// Entrada: ctx - contexto (cancelable), jobID, interval - periodo de polling
+// Salida: estado final; *JobFailedError si el job fallo o fue cancelado, ctx.Err() si se cancela ctx
+func (c *Client) Wait(ctx context.Context, jobID string, interval time.Duration) (*JobStatusResponse, error) {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		st, err := c.Status(ctx, jobID)
+		if err != nil {
+			return nil, err
+		}
+		switch st.Status {
+		case "COMPLETED":
+			return st, nil
+		case "FAILED", "CANCELLED":
+			return st, &JobFailedError{JobID: jobID, Status: st.Status, Failures: st.Failures}
+		}
+		select {
+		case <-ctx.Done():
+			return st, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Cancel - Cancela un job en ejecucion
+// Salida: tareas quitadas de la cola y canceladas, ErrJobNotFound, o
+//
+//	*APIError (409 si el job ya termino)
+func (c *Client) Cancel(ctx context.Context, jobID string) (*CancelJobResponse, error) {
+	var out CancelJobResponse
+	if err := c.doOn(ctx, ResourceJob, http.MethodPost, "/api/v1/jobs/"+jobID+"/cancel", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Workers - Lista los workers registrados en el Master
+func (c *Client) Workers(ctx context.Context) ([]WorkerStatusResponse, error) {
+	var out []WorkerStatusResponse
+	err := c.do(ctx, http.MethodGet, "/api/v1/workers", nil, &out)
+	return out, err
+}
+
+// Worker - Consulta el detalle de un worker (tareas asignadas e historial de metricas)
+// Salida: WorkerDetailResponse o error (errors.Is(err, ErrWorkerNotFound) si no existe)
+func (c *Client) Worker(ctx context.Context, workerID string) (*WorkerDetailResponse, error) {
+	var out WorkerDetailResponse
+	if err := c.doOn(ctx, ResourceWorker, http.MethodGet, "/api/v1/workers/"+workerID, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Decommission - Inicia el retiro ordenado de un worker
+// Salida: estado del worker (DRAINING) o error (errors.Is(err, ErrWorkerNotFound) si no existe)
+func (c *Client) Decommission(ctx context.Context, workerID string) (*WorkerStatusResponse, error) {
+	var out WorkerStatusResponse
+	if err := c.doOn(ctx, ResourceWorker, http.MethodPost, "/api/v1/workers/"+workerID+"/decommission", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Queue - Consulta la cola de tareas pendientes del Master
+func (c *Client) Queue(ctx context.Context) (*QueueStatusResponse, error) {
+	var out QueueStatusResponse
+	if err := c.do(ctx, http.MethodGet, "/api/v1/queue", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Scaling - Consulta las señales y el estado del escalado dinamico
+func (c *Client) Scaling(ctx context.Context) (*ScalingStatusResponse, error) {
+	var out ScalingStatusResponse
+	if err := c.do(ctx, http.MethodGet, "/api/v1/scaling", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Events - Consulta el historial de eventos de un job
+// Entrada: after - solo eventos con Seq mayor (0 = todos)
+// Salida: JobEventsResponse o error (errors.Is(err, ErrJobNotFound) si no existe)
+func (c *Client) Events(ctx context.Context, jobID string, after int) (*JobEventsResponse, error) {
+	path := "/api/v1/jobs/" + jobID + "/events"
+	if after > 0 {
+		path += "?after=" + strconv.Itoa(after)
+	}
+	var out JobEventsResponse
+	if err := c.doOn(ctx, ResourceJob, http.MethodGet, path, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Results - Obtiene las rutas de salida de los nodos finales
+// Salida: JobResultsResponse o error (errors.Is(err, ErrJobNotFound) si no existe)
+func (c *Client) Results(ctx context.Context, jobID string) (*JobResultsResponse, error) {
+	var out JobResultsResponse
+	if err := c.doOn(ctx, ResourceJob, http.MethodGet, "/api/v1/jobs/"+jobID+"/results", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// StreamResults - Transmite la salida de un nodo linea por linea
+// Entrada: ctx, jobID, nodeID - nodo a leer, fn - callback por linea
+// Salida: error de red, *APIError o el primer error retornado por fn
+// Descripcion: Usa GET /api/v1/jobs/{id}/results/{node}; las particiones
+//
+//	se entregan en orden sin cargar el archivo completo en memoria.
+func (c *Client) StreamResults(ctx context.Context, jobID, nodeID string, fn func(line string) error) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/api/v1/jobs/%s/results/%s", c.BaseURL, jobID, nodeID), nil)
+	if err != nil {
+		return err
+	}
+	// Sin timeout global: la salida puede ser grande; se controla con ctx
+	httpClient := *c.HTTP
+	httpClient.Timeout = 0
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error conectando con Master: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		return &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(msg))}
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if err := fn(scanner.Text()); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
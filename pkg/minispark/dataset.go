@@ -0,0 +1,259 @@
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: dataset.go
+Descripcion: API fluida para construir jobs de Mini-Spark desde Go.
+             Cada llamada (ReadCSV(...).FlatMap(...).ReduceByKey(...))
+             agrega un nodo al plan y genera IDs y aristas de forma
+             automatica, evitando DAGs escritos a mano con errores.
+             El resultado es un JobRequest validado.
+*/
+
+package minispark
+
+import (
+	"fmt"
+	"mini-spark/internal/common"
+	"reflect"
+)
+
+// plan DAG en construccion de un Dataset
+// Descripcion: Cada derivacion trabaja sobre una copia (clone), asi las
+//
+//	ramas hermanas de un mismo Dataset no se ven entre si.
+type plan struct {
+	nodes    []common.DAGNode
+	edges    [][]string
+	counters map[string]int
+}
+
+// newPlan - Crea un plan vacio
+func newPlan() *plan {
+	return &plan{counters: make(map[string]int)}
+}
+
+// clone - Copia del plan para derivar un Dataset nuevo
+// Descripcion: Las aristas son slices de 2 elementos que nunca se
+//
+//	modifican, por lo que basta copiar el slice exterior.
+func (p *plan) clone() *plan {
+	c := &plan{
+		nodes:    append([]common.DAGNode{}, p.nodes...),
+		edges:    append([][]string{}, p.edges...),
+		counters: make(map[string]int, len(p.counters)),
+	}
+	for op, n := range p.counters {
+		c.counters[op] = n
+	}
+	return c
+}
+
+// nextID - Genera un ID unico dentro del plan a partir del operador
+func (p *plan) nextID(op string) string {
+	for {
+		p.counters[op]++
+		id := fmt.Sprintf("%s_%d", op, p.counters[op])
+		if !p.has(id) {
+			return id
+		}
+	}
+}
+
+// has - true si el plan ya contiene un nodo con ese ID
+func (p *plan) has(id string) bool {
+	_, ok := p.node(id)
+	return ok
+}
+
+// node - Nodo del plan con ese ID
+func (p *plan) node(id string) (common.DAGNode, bool) {
+	for _, n := range p.nodes {
+		if n.ID == id {
+			return n, true
+		}
+	}
+	return common.DAGNode{}, false
+}
+
+// parents - Padres de un nodo en orden de aristas (orden de inputs)
+func (p *plan) parents(id string) []string {
+	var out []string
+	for _, e := range p.edges {
+		if e[1] == id {
+			out = append(out, e[0])
+		}
+	}
+	return out
+}
+
+// absorb - Copia los nodos de otro plan renombrando IDs en conflicto
+// Salida: mapa ID original -> ID en este plan
+// Descripcion: Un nodo identico (mismo ID, definicion y padres) ya
+//
+//	presente se reutiliza: dos ramas derivadas del mismo Dataset
+//	comparten su prefijo en lugar de duplicarlo.
+func (p *plan) absorb(other *plan) map[string]string {
+	rename := make(map[string]string, len(other.nodes))
+	for _, n := range other.nodes {
+		// Los nodos estan en orden de creacion: los padres ya se renombraron
+		var parents []string
+		for _, par := range other.parents(n.ID) {
+			parents = append(parents, rename[par])
+		}
+		if existing, ok := p.node(n.ID); ok && reflect.DeepEqual(existing, n) && reflect.DeepEqual(p.parents(n.ID), parents) {
+			rename[n.ID] = n.ID
+			continue
+		}
+		newID := n.ID
+		if p.has(newID) {
+			newID = p.nextID(n.Op)
+		}
+		rename[n.ID] = newID
+		n.ID = newID
+		p.nodes = append(p.nodes, n)
+		for _, par := range parents {
+			p.edges = append(p.edges, []string{par, newID})
+		}
+	}
+	return rename
+}
+
+// Dataset referencia inmutable a la salida de un nodo del plan
+// Descripcion: Los metodos retornan un nuevo Dataset con su propia copia
+//
+//	del plan; el mismo Dataset puede ramificarse en varios hijos sin
+//	que una rama aparezca en el Job() de otra. Los errores de
+//	construccion se acumulan y se reportan en Job().
+type Dataset struct {
+	plan *plan
+	id   string
+	err  error
+}
+
+// ReadCSV - Crea un Dataset fuente a partir de un archivo CSV/texto
+func ReadCSV(path string) *Dataset {
+	return source(common.DAGNode{Op: "read_csv", Path: path})
+}
+
+// ReadJSONL - Crea un Dataset fuente desde JSON Lines
+// Entrada: path - archivo JSONL, columns - campos a extraer (opcional)
+// Descripcion: Con columnas, cada objeto se convierte en una fila CSV.
+func ReadJSONL(path string, columns ...string) *Dataset {
+	return source(common.DAGNode{Op: "read_jsonl", Path: path, Columns: columns})
+}
+
+// source - Crea un plan nuevo con un nodo fuente
+func source(node common.DAGNode) *Dataset {
+	p := newPlan()
+	node.ID = p.nextID(node.Op)
+	p.nodes = append(p.nodes, node)
+	return &Dataset{plan: p, id: node.ID}
+}
+
+// ID - Identificador del nodo que produce este Dataset
+func (d *Dataset) ID() string { return d.id }
+
+// then - Agrega un nodo hijo de este Dataset
+func (d *Dataset) then(node common.DAGNode) *Dataset {
+	if d.err != nil {
+		return d
+	}
+	p := d.plan.clone()
+	node.ID = p.nextID(node.Op)
+	p.nodes = append(p.nodes, node)
+	p.edges = append(p.edges, []string{d.id, node.ID})
+	return &Dataset{plan: p, id: node.ID}
+}
+
+// Map - Aplica una UDF registrada (to_lower, to_json, ...) a cada linea
+func (d *Dataset) Map(fn string) *Dataset {
+	return d.then(common.DAGNode{Op: "map", Fn: fn})
+}
+
+// FlatMap - Aplica una UDF que genera 0..N lineas por entrada (tokenize)
+func (d *Dataset) FlatMap(fn string) *Dataset {
+	return d.then(common.DAGNode{Op: "flat_map", Fn: fn})
+}
+
+// Filter - Filtra lineas con un predicado registrado (long_words)
+func (d *Dataset) Filter(fn string) *Dataset {
+	return d.then(common.DAGNode{Op: "filter", Fn: fn})
+}
+
+// Where - Filtra filas por condiciones declarativas ("amount > 10")
+// Entrada: columns - esquema de las filas (nil para usar indices), conds - condiciones AND
+func (d *Dataset) Where(columns []string, conds ...string) *Dataset {
+	return d.then(common.DAGNode{Op: "filter", Columns: columns, Where: conds})
+}
+
+// Select - Proyecta/reordena columnas
+// Entrada: columns - esquema de las filas (nil para usar indices), cols - columnas a emitir
+func (d *Dataset) Select(columns []string, cols ...string) *Dataset {
+	return d.then(common.DAGNode{Op: "map", Columns: columns, Select: cols})
+}
+
+// ReduceByKey - Cuenta ocurrencias de cada linea (fn informativa, ej: "sum")
+func (d *Dataset) ReduceByKey(fn string) *Dataset {
+	return d.then(common.DAGNode{Op: "reduce_by_key", Fn: fn})
+}
+
+// AggregateByKey - Agrupa por columnas y calcula varios agregados
+// Entrada: columns - esquema opcional, groupBy - columnas clave, aggregates - "sum(x)", "count(*)"
+func (d *Dataset) AggregateByKey(columns, groupBy []string, aggregates ...string) *Dataset {
+	return d.then(common.DAGNode{Op: "aggregate_by_key", Columns: columns, GroupBy: groupBy, Aggregates: aggregates})
+}
+
+// TopNByKey - Emite las N primeras filas por clave segun orderBy
+// Entrada: columns - esquema opcional, partitionBy - clave, orderBy - "col" o "col desc", n - limite
+func (d *Dataset) TopNByKey(columns, partitionBy, orderBy []string, n int) *Dataset {
+	return d.then(common.DAGNode{Op: "top_n_by_key", Columns: columns, PartitionBy: partitionBy, OrderBy: orderBy, Limit: n})
+}
+
+// Window - Agrega una columna rank/dense_rank/row_number por particion
+func (d *Dataset) Window(fn string, columns, partitionBy, orderBy []string) *Dataset {
+	return d.then(common.DAGNode{Op: "window", Fn: fn, Columns: columns, PartitionBy: partitionBy, OrderBy: orderBy})
+}
+
+// Join - Inner join por primera columna con otro Dataset
+// Descripcion: Los nodos de other se incorporan a una copia de este plan
+//
+//	(renombrando IDs en conflicto y reutilizando el prefijo comun si
+//	ambos derivan del mismo Dataset). Este Dataset es el lado izquierdo.
+func (d *Dataset) Join(other *Dataset) *Dataset {
+	if d.err != nil {
+		return d
+	}
+	if other == nil {
+		return &Dataset{plan: d.plan, id: d.id, err: &ValidationError{Reason: "join con Dataset nil"}}
+	}
+	if other.err != nil {
+		return other
+	}
+	p := d.plan.clone()
+	rightID := p.absorb(other.plan)[other.id]
+	id := p.nextID("join")
+	p.nodes = append(p.nodes, common.DAGNode{ID: id, Op: "join"})
+	// Orden de aristas = orden de inputs: izquierda primero
+	p.edges = append(p.edges, []string{d.id, id}, []string{rightID, id})
+	return &Dataset{plan: p, id: id}
+}
+
+// Job - Genera el JobRequest validado del plan completo
+// Entrada: name - nombre del job, parallelism - particiones (minimo 1)
+// Salida: JobRequest o *ValidationError si el plan es invalido
+func (d *Dataset) Job(name string, parallelism int) (JobRequest, error) {
+	if d.err != nil {
+		return common.JobRequest{}, d.err
+	}
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	req := common.JobRequest{
+		Name:        name,
+		DAG:         common.DAG{Nodes: append([]common.DAGNode{}, d.plan.nodes...), Edges: append([][]string{}, d.plan.edges...)},
+		Parallelism: parallelism,
+	}
+	if err := common.ValidateJobRequest(req); err != nil {
+		return common.JobRequest{}, &ValidationError{Reason: err.Error()}
+	}
+	return req, nil
+}
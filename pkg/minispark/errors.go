@@ -0,0 +1,73 @@
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: errors.go
+Descripcion: Errores tipados del SDK de Mini-Spark.
+             Permiten a los servicios distinguir DAGs invalidos,
+             respuestas de error del Master, jobs inexistentes y jobs
+             fallidos usando errors.Is / errors.As.
+*/
+
+package minispark
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrJobNotFound el Master no conoce el job solicitado (HTTP 404)
+var ErrJobNotFound = errors.New("job no encontrado")
+
+// ErrWorkerNotFound el Master no conoce el worker solicitado (HTTP 404)
+var ErrWorkerNotFound = errors.New("worker no encontrado")
+
+// ValidationError el plan construido no es un DAG valido
+type ValidationError struct {
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return "DAG inválido: " + e.Reason
+}
+
+// Recursos a los que puede referirse un APIError
+const (
+	ResourceJob    = "job"    // Status, Events, Results, Cancel
+	ResourceWorker = "worker" // Worker, Decommission
+)
+
+// APIError respuesta no exitosa del Master
+type APIError struct {
+	StatusCode int    // Codigo HTTP
+	Message    string // Cuerpo de la respuesta
+	Resource   string // ResourceJob | ResourceWorker ("" si la peticion no apunta a uno)
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("error del Master (%d): %s", e.StatusCode, e.Message)
+}
+
+// Is - errors.Is(err, ErrJobNotFound) para un 404 de una peticion sobre un
+// job y errors.Is(err, ErrWorkerNotFound) para uno sobre un worker
+func (e *APIError) Is(target error) bool {
+	if e.StatusCode != 404 {
+		return false
+	}
+	switch target {
+	case ErrJobNotFound:
+		return e.Resource == ResourceJob
+	case ErrWorkerNotFound:
+		return e.Resource == ResourceWorker
+	}
+	return false
+}
+
+// JobFailedError el job termino en estado FAILED
+type JobFailedError struct {
+	JobID    string
+	Status   string
+	Failures int
+}
+
+func (e *JobFailedError) Error() string {
+	return fmt.Sprintf("job %s terminó en estado %s (%d fallos)", e.JobID, e.Status, e.Failures)
+}
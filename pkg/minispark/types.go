@@ -0,0 +1,58 @@
+/*
+Autores: Steven Sequeira Araya, Jefferson Salas Cordero
+Nombre del archivo: types.go
+Descripcion: Tipos publicos del SDK de Mini-Spark.
+             Alias de los tipos de internal/common que aparecen en la
+             API del cliente, para que codigo fuera de este modulo
+             pueda construir peticiones y leer respuestas.
+*/
+
+package minispark
+
+import "mini-spark/internal/common"
+
+// --- Definicion de jobs ---
+
+// JobRequest job a enviar (DAG, paralelismo, politicas)
+type JobRequest = common.JobRequest
+
+// DAG grafo de nodos y aristas de un job
+type DAG = common.DAG
+
+// DAGNode nodo (operador) del DAG
+type DAGNode = common.DAGNode
+
+// RetryPolicy politica de reintentos de un job o nodo
+type RetryPolicy = common.RetryPolicy
+
+// SQLRequest consulta SQL a compilar y ejecutar
+type SQLRequest = common.SQLRequest
+
+// TableDef tabla registrada para consultas SQL
+type TableDef = common.TableDef
+
+// --- Respuestas del Master ---
+
+// JobStatusResponse estado, progreso y metricas de un job
+type JobStatusResponse = common.JobStatusResponse
+
+// JobResultsResponse rutas de salida de los nodos finales
+type JobResultsResponse = common.JobResultsResponse
+
+// JobEventsResponse historial de eventos de un job
+type JobEventsResponse = common.JobEventsResponse
+
+// CancelJobResponse resultado de cancelar un job
+type CancelJobResponse = common.CancelJobResponse
+
+// WorkerStatusResponse estado de un worker
+type WorkerStatusResponse = common.WorkerStatusResponse
+
+// WorkerDetailResponse detalle de un worker (tareas e historial de metricas)
+type WorkerDetailResponse = common.WorkerDetailResponse
+
+// QueueStatusResponse cola de tareas pendientes del Master
+type QueueStatusResponse = common.QueueStatusResponse
+
+// ScalingStatusResponse señales y estado del escalado dinamico
+type ScalingStatusResponse = common.ScalingStatusResponse
@@ -10,10 +10,27 @@ Descripcion: Pruebas de integracion que validan pipelines completos.
 package tests
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mini-spark/internal/common"
+	"mini-spark/internal/master"
 	"mini-spark/internal/operators"
+	"mini-spark/internal/query"
+	"mini-spark/internal/tracing"
+	"mini-spark/internal/worker"
+	"mini-spark/pkg/minispark"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 // TestDataFlowIntegration - Prueba pipeline completo de word count
@@ -40,19 +57,19 @@ func TestDataFlowIntegration(t *testing.T) {
 	// 2. Ejecutar Pipeline (simulando workers secuenciales)
 
 	// Stage 1: FlatMap - Tokenizar texto en palabras
-	err := operators.FlatMap([]string{inputFile}, flatOut, "tokenize")
+	err := operators.FlatMap(context.Background(), []string{inputFile}, flatOut, "tokenize")
 	if err != nil {
 		t.Fatalf("FlatMap falló: %v", err)
 	}
 
 	// Stage 2: Map - Convertir palabras a minusculas
-	err = operators.Map([]string{flatOut}, mapOut, "to_lower")
+	err = operators.Map(context.Background(), []string{flatOut}, mapOut, "to_lower")
 	if err != nil {
 		t.Fatalf("Map falló: %v", err)
 	}
 
 	// Stage 3: ReduceByKey - Contar frecuencia de cada palabra
-	err = operators.ReduceByKey([]string{mapOut}, reduceOut)
+	err = operators.ReduceByKey(context.Background(), []string{mapOut}, reduceOut)
 	if err != nil {
 		t.Fatalf("Reduce falló: %v", err)
 	}
@@ -70,3 +87,2086 @@ func TestDataFlowIntegration(t *testing.T) {
 		t.Errorf("Falta 'world, 1'")
 	}
 }
+
+// runWorkerTask - Ejecuta una tarea en un Worker real con Master simulado
+// Entrada: t - objeto testing, task - tarea a ejecutar
+// Salida: TaskResult reportado por el Worker
+// Descripcion: Levanta un servidor httptest que recibe /task/complete
+//
+//	y ejecuta la tarea de forma sincrona en el Worker.
+func runWorkerTask(t *testing.T, task common.Task) common.TaskResult {
+	results := make(chan common.TaskResult, 1)
+	master := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var res common.TaskResult
+		json.NewDecoder(r.Body).Decode(&res)
+		results <- res
+	}))
+	defer master.Close()
+
+	wk := worker.NewWorker(0, master.URL, t.TempDir())
+	wk.ExecuteTask(task)
+	return <-results
+}
+
+// TestWorkerSpillOperators - Prueba operadores con spill/sort externo
+// Entrada: t - objeto testing
+// Salida: ninguna (void), reporta fallos via t.Error
+// Descripcion: Fuerza un SpillThreshold minimo para que aggregate_by_key
+//
+//	y top_n_by_key pasen por disco, y compara contra la version en memoria.
+//	Con MergeFanIn=2 los 4 runs se mezclan en dos pasadas.
+func TestWorkerSpillOperators(t *testing.T) {
+	oldThreshold, oldFanIn := worker.SpillThreshold, worker.MergeFanIn
+	worker.SpillThreshold = 2
+	defer func() { worker.SpillThreshold, worker.MergeFanIn = oldThreshold, oldFanIn }()
+
+	inputFile := createTempFile(t, "a,x,3\nb,y,1\na,z,7\nc,x,2\nb,w,5\na,q,7\nc,r,9")
+	defer os.Remove(inputFile)
+	columns := []string{"key", "name", "value"}
+
+	cases := []struct {
+		name  string
+		task  common.Task
+		fanIn int
+	}{
+		{
+			name: "aggregate_by_key con spill",
+			task: common.Task{Op: "aggregate_by_key", GroupBy: []string{"key"}, Aggregates: []string{"sum(value)", "count(*)", "max(name)"}},
+		},
+		{
+			name: "top_n_by_key con sort externo",
+			task: common.Task{Op: "top_n_by_key", PartitionBy: []string{"key"}, OrderBy: []string{"value desc"}, Limit: 2},
+		},
+		{
+			name: "window rank con sort externo",
+			task: common.Task{Op: "window", Fn: "rank", PartitionBy: []string{"key"}, OrderBy: []string{"value desc"}},
+		},
+		{
+			name:  "window row_number con merge multi-pasada",
+			task:  common.Task{Op: "window", Fn: "row_number", PartitionBy: []string{"key"}, OrderBy: []string{"value desc"}},
+			fanIn: 2,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			worker.MergeFanIn = oldFanIn
+			if tc.fanIn > 0 {
+				worker.MergeFanIn = tc.fanIn
+			}
+			task := tc.task
+			task.ID, task.JobID, task.NodeID = "t1", "job", strings.ReplaceAll(tc.name, " ", "_")
+			task.InputFiles = []string{inputFile}
+			task.Columns = columns
+
+			res := runWorkerTask(t, task)
+			if res.Status != "COMPLETED" {
+				t.Fatalf("Tarea fallo: %s", res.ErrorMsg)
+			}
+
+			// Resultado esperado: misma operacion completamente en memoria
+			expectedFile := inputFile + "_expected"
+			defer os.Remove(expectedFile)
+			var err error
+			if task.Op == "aggregate_by_key" {
+				err = operators.AggregateByKey(context.Background(), task.InputFiles, expectedFile, task.Op, task.GroupBy, task.Aggregates, columns)
+			} else {
+				spec, perr := operators.PrepareWindow(task.Op, task.Fn, task.PartitionBy, task.OrderBy, columns, task.Limit)
+				if perr != nil {
+					t.Fatal(perr)
+				}
+				err = operators.WindowByKey(context.Background(), task.InputFiles, expectedFile, spec)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got, want := readFile(t, res.Result), readFile(t, expectedFile); got != want {
+				t.Errorf("Resultado con spill difiere.\nEsperado:\n%s\nObtenido:\n%s", want, got)
+			}
+			if left, _ := filepath.Glob(res.Result + "*_run_*"); len(left) > 0 {
+				t.Errorf("Quedaron runs temporales: %v", left)
+			}
+		})
+	}
+}
+
+// runDAGLocally - Ejecuta un DAG nodo por nodo en un Worker local
+// Entrada: t - objeto testing, dag - grafo en orden topologico
+// Salida: mapa NodeID -> archivo de salida
+// Descripcion: Simula el rol del Master con una sola particion: cada nodo
+//
+//	recibe como entradas las salidas de sus padres (en orden de aristas).
+func runDAGLocally(t *testing.T, dag common.DAG) map[string]string {
+	outputs := make(map[string]string)
+	for _, node := range dag.Nodes {
+		var inputs []string
+		for _, e := range dag.Edges {
+			if e[1] == node.ID {
+				inputs = append(inputs, outputs[e[0]])
+			}
+		}
+		task := common.Task{
+			ID: node.ID, JobID: "sql", NodeID: node.ID, Op: node.Op, Fn: node.Fn,
+			Args: []string{node.Path}, InputFiles: inputs, TotalPartitions: 1,
+			Columns: node.Columns, GroupBy: node.GroupBy, Aggregates: node.Aggregates,
+			Where: node.Where, Select: node.Select,
+		}
+		res := runWorkerTask(t, task)
+		if res.Status != "COMPLETED" {
+			t.Fatalf("Nodo %s fallo: %s", node.ID, res.ErrorMsg)
+		}
+		outputs[node.ID] = res.Result
+	}
+	return outputs
+}
+
+// testMaster Master de pruebas cuyos workers falsos solo reciben tareas
+type testMaster struct {
+	*master.Master
+	stateFile string                    // Archivo de estado (en un directorio temporal)
+	tasks     chan sentTask             // Tareas enviadas a los workers falsos
+	cancels   chan common.CancelRequest // Cancelaciones enviadas a los workers falsos
+	t         *testing.T
+}
+
+// sentTask tarea recibida por un worker falso
+type sentTask struct {
+	common.Task
+	WorkerID string // Worker al que el Master envio la tarea
+}
+
+// newTestMaster - Master con workers falsos y SchedulerLoop corriendo
+// Entrada: t - objeto testing, slots/labels - de cada worker, workerIDs - workers a registrar
+// Salida: testMaster; las tareas enviadas se leen con nextTask
+// Descripcion: Desactiva la especulacion. Al terminar el test detiene
+//
+//	SchedulerLoop y cierra los servidores de los workers.
+func newTestMaster(t *testing.T, slots int, labels map[string]string, workerIDs ...string) *testMaster {
+	m := newIdleTestMaster(t)
+	for _, id := range workerIDs {
+		m.addWorker(id, slots, labels)
+	}
+	m.start()
+	return m
+}
+
+// newIdleTestMaster - Master de pruebas sin workers ni SchedulerLoop, para
+// configurar politicas o pools antes de llamar a start
+func newIdleTestMaster(t *testing.T) *testMaster {
+	stateFile := t.TempDir() + "/state.json"
+	m := &testMaster{Master: master.NewMaster(stateFile), stateFile: stateFile,
+		tasks: make(chan sentTask, 64), cancels: make(chan common.CancelRequest, 16), t: t}
+	m.Speculation.Enabled = false
+	return m
+}
+
+// start - Arranca SchedulerLoop; se detiene al terminar el test
+func (m *testMaster) start() {
+	go m.SchedulerLoop()
+	m.t.Cleanup(m.Stop)
+}
+
+// addWorker - Registra un worker falso que entrega cada tarea en m.tasks
+// y cada cancelacion en m.cancels
+func (m *testMaster) addWorker(id string, slots int, labels map[string]string) {
+	m.addWorkerWith(id, slots, labels, nil)
+}
+
+// addWorkerWith - Como addWorker, pero respond (si no es nil) escribe la
+// respuesta a cada tarea recibida, p. ej. un 429 de backpressure
+func (m *testMaster) addWorkerWith(id string, slots int, labels map[string]string, respond func(w http.ResponseWriter)) {
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/task/cancel" {
+			var req common.CancelRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			select {
+			case m.cancels <- req:
+			case <-done:
+			}
+			return
+		}
+		var task common.Task
+		json.NewDecoder(r.Body).Decode(&task)
+		select {
+		case m.tasks <- sentTask{Task: task, WorkerID: id}:
+		case <-done: // Test terminado: nadie lee m.tasks
+			return
+		}
+		if respond != nil {
+			respond(w)
+		}
+	}))
+	m.t.Cleanup(func() {
+		close(done)
+		srv.Close()
+	})
+	m.register(id, slots, labels, srv.URL)
+}
+
+// register - Registra un worker que atiende en url (worker real, servidor
+// propio del test o uno ya cerrado)
+func (m *testMaster) register(id string, slots int, labels map[string]string, url string) {
+	reg, _ := json.Marshal(common.RegisterRequest{ID: id, Port: 1, Slots: slots, ProtocolVersion: common.ProtocolVersion, Labels: labels})
+	m.RegisterHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/register", strings.NewReader(string(reg))))
+	m.Workers[id].URL = url
+}
+
+// nextTask - Siguiente tarea enviada a un worker falso (falla el test tras 2s)
+func (m *testMaster) nextTask() sentTask {
+	m.t.Helper()
+	select {
+	case task := <-m.tasks:
+		return task
+	case <-time.After(2 * time.Second):
+		m.t.Fatal("Timeout esperando tareas")
+	}
+	return sentTask{}
+}
+
+// TestSQLPipelineIntegration - Ejecuta una consulta SQL compilada de punta a punta
+// Entrada: t - objeto testing
+// Salida: ninguna (void), reporta fallos via t.Error
+// Descripcion: Compila SELECT ... JOIN ... WHERE ... GROUP BY y ejecuta el DAG
+//
+//	resultante con los operadores reales del Worker.
+func TestSQLPipelineIntegration(t *testing.T) {
+	users := createTempFile(t, "1,Carlos\n2,Maria\n3,Juan")
+	defer os.Remove(users)
+	purchases := createTempFile(t, "Laptop,1\nMouse,2\nMonitor,1\nTeclado,3\nCable,1")
+	defer os.Remove(purchases)
+
+	tables := map[string]common.TableDef{
+		"users":     {Name: "users", Path: users, Columns: []string{"id", "name"}},
+		"purchases": {Name: "purchases", Path: purchases, Columns: []string{"item", "user_id"}},
+	}
+	sql := "SELECT u.name, count(*) AS total FROM users u JOIN purchases p ON u.id = p.user_id WHERE p.item != 'Cable' AND u.id < 3 GROUP BY u.name"
+
+	req, err := query.CompileSQL(sql, tables, "sql-test", 1)
+	if err != nil {
+		t.Fatalf("Error compilando SQL: %v", err)
+	}
+	outputs := runDAGLocally(t, req.DAG)
+	final := outputs[req.DAG.Nodes[len(req.DAG.Nodes)-1].ID]
+
+	if got := readFile(t, final); got != "Carlos, 2\nMaria, 1" {
+		t.Errorf("Resultado SQL inesperado:\n%s", got)
+	}
+
+	// Claves repetidas del lado izquierdo: cada compra conserva su fila
+	req, err = query.CompileSQL("SELECT u.name, count(*) FROM purchases p JOIN users u ON u.id = p.user_id GROUP BY u.name", tables, "sql-dup", 1)
+	if err != nil {
+		t.Fatalf("Error compilando SQL: %v", err)
+	}
+	outputs = runDAGLocally(t, req.DAG)
+	if got := readFile(t, outputs[req.DAG.Nodes[len(req.DAG.Nodes)-1].ID]); got != "Carlos, 3\nJuan, 1\nMaria, 1" {
+		t.Errorf("Join con claves repetidas:\n%s", got)
+	}
+
+	// Agregados sobre columnas: la proyeccion usa la salida de aggregate,
+	// que ya no contiene la columna del argumento
+	orders := createTempFile(t, "Norte,10\nSur,5\nNorte,30\nSur,7\nNorte,20")
+	defer os.Remove(orders)
+	tables["orders"] = common.TableDef{Name: "orders", Path: orders, Columns: []string{"region", "amount"}}
+	aggregates := []struct {
+		sql      string
+		expected string
+	}{
+		{"SELECT region, sum(amount) AS total FROM orders GROUP BY region", "Norte, 60\nSur, 12"},
+		{"SELECT o.region, sum(o.amount) FROM orders o GROUP BY o.region", "Norte, 60\nSur, 12"},
+		{"SELECT region, min(amount), max(amount), avg(amount), count(amount) FROM orders GROUP BY region", "Norte, 10, 30, 20, 3\nSur, 5, 7, 6, 2"},
+		{"SELECT max(o.amount) AS top, o.region FROM orders o GROUP BY o.region", "30, Norte\n7, Sur"},
+	}
+	for _, tc := range aggregates {
+		req, err := query.CompileSQL(tc.sql, tables, "sql-agg", 1)
+		if err != nil {
+			t.Fatalf("Error compilando %q: %v", tc.sql, err)
+		}
+		outputs := runDAGLocally(t, req.DAG)
+		if got := readFile(t, outputs[req.DAG.Nodes[len(req.DAG.Nodes)-1].ID]); got != tc.expected {
+			t.Errorf("%s:\nesperado %q\nobtenido %q", tc.sql, tc.expected, got)
+		}
+	}
+}
+
+// TestSDKClientIntegration - Prueba el cliente del SDK contra un Master simulado
+// Entrada: t - objeto testing
+// Salida: ninguna (void), reporta fallos via t.Error
+// Descripcion: Verifica envio, polling hasta COMPLETED, transmision de
+//
+//	resultados por lineas y el mapeo de 404 de un job a ErrJobNotFound.
+func TestSDKClientIntegration(t *testing.T) {
+	polls := 0
+	master := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/jobs":
+			var req common.JobRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			json.NewEncoder(w).Encode(map[string]string{"job_id": "job-1", "status": "ACCEPTED"})
+		case r.URL.Path == "/api/v1/jobs/job-1":
+			polls++
+			status := "RUNNING"
+			if polls >= 2 {
+				status = "COMPLETED"
+			}
+			json.NewEncoder(w).Encode(common.JobStatusResponse{ID: "job-1", Status: status})
+		case r.URL.Path == "/api/v1/jobs/job-1/results/reduce_by_key_1":
+			w.Write([]byte("go, 2\nspark, 1\n"))
+		default:
+			http.Error(w, "Job no encontrado", http.StatusNotFound)
+		}
+	}))
+	defer master.Close()
+
+	ctx := context.Background()
+	client := minispark.NewClient(master.URL)
+	ds := minispark.ReadCSV("data/input.txt").FlatMap("tokenize").ReduceByKey("sum")
+	jobID, err := client.Run(ctx, ds, "wordcount", 1)
+	if err != nil || jobID != "job-1" {
+		t.Fatalf("Submit fallo: id=%q err=%v", jobID, err)
+	}
+
+	st, err := client.Wait(ctx, jobID, 10*time.Millisecond)
+	if err != nil || st.Status != "COMPLETED" {
+		t.Fatalf("Wait fallo: %+v err=%v", st, err)
+	}
+
+	var lines []string
+	err = client.StreamResults(ctx, jobID, "reduce_by_key_1", func(line string) error {
+		lines = append(lines, line)
+		return nil
+	})
+	if err != nil || strings.Join(lines, "|") != "go, 2|spark, 1" {
+		t.Errorf("StreamResults inesperado: %v err=%v", lines, err)
+	}
+
+	if _, err := client.Status(ctx, "otro"); !errors.Is(err, minispark.ErrJobNotFound) || errors.Is(err, minispark.ErrWorkerNotFound) {
+		t.Errorf("Se esperaba solo ErrJobNotFound, obtenido %v", err)
+	}
+	// Un 404 que no es de un job ni de un worker (ej: ruta desconocida)
+	if err := error(&minispark.APIError{StatusCode: 404}); errors.Is(err, minispark.ErrJobNotFound) || errors.Is(err, minispark.ErrWorkerNotFound) {
+		t.Errorf("404 sin recurso no deberia coincidir con ErrJobNotFound/ErrWorkerNotFound")
+	}
+}
+
+// TestStreamNodeOutput - Prueba la transmision de la salida de un nodo
+// Entrada: t - objeto testing
+// Salida: ninguna (void), reporta fallos via t.Error
+// Descripcion: Con todas las particiones disponibles se concatenan en
+//
+//	orden; si falta la salida de alguna particion o su archivo no existe
+//	responde 404 en lugar de un 200 con datos parciales.
+func TestStreamNodeOutput(t *testing.T) {
+	m := master.NewMaster(t.TempDir() + "/state.json")
+	job := &common.Job{ID: "j", Status: "COMPLETED", Parallelism: 2, Graph: common.DAG{
+		Nodes: []common.DAGNode{{ID: "read", Op: "read_csv", Path: "x.csv"}},
+	}}
+	m.Jobs["j"] = job
+	m.InitJobProgress(job)
+	part0 := createTempFile(t, "a\n")
+	part1 := createTempFile(t, "b\n")
+	stream := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		m.GetJobStatusHandler(rec, httptest.NewRequest(http.MethodGet, "/api/v1/jobs/j/results/read", nil))
+		return rec
+	}
+
+	m.JobPartitionOutputs["j"]["read"] = map[int]string{0: part0, 1: part1}
+	if rec := stream(); rec.Code != http.StatusOK || rec.Body.String() != "a\nb\n" {
+		t.Errorf("Salida completa: codigo %d, cuerpo %q", rec.Code, rec.Body.String())
+	}
+	m.JobPartitionOutputs["j"]["read"] = map[int]string{0: part0}
+	if rec := stream(); rec.Code != http.StatusNotFound {
+		t.Errorf("Particion sin salida: esperado 404, obtenido %d (%q)", rec.Code, rec.Body.String())
+	}
+	m.JobPartitionOutputs["j"]["read"] = map[int]string{0: part0, 1: part1 + ".borrado"}
+	if rec := stream(); rec.Code != http.StatusNotFound || strings.Contains(rec.Body.String(), "a\n") {
+		t.Errorf("Archivo inexistente: codigo %d, cuerpo %q", rec.Code, rec.Body.String())
+	}
+}
+
+// TestSchedulerRespectsWorkerSlots - Prueba que el scheduler no sature workers
+// Entrada: t - objeto testing
+// Salida: ninguna (void), reporta fallos via t.Error
+// Descripcion: Registra un worker simulado con 1 slot y encola 2 tareas:
+//
+//	la segunda debe quedar en cola hasta que la primera se reporte.
+func TestSchedulerRespectsWorkerSlots(t *testing.T) {
+	m := newTestMaster(t, 1, nil, "w1")
+	m.Pending.Push(common.Task{ID: "t1", JobID: "j", NodeID: "n", Attempt: 1}, 0)
+	m.Pending.Push(common.Task{ID: "t2", JobID: "j", NodeID: "n", PartitionID: 1, Attempt: 1}, 0)
+
+	first := m.nextTask()
+	select {
+	case task := <-m.tasks:
+		t.Fatalf("Tarea %s enviada a un worker sin slots libres", task.ID)
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	// Reportar la primera tarea libera el slot
+	res, _ := json.Marshal(common.TaskResult{ID: first.ID, JobID: "j", NodeID: "n", Status: "COMPLETED", Result: "out"})
+	m.CompleteTaskHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/task/complete", strings.NewReader(string(res))))
+
+	if task := m.nextTask(); task.ID == first.ID {
+		t.Errorf("Se reenvio la misma tarea %s", task.ID)
+	}
+}
+
+// TestSchedulerDataLocality - Prueba la colocacion de tareas junto a sus datos
+// Entrada: t - objeto testing
+// Salida: ninguna (void), reporta fallos via t.Error
+// Descripcion: Con dos workers simulados, cada particion del nodo hijo
+//
+//	debe ir al worker que produjo la particion del padre, y el estado
+//	del job debe reportar 100% de aciertos de localidad.
+func TestSchedulerDataLocality(t *testing.T) {
+	m := newIdleTestMaster(t)
+	// Round-robin alternaria workers; la localidad debe imponerse
+	m.Placement, _ = master.NewPlacementPolicy("round_robin", 0)
+	m.addWorker("w1", 4, nil)
+	m.addWorker("w2", 4, nil)
+	m.start()
+
+	job, _ := json.Marshal(common.JobRequest{
+		Name: "locality",
+		DAG: common.DAG{
+			Nodes: []common.DAGNode{{ID: "read", Op: "read_csv", Path: "x.csv"}, {ID: "lower", Op: "map", Fn: "to_lower"}},
+			Edges: [][]string{{"read", "lower"}},
+		},
+		Parallelism: 2,
+	})
+	rec := httptest.NewRecorder()
+	m.SubmitJobHandler(rec, httptest.NewRequest(http.MethodPost, "/api/v1/jobs", strings.NewReader(string(job))))
+	var submitted map[string]string
+	json.NewDecoder(rec.Body).Decode(&submitted)
+	jobID := submitted["job_id"]
+
+	complete := func(a sentTask) {
+		res, _ := json.Marshal(common.TaskResult{
+			ID: a.ID, JobID: a.JobID, NodeID: a.NodeID, PartitionID: a.PartitionID,
+			Status: "COMPLETED", Result: a.WorkerID + "_out", WorkerID: a.WorkerID,
+		})
+		m.CompleteTaskHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/task/complete", strings.NewReader(string(res))))
+	}
+
+	owners := make(map[int]string)
+	for i := 0; i < 2; i++ {
+		a := m.nextTask()
+		owners[a.PartitionID] = a.WorkerID
+		complete(a)
+	}
+	for i := 0; i < 2; i++ {
+		a := m.nextTask()
+		if a.WorkerID != owners[a.PartitionID] {
+			t.Errorf("Particion %d asignada a %s, datos en %s", a.PartitionID, a.WorkerID, owners[a.PartitionID])
+		}
+		if len(a.InputFiles) != 1 || a.InputFiles[0] != owners[a.PartitionID]+"_out" {
+			t.Errorf("Inputs inesperados: %v", a.InputFiles)
+		}
+		complete(a)
+	}
+
+	rec = httptest.NewRecorder()
+	m.GetJobStatusHandler(rec, httptest.NewRequest(http.MethodGet, "/api/v1/jobs/"+jobID, nil))
+	var st common.JobStatusResponse
+	json.NewDecoder(rec.Body).Decode(&st)
+	if st.Locality == nil || st.Locality.Hits != 2 || st.Locality.HitRate != 1 {
+		t.Errorf("Localidad inesperada: %+v", st.Locality)
+	}
+}
+
+// TestSpeculativeExecution - Prueba la ejecucion especulativa de rezagadas
+// Entrada: t - objeto testing
+// Salida: ninguna (void), reporta fallos via t.Error
+// Descripcion: Con 3 particiones, 2 terminan de inmediato y la tercera se
+//
+//	demora: el Master debe lanzar una copia en el otro worker, aceptar el
+//	primer resultado y responder 410 Gone al intento perdedor. El Worker
+//	real debe borrar su salida al recibir 410.
+func TestSpeculativeExecution(t *testing.T) {
+	m := newIdleTestMaster(t)
+	m.Speculation.Enabled = true
+	m.Speculation.MinRuntime = 0
+	m.addWorker("w1", 4, nil)
+	m.addWorker("w2", 4, nil)
+	m.start()
+
+	job, _ := json.Marshal(common.JobRequest{
+		Name:        "stragglers",
+		DAG:         common.DAG{Nodes: []common.DAGNode{{ID: "read", Op: "read_csv", Path: "x.csv"}}},
+		Parallelism: 3,
+	})
+	rec := httptest.NewRecorder()
+	m.SubmitJobHandler(rec, httptest.NewRequest(http.MethodPost, "/api/v1/jobs", strings.NewReader(string(job))))
+	var submitted map[string]string
+	json.NewDecoder(rec.Body).Decode(&submitted)
+	jobID := submitted["job_id"]
+
+	complete := func(a sentTask) int {
+		res, _ := json.Marshal(common.TaskResult{
+			ID: a.ID, JobID: a.JobID, NodeID: a.NodeID, PartitionID: a.PartitionID,
+			Status: "COMPLETED", Result: a.WorkerID + "_out",
+		})
+		rec := httptest.NewRecorder()
+		m.CompleteTaskHandler(rec, httptest.NewRequest(http.MethodPost, "/task/complete", strings.NewReader(string(res))))
+		return rec.Code
+	}
+
+	// Completar todas menos la particion 2 (rezagada)
+	var straggler sentTask
+	for i := 0; i < 3; i++ {
+		a := m.nextTask()
+		if a.PartitionID == 2 {
+			straggler = a
+			continue
+		}
+		complete(a)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	m.CheckStragglers()
+	spec := m.nextTask()
+	if !spec.Speculative || spec.PartitionID != 2 || spec.WorkerID == straggler.WorkerID {
+		t.Fatalf("Copia especulativa inesperada: worker=%s task=%+v", spec.WorkerID, spec.Task)
+	}
+
+	// La copia gana; el original es descartado con 410
+	if code := complete(spec); code != http.StatusOK {
+		t.Errorf("Copia especulativa: codigo %d", code)
+	}
+	if code := complete(straggler); code != http.StatusGone {
+		t.Errorf("Intento perdedor: esperado 410, obtenido %d", code)
+	}
+
+	rec = httptest.NewRecorder()
+	m.GetJobStatusHandler(rec, httptest.NewRequest(http.MethodGet, "/api/v1/jobs/"+jobID, nil))
+	var st common.JobStatusResponse
+	json.NewDecoder(rec.Body).Decode(&st)
+	if st.Status != "COMPLETED" || st.Speculation == nil || st.Speculation.Launched != 1 || st.Speculation.Won != 1 {
+		t.Errorf("Estado inesperado: %s %+v", st.Status, st.Speculation)
+	}
+
+	// Worker real: un 410 del Master elimina solo la salida del intento
+	// perdedor, nunca la del intento ganador de la misma particion
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ok.Close()
+	gone := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer gone.Close()
+	outDir := t.TempDir()
+	input := createTempFile(t, "a\nb")
+	worker.NewWorker(0, ok.URL, outDir).ExecuteTask(common.Task{ID: "retry", JobID: "j", NodeID: "read", Op: "read_csv", Args: []string{input}, Attempt: 2})
+	worker.NewWorker(0, gone.URL, outDir).ExecuteTask(common.Task{ID: "late", JobID: "j", NodeID: "read", Op: "read_csv", Args: []string{input}})
+	if _, err := os.Stat(outDir + "/j_read_part0_late.txt"); !os.IsNotExist(err) {
+		t.Errorf("La salida descartada no fue eliminada: %v", err)
+	}
+	if _, err := os.Stat(outDir + "/j_read_part0_retry.txt"); err != nil {
+		t.Errorf("El intento tardio borro la salida del ganador: %v", err)
+	}
+}
+
+// TestTaskTimeouts - Prueba la deteccion de tareas colgadas
+// Entrada: t - objeto testing
+// Salida: ninguna (void), reporta fallos via t.Error
+// Descripcion: Una tarea que nunca reporta debe cancelarse en su worker,
+//
+//	reintentarse en el otro worker y, agotados los reintentos, marcar el
+//	job como FAILED. Un worker real responde 404 al cancelar una tarea
+//	desconocida.
+func TestTaskTimeouts(t *testing.T) {
+	m := newIdleTestMaster(t)
+	m.TaskTimeout = 20 * time.Millisecond
+	m.addWorker("w1", 4, nil)
+	m.addWorker("w2", 4, nil)
+	m.start()
+
+	job, _ := json.Marshal(common.JobRequest{
+		Name:        "hung",
+		DAG:         common.DAG{Nodes: []common.DAGNode{{ID: "read", Op: "read_csv", Path: "x.csv"}}},
+		Parallelism: 1,
+	})
+	rec := httptest.NewRecorder()
+	m.SubmitJobHandler(rec, httptest.NewRequest(http.MethodPost, "/api/v1/jobs", strings.NewReader(string(job))))
+	var submitted map[string]string
+	json.NewDecoder(rec.Body).Decode(&submitted)
+
+	prev := m.nextTask()
+	for attempt := 2; attempt <= common.MaxRetries; attempt++ {
+		time.Sleep(30 * time.Millisecond)
+		m.CheckTimeouts()
+		a := m.nextTask()
+		if a.Attempt != attempt || a.WorkerID == prev.WorkerID {
+			t.Errorf("Reintento %d inesperado: worker=%s (anterior %s) attempt=%d", attempt, a.WorkerID, prev.WorkerID, a.Attempt)
+		}
+		prev = a
+	}
+	time.Sleep(30 * time.Millisecond)
+	m.CheckTimeouts()
+
+	rec = httptest.NewRecorder()
+	m.GetJobStatusHandler(rec, httptest.NewRequest(http.MethodGet, "/api/v1/jobs/"+submitted["job_id"], nil))
+	var st common.JobStatusResponse
+	json.NewDecoder(rec.Body).Decode(&st)
+	if st.Status != "FAILED" || st.Failures != common.MaxRetries {
+		t.Errorf("Se esperaba FAILED con %d fallos, obtenido %s/%d", common.MaxRetries, st.Status, st.Failures)
+	}
+
+	// El ultimo intento cancelado termina despues: su exito no revive el job
+	late, _ := json.Marshal(common.TaskResult{ID: prev.ID, JobID: prev.JobID, NodeID: prev.NodeID, PartitionID: prev.PartitionID,
+		Status: "COMPLETED", Result: "out.txt", WorkerID: prev.WorkerID, Attempt: prev.Attempt})
+	rec = httptest.NewRecorder()
+	m.CompleteTaskHandler(rec, httptest.NewRequest(http.MethodPost, "/task/complete", strings.NewReader(string(late))))
+	if rec.Code != http.StatusGone {
+		t.Errorf("Exito tardio de un job fallido: esperado 410, obtenido %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	m.GetJobStatusHandler(rec, httptest.NewRequest(http.MethodGet, "/api/v1/jobs/"+submitted["job_id"]+"/events", nil))
+	var history common.JobEventsResponse
+	json.NewDecoder(rec.Body).Decode(&history)
+	if last := history.Events[len(history.Events)-1]; history.Status != "FAILED" || last.Type != common.EventJobFailed {
+		t.Errorf("El job deberia seguir FAILED, obtenido %s (ultimo evento %s)", history.Status, last.Type)
+	}
+
+	wk := worker.NewWorker(0, "http://localhost:0", t.TempDir())
+	body, _ := json.Marshal(common.CancelRequest{TaskID: "desconocida", Reason: "timeout"})
+	rec = httptest.NewRecorder()
+	wk.CancelHandler(rec, httptest.NewRequest(http.MethodPost, "/task/cancel", strings.NewReader(string(body))))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Cancelar tarea desconocida: esperado 404, obtenido %d", rec.Code)
+	}
+}
+
+// TestRetryPolicy - Prueba la politica de reintentos y la clasificacion de fallos
+// Entrada: t - objeto testing
+// Salida: ninguna (void), reporta fallos via t.Error
+// Descripcion: Verifica el backoff exponencial con tope, que un fallo
+//
+//	RETRYABLE se reencola tras la espera en otro worker, que un fallo
+//	FATAL marca el job FAILED de inmediato con su causa y cancela sus
+//	demas tareas, y que el Worker clasifica una operacion desconocida
+//	como FATAL.
+func TestRetryPolicy(t *testing.T) {
+	policy := common.RetryPolicy{MaxAttempts: 5, BackoffMs: 100, MaxBackoffMs: 300}
+	for attempt, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 300 * time.Millisecond, 4: 300 * time.Millisecond} {
+		// Jitter por defecto: +-20%
+		if got := master.RetryDelay(policy, attempt); got < want*8/10 || got > want*12/10 {
+			t.Errorf("RetryDelay(%d) = %s, esperado %s +-20%%", attempt, got, want)
+		}
+	}
+	policy.Jitter = 0.5
+	if d := master.RetryDelay(policy, 1); d < 50*time.Millisecond || d > 150*time.Millisecond {
+		t.Errorf("RetryDelay con jitter fuera de rango: %s", d)
+	}
+
+	m := newTestMaster(t, 4, nil, "w1", "w2")
+
+	submit := func(retry *common.RetryPolicy, parallelism int) string {
+		job, _ := json.Marshal(common.JobRequest{
+			Name:        "retry",
+			DAG:         common.DAG{Nodes: []common.DAGNode{{ID: "read", Op: "read_csv", Path: "x.csv"}}},
+			Parallelism: parallelism,
+			Retry:       retry,
+		})
+		rec := httptest.NewRecorder()
+		m.SubmitJobHandler(rec, httptest.NewRequest(http.MethodPost, "/api/v1/jobs", strings.NewReader(string(job))))
+		var submitted map[string]string
+		json.NewDecoder(rec.Body).Decode(&submitted)
+		return submitted["job_id"]
+	}
+	fail := func(task sentTask, class string) {
+		res, _ := json.Marshal(common.TaskResult{ID: task.ID, JobID: task.JobID, NodeID: task.NodeID, PartitionID: task.PartitionID,
+			Status: "FAILED", ErrorMsg: "boom", ErrorClass: class})
+		m.CompleteTaskHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/task/complete", strings.NewReader(string(res))))
+	}
+	status := func(jobID string) common.JobStatusResponse {
+		rec := httptest.NewRecorder()
+		m.GetJobStatusHandler(rec, httptest.NewRequest(http.MethodGet, "/api/v1/jobs/"+jobID, nil))
+		var st common.JobStatusResponse
+		json.NewDecoder(rec.Body).Decode(&st)
+		return st
+	}
+
+	// RETRYABLE: se reencola tras el backoff, excluyendo el worker que fallo
+	retryJob := submit(&common.RetryPolicy{MaxAttempts: 2, BackoffMs: 150}, 1)
+	first := m.nextTask()
+	start := time.Now()
+	fail(first, common.ErrorClassRetryable)
+	second := m.nextTask()
+	if waited := time.Since(start); waited < 100*time.Millisecond {
+		t.Errorf("Reintento sin backoff: %s", waited)
+	}
+	if second.Attempt != 2 || second.Retry.MaxAttempts != 2 || len(second.ExcludedWorkers) != 1 {
+		t.Errorf("Reintento inesperado: %+v", second)
+	}
+	// La espera en cola del reintento no incluye el backoff
+	if second.QueuedAt.Sub(start) < 100*time.Millisecond {
+		t.Errorf("QueuedAt del reintento no se reinicio tras el backoff: %s", second.QueuedAt.Sub(start))
+	}
+	fail(second, common.ErrorClassRetryable)
+	if st := status(retryJob); st.Status != "FAILED" || !strings.Contains(st.Error, "2 intentos agotados") {
+		t.Errorf("Reintentos agotados: estado %s, error %q", st.Status, st.Error)
+	}
+
+	// FATAL: el job falla de inmediato con la causa raiz y su otra
+	// particion en ejecucion se cancela en su worker
+	fatalJob := submit(nil, 2)
+	failed, other := m.nextTask(), m.nextTask()
+	fail(failed, common.ErrorClassFatal)
+	if st := status(fatalJob); st.Status != "FAILED" || st.Failures != 1 || !strings.Contains(st.Error, "boom") {
+		t.Errorf("Fallo FATAL: estado %s, fallos %d, error %q", st.Status, st.Failures, st.Error)
+	}
+	select {
+	case req := <-m.cancels:
+		if req.TaskID != other.ID {
+			t.Errorf("Se cancelo la tarea %s, esperada %s", req.TaskID, other.ID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("La tarea restante del job fallido no se cancelo")
+	}
+	// La particion cancelada termina igual: su exito no sobrescribe el fallo
+	done, _ := json.Marshal(common.TaskResult{ID: other.ID, JobID: other.JobID, NodeID: other.NodeID, PartitionID: other.PartitionID,
+		Status: "COMPLETED", Result: "out.txt", Attempt: other.Attempt})
+	rec := httptest.NewRecorder()
+	m.CompleteTaskHandler(rec, httptest.NewRequest(http.MethodPost, "/task/complete", strings.NewReader(string(done))))
+	if st := status(fatalJob); rec.Code != http.StatusGone || st.Status != "FAILED" {
+		t.Errorf("Exito tardio tras fallo FATAL: HTTP %d, estado %s", rec.Code, st.Status)
+	}
+	select {
+	case task := <-m.tasks:
+		t.Errorf("Tarea FATAL reintentada: %+v", task)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// Worker real: una operacion desconocida se reporta como FATAL
+	reported := make(chan common.TaskResult, 1)
+	fake := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var res common.TaskResult
+		json.NewDecoder(r.Body).Decode(&res)
+		reported <- res
+	}))
+	defer fake.Close()
+	wk := worker.NewWorker(0, fake.URL, t.TempDir())
+	wk.ExecuteTask(common.Task{ID: "t", JobID: "j", NodeID: "n", Op: "desconocida"})
+	if res := <-reported; res.Status != "FAILED" || res.ErrorClass != common.ErrorClassFatal {
+		t.Errorf("Clasificacion inesperada: %s/%s", res.Status, res.ErrorClass)
+	}
+}
+
+// TestWorkerBlacklist - Prueba la exclusion de workers con fallos repetidos
+// Entrada: t - objeto testing
+// Salida: ninguna (void), reporta fallos via t.Error
+// Descripcion: Dos fallos de w1 en un job lo excluyen de ese job; un
+//
+//	tercer fallo (en otro job) lo excluye del cluster hasta que vence
+//	el enfriamiento. Las exclusiones aparecen en GET /api/v1/workers.
+func TestWorkerBlacklist(t *testing.T) {
+	m := newIdleTestMaster(t)
+	m.Placement = &master.RoundRobinPolicy{}
+	m.Blacklist = master.BlacklistConfig{Enabled: true, MaxFailures: 3, MaxPerJob: 2, Cooldown: 400 * time.Millisecond}
+	m.addWorker("w1", 16, nil)
+	m.addWorker("w2", 16, nil)
+	m.start()
+
+	submit := func(parallelism int) string {
+		job, _ := json.Marshal(common.JobRequest{
+			Name:        "blacklist",
+			DAG:         common.DAG{Nodes: []common.DAGNode{{ID: "read", Op: "read_csv", Path: "x.csv"}}},
+			Parallelism: parallelism,
+			Retry:       &common.RetryPolicy{MaxAttempts: 5, BackoffMs: 1},
+		})
+		rec := httptest.NewRecorder()
+		m.SubmitJobHandler(rec, httptest.NewRequest(http.MethodPost, "/api/v1/jobs", strings.NewReader(string(job))))
+		var submitted map[string]string
+		json.NewDecoder(rec.Body).Decode(&submitted)
+		return submitted["job_id"]
+	}
+	// Recibir n tareas y separar las enviadas a w1
+	collect := func(n int) (onW1 []common.Task, workers map[string]int) {
+		workers = make(map[string]int)
+		for i := 0; i < n; i++ {
+			a := m.nextTask()
+			workers[a.WorkerID]++
+			if a.WorkerID == "w1" {
+				onW1 = append(onW1, a.Task)
+			}
+		}
+		return onW1, workers
+	}
+	fail := func(task common.Task) {
+		res, _ := json.Marshal(common.TaskResult{ID: task.ID, JobID: task.JobID, NodeID: task.NodeID, PartitionID: task.PartitionID,
+			Status: "FAILED", ErrorMsg: "disco lleno", ErrorClass: common.ErrorClassRetryable})
+		m.CompleteTaskHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/task/complete", strings.NewReader(string(res))))
+	}
+	listWorkers := func() map[string]common.WorkerStatusResponse {
+		rec := httptest.NewRecorder()
+		m.ListWorkersHandler(rec, httptest.NewRequest(http.MethodGet, "/api/v1/workers", nil))
+		var list []common.WorkerStatusResponse
+		json.NewDecoder(rec.Body).Decode(&list)
+		byID := make(map[string]common.WorkerStatusResponse)
+		for _, ws := range list {
+			byID[ws.ID] = ws
+		}
+		return byID
+	}
+
+	// Job A: dos fallos en w1 lo excluyen solo de A
+	jobA := submit(4)
+	onW1, _ := collect(4)
+	if len(onW1) < 2 {
+		t.Fatalf("Se esperaban al menos 2 tareas en w1, obtenidas %d", len(onW1))
+	}
+	fail(onW1[0])
+	fail(onW1[1])
+	if _, workers := collect(2); workers["w1"] != 0 {
+		t.Errorf("Reintentos de A enviados a w1 excluido: %v", workers)
+	}
+	ws := listWorkers()
+	if len(ws["w1"].BlacklistedJobs) != 1 || ws["w1"].BlacklistedJobs[0] != jobA || ws["w1"].BlacklistedUntil != nil || ws["w1"].Failures != 2 {
+		t.Errorf("Exclusion por job inesperada: %+v", ws["w1"])
+	}
+
+	// Job B: w1 sigue disponible para otros jobs; un tercer fallo lo excluye del cluster
+	submit(4)
+	onW1, _ = collect(4)
+	if len(onW1) == 0 {
+		t.Fatal("w1 no recibio tareas de otro job")
+	}
+	fail(onW1[0])
+	collect(1)
+	if ws := listWorkers(); ws["w1"].BlacklistedUntil == nil || ws["w2"].BlacklistedUntil != nil {
+		t.Errorf("Exclusion del cluster inesperada: w1=%+v w2=%+v", ws["w1"], ws["w2"])
+	}
+	submit(2)
+	if _, workers := collect(2); workers["w1"] != 0 {
+		t.Errorf("Tareas enviadas a w1 excluido del cluster: %v", workers)
+	}
+
+	// Tras el enfriamiento w1 vuelve a recibir tareas
+	time.Sleep(450 * time.Millisecond)
+	submit(2)
+	if _, workers := collect(2); workers["w1"] == 0 {
+		t.Errorf("w1 no volvio a recibir tareas tras el enfriamiento: %v", workers)
+	}
+	if ws := listWorkers(); ws["w1"].BlacklistedUntil != nil {
+		t.Errorf("Exclusion vencida aun listada: %+v", ws["w1"])
+	}
+}
+
+// TestFairScheduling - Prueba el reparto justo de slots entre jobs
+// Entrada: t - objeto testing
+// Salida: ninguna (void), reporta fallos via t.Error
+// Descripcion: Un job pequeño recibe el siguiente slot libre aunque un job
+//
+//	grande llego antes; los pools reparten slots segun su peso y, dentro
+//	de un pool, el job de mayor prioridad se planifica primero.
+func TestFairScheduling(t *testing.T) {
+	if _, err := master.ParsePools("interactive=3, batch=1"); err != nil {
+		t.Errorf("ParsePools valido: %v", err)
+	}
+	for _, spec := range []string{"batch", "batch=0", "=2", "batch=x"} {
+		if _, err := master.ParsePools(spec); err == nil {
+			t.Errorf("ParsePools(%q) deberia fallar", spec)
+		}
+	}
+
+	// newCluster - Master con un unico worker de slots fijos
+	newCluster := func(slots int, pools string) *testMaster {
+		m := newIdleTestMaster(t)
+		m.Pools, _ = master.ParsePools(pools)
+		m.addWorker("w1", slots, nil)
+		m.start()
+		return m
+	}
+	submit := func(m *testMaster, pool string, priority, parallelism int) (string, int) {
+		job, _ := json.Marshal(common.JobRequest{
+			Name:        "fair",
+			DAG:         common.DAG{Nodes: []common.DAGNode{{ID: "read", Op: "read_csv", Path: "x.csv"}}},
+			Parallelism: parallelism,
+			Pool:        pool,
+			Priority:    priority,
+		})
+		rec := httptest.NewRecorder()
+		m.SubmitJobHandler(rec, httptest.NewRequest(http.MethodPost, "/api/v1/jobs", strings.NewReader(string(job))))
+		var submitted map[string]string
+		json.NewDecoder(rec.Body).Decode(&submitted)
+		// Dar tiempo a que las tareas lleguen al scheduler
+		time.Sleep(100 * time.Millisecond)
+		return submitted["job_id"], rec.Code
+	}
+	complete := func(m *testMaster, task sentTask) {
+		res, _ := json.Marshal(common.TaskResult{ID: task.ID, JobID: task.JobID, NodeID: task.NodeID, PartitionID: task.PartitionID,
+			Status: "COMPLETED", Result: "out.txt"})
+		m.CompleteTaskHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/task/complete", strings.NewReader(string(res))))
+	}
+
+	// 1. Un job grande no acapara el cluster frente a uno pequeño posterior
+	m := newCluster(2, "")
+	big, _ := submit(m, "", 0, 6)
+	running := []sentTask{m.nextTask(), m.nextTask()}
+	small, _ := submit(m, "", 0, 2)
+	complete(m, running[0])
+	if task := m.nextTask(); task.JobID != small {
+		t.Errorf("El slot libre fue al job grande (%s) en vez del pequeño", big)
+	}
+	if _, code := submit(m, "inexistente", 0, 1); code != http.StatusBadRequest {
+		t.Errorf("Pool desconocido: esperado 400, obtenido %d", code)
+	}
+
+	// 2. Pools con peso: interactive=3 obtiene 3 de cada 4 slots frente a batch=1
+	m = newCluster(4, "interactive=3,batch=1")
+	batch, _ := submit(m, "batch", 0, 8)
+	var batchRunning, interRunning []sentTask
+	for i := 0; i < 4; i++ {
+		batchRunning = append(batchRunning, m.nextTask())
+	}
+	inter, _ := submit(m, "interactive", 0, 8)
+	for i := 0; i < 3; i++ {
+		complete(m, batchRunning[i])
+		task := m.nextTask()
+		if task.JobID != inter {
+			t.Fatalf("Slot %d: esperado pool interactive, obtenido job %s", i, task.JobID)
+		}
+		interRunning = append(interRunning, task)
+	}
+	complete(m, interRunning[0])
+	if task := m.nextTask(); task.JobID != inter {
+		t.Errorf("Con 1 batch y 2 interactive el slot debia ir a interactive")
+	}
+	complete(m, batchRunning[3])
+	if task := m.nextTask(); task.JobID != batch {
+		t.Errorf("Con 0 batch y 3 interactive el slot debia ir a batch")
+	}
+
+	// 3. Prioridad dentro del pool
+	m = newCluster(1, "")
+	low, _ := submit(m, "", 0, 3)
+	first := m.nextTask()
+	submit(m, "", 0, 1)
+	high, _ := submit(m, "", 10, 1)
+	complete(m, first)
+	if task := m.nextTask(); task.JobID != high {
+		t.Errorf("Se esperaba el job de prioridad 10, obtenido %s (job inicial %s)", task.JobID, low)
+	}
+
+	rec := httptest.NewRecorder()
+	m.GetJobStatusHandler(rec, httptest.NewRequest(http.MethodGet, "/api/v1/jobs/"+high, nil))
+	var st common.JobStatusResponse
+	json.NewDecoder(rec.Body).Decode(&st)
+	if st.Pool != common.DefaultPool || st.Priority != 10 {
+		t.Errorf("Estado sin pool/prioridad: %s/%d", st.Pool, st.Priority)
+	}
+}
+
+// TestPendingQueue - Prueba la cola de tareas pendientes y la cancelacion de jobs
+// Entrada: t - objeto testing
+// Salida: ninguna (void), reporta fallos via t.Error
+// Descripcion: La cola ordena por prioridad y llegada; un job con mas de
+//
+//	100 particiones listas se encola sin bloquear al Master aunque no
+//	haya workers; cancelar un job vacia sus tareas de la cola, cancela
+//	las que estan en ejecucion y descarta sus resultados tardios.
+func TestPendingQueue(t *testing.T) {
+	q := master.NewPendingQueue()
+	q.Push(common.Task{ID: "a", JobID: "j1"}, 0)
+	q.Push(common.Task{ID: "b", JobID: "j2"}, 5)
+	q.Push(common.Task{ID: "c", JobID: "j1"}, 0)
+	q.Push(common.Task{ID: "d", JobID: "j2"}, 5)
+	var order []string
+	for _, task := range q.Tasks() {
+		order = append(order, task.ID)
+	}
+	if strings.Join(order, ",") != "b,d,a,c" {
+		t.Errorf("Orden de la cola inesperado: %v", order)
+	}
+	if n := q.RemoveJob("j2"); n != 2 || q.Len() != 2 {
+		t.Errorf("RemoveJob quito %d, quedan %d", n, q.Len())
+	}
+
+	m := newTestMaster(t, 1, nil)
+
+	submit := func(parallelism int) string {
+		job, _ := json.Marshal(common.JobRequest{
+			Name:        "queue",
+			DAG:         common.DAG{Nodes: []common.DAGNode{{ID: "read", Op: "read_csv", Path: "x.csv"}}},
+			Parallelism: parallelism,
+		})
+		rec := httptest.NewRecorder()
+		m.SubmitJobHandler(rec, httptest.NewRequest(http.MethodPost, "/api/v1/jobs", strings.NewReader(string(job))))
+		var submitted map[string]string
+		json.NewDecoder(rec.Body).Decode(&submitted)
+		return submitted["job_id"]
+	}
+	queueView := func() common.QueueStatusResponse {
+		rec := httptest.NewRecorder()
+		m.QueueHandler(rec, httptest.NewRequest(http.MethodGet, "/api/v1/queue", nil))
+		var view common.QueueStatusResponse
+		json.NewDecoder(rec.Body).Decode(&view)
+		return view
+	}
+	cancel := func(jobID string) (*httptest.ResponseRecorder, common.CancelJobResponse) {
+		rec := httptest.NewRecorder()
+		m.GetJobStatusHandler(rec, httptest.NewRequest(http.MethodPost, "/api/v1/jobs/"+jobID+"/cancel", nil))
+		var res common.CancelJobResponse
+		json.NewDecoder(rec.Body).Decode(&res)
+		return rec, res
+	}
+
+	// Sin workers: 250 tareas quedan en cola sin bloquear al Master
+	big := submit(250)
+	deadline := time.Now().Add(2 * time.Second)
+	for queueView().Length < 250 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if view := queueView(); view.Length != 250 || view.ByJob[big] != 250 || view.Tasks[0].Pool != common.DefaultPool {
+		t.Fatalf("Cola inesperada: length=%d by_job=%v", view.Length, view.ByJob)
+	}
+	if rec, res := cancel(big); rec.Code != http.StatusOK || res.RemovedPending != 250 || res.Status != "CANCELLED" {
+		t.Errorf("Cancelar job en cola: codigo %d, %+v", rec.Code, res)
+	}
+	if view := queueView(); view.Length != 0 {
+		t.Errorf("La cola conserva %d tareas del job cancelado", view.Length)
+	}
+	if rec, _ := cancel(big); rec.Code != http.StatusConflict {
+		t.Errorf("Cancelar dos veces: esperado 409, obtenido %d", rec.Code)
+	}
+
+	// Con un worker de 1 slot: una tarea corre y otra espera
+	m.addWorker("w1", 1, nil)
+
+	small := submit(2)
+	running := m.nextTask()
+	time.Sleep(100 * time.Millisecond)
+	if rec, res := cancel(small); rec.Code != http.StatusOK || res.RemovedPending != 1 || res.CancelledRunning != 1 {
+		t.Errorf("Cancelar job en ejecucion: codigo %d, %+v", rec.Code, res)
+	}
+	select {
+	case req := <-m.cancels:
+		if req.TaskID != running.ID {
+			t.Errorf("Se cancelo la tarea %s, esperada %s", req.TaskID, running.ID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("El worker no recibio la cancelacion")
+	}
+
+	// El resultado tardio de la tarea cancelada se descarta
+	res, _ := json.Marshal(common.TaskResult{ID: running.ID, JobID: small, NodeID: "read", PartitionID: running.PartitionID, Status: "COMPLETED", Result: "out"})
+	rec := httptest.NewRecorder()
+	m.CompleteTaskHandler(rec, httptest.NewRequest(http.MethodPost, "/task/complete", strings.NewReader(string(res))))
+	if rec.Code != http.StatusGone {
+		t.Errorf("Resultado de job cancelado: esperado 410, obtenido %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	m.GetJobStatusHandler(rec, httptest.NewRequest(http.MethodGet, "/api/v1/jobs/"+small, nil))
+	var st common.JobStatusResponse
+	json.NewDecoder(rec.Body).Decode(&st)
+	if st.Status != "CANCELLED" {
+		t.Errorf("Estado esperado CANCELLED, obtenido %s", st.Status)
+	}
+}
+
+// TestWorkerDownRequeue - Prueba el reencolado de tareas de un worker caido
+// Entrada: t - objeto testing
+// Salida: ninguna (void), reporta fallos via t.Error
+// Descripcion: Un fallo de envio reencola solo esa tarea y no marca DOWN
+//
+//	al worker. Al caer un worker sus intentos se cancelan en el y un
+//	FAILED tardio de uno de ellos no falla el job.
+func TestWorkerDownRequeue(t *testing.T) {
+	m := newTestMaster(t, 4, nil, "w1")
+
+	job, _ := json.Marshal(common.JobRequest{Name: "down", Parallelism: 2,
+		DAG: common.DAG{Nodes: []common.DAGNode{{ID: "read", Op: "read_csv", Path: "x.csv"}}}})
+	rec := httptest.NewRecorder()
+	m.SubmitJobHandler(rec, httptest.NewRequest(http.MethodPost, "/api/v1/jobs", strings.NewReader(string(job))))
+	var submitted map[string]string
+	json.NewDecoder(rec.Body).Decode(&submitted)
+	old := map[string]bool{}
+	for len(old) < 2 {
+		old[m.nextTask().ID] = true
+	}
+
+	// Worker inalcanzable: recibira las tareas reencoladas y fallara el envio
+	closed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	closed.Close()
+	m.register("w2", 4, nil, closed.URL)
+
+	// w1 se retira con sus dos tareas en curso
+	dereg, _ := json.Marshal(common.DeregisterRequest{ID: "w1"})
+	m.DeregisterHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/deregister", strings.NewReader(string(dereg))))
+	for i := 0; i < 2; i++ {
+		select {
+		case req := <-m.cancels:
+			if !old[req.TaskID] {
+				t.Errorf("Cancelacion de tarea inesperada: %s", req.TaskID)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("El worker caido no recibio la cancelacion de sus intentos")
+		}
+	}
+
+	// El fallo de envio a w2 no lo marca DOWN
+	time.Sleep(200 * time.Millisecond)
+	rec = httptest.NewRecorder()
+	m.WorkerHandler(rec, httptest.NewRequest(http.MethodGet, "/api/v1/workers/w2", nil))
+	var ws common.WorkerStatusResponse
+	json.NewDecoder(rec.Body).Decode(&ws)
+	if ws.Status != "UP" {
+		t.Errorf("Un fallo de envio marco el worker como %s", ws.Status)
+	}
+
+	// FAILED tardio de un intento del worker caido: se ignora
+	for id := range old {
+		res, _ := json.Marshal(common.TaskResult{ID: id, JobID: submitted["job_id"], NodeID: "read", Status: "FAILED", ErrorMsg: "tarde", ErrorClass: common.ErrorClassFatal})
+		m.CompleteTaskHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/task/complete", strings.NewReader(string(res))))
+		break
+	}
+	rec = httptest.NewRecorder()
+	m.GetJobStatusHandler(rec, httptest.NewRequest(http.MethodGet, "/api/v1/jobs/"+submitted["job_id"], nil))
+	var st common.JobStatusResponse
+	json.NewDecoder(rec.Body).Decode(&st)
+	if st.Status != "RUNNING" {
+		t.Errorf("Estado esperado RUNNING, obtenido %s", st.Status)
+	}
+}
+
+// TestWorkerBackpressure - Prueba el rechazo de tareas por falta de slots
+// Entrada: t - objeto testing
+// Salida: ninguna (void), reporta fallos via t.Error
+// Descripcion: Un worker real con 1 slot ocupado responde 429 con su
+//
+//	capacidad; el Master trata el 429 como backpressure: actualiza los
+//	slots del worker, deja de enviarle tareas por un momento y reencola
+//	la tarea en otro worker.
+func TestWorkerBackpressure(t *testing.T) {
+	// Worker real con 1 slot: el reporte al "Master" queda bloqueado y retiene el slot
+	release := make(chan struct{})
+	blocking := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer blocking.Close()
+	defer close(release)
+	wk := worker.NewWorker(0, blocking.URL, t.TempDir())
+	wk.SetSlots(1)
+	input := createTempFile(t, "a\nb")
+	send := func(id string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(common.Task{ID: id, JobID: "j", NodeID: "read", Op: "read_csv", Args: []string{input}})
+		rec := httptest.NewRecorder()
+		wk.TaskHandler(rec, httptest.NewRequest(http.MethodPost, "/task", strings.NewReader(string(body))))
+		return rec
+	}
+	if rec := send("t1"); rec.Code != http.StatusOK {
+		t.Fatalf("Primera tarea: esperado 200, obtenido %d", rec.Code)
+	}
+	rec := send("t2")
+	var busy common.BusyResponse
+	json.NewDecoder(rec.Body).Decode(&busy)
+	if rec.Code != http.StatusTooManyRequests || busy.Slots != 1 || busy.ActiveTasks != 1 || busy.RetryAfterMs <= 0 {
+		t.Errorf("Worker lleno: codigo %d, %+v", rec.Code, busy)
+	}
+
+	// Master: w1 rechaza con 429, la tarea termina en w2
+	m := newIdleTestMaster(t)
+	m.Placement = &master.RoundRobinPolicy{}
+	m.addWorkerWith("w1", 8, nil, func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		json.NewEncoder(w).Encode(common.BusyResponse{WorkerID: "w1", Slots: 2, ActiveTasks: 2, RetryAfterMs: 5000})
+	})
+	m.addWorker("w2", 8, nil)
+	m.start()
+	m.Pending.Push(common.Task{ID: "t1", JobID: "j", NodeID: "n", Attempt: 1}, 0)
+
+	got := []string{m.nextTask().WorkerID, m.nextTask().WorkerID}
+	if got[0] != "w1" || got[1] != "w2" {
+		t.Errorf("Secuencia de envios inesperada: %v", got)
+	}
+
+	rec = httptest.NewRecorder()
+	m.ListWorkersHandler(rec, httptest.NewRequest(http.MethodGet, "/api/v1/workers", nil))
+	var list []common.WorkerStatusResponse
+	json.NewDecoder(rec.Body).Decode(&list)
+	if len(list) != 2 || list[0].ID != "w1" || list[0].Slots != 2 || list[0].BusyUntil == nil || list[1].BusyUntil != nil {
+		t.Errorf("Listado tras 429 inesperado: %+v", list)
+	}
+}
+
+// TestWorkerDecommission - Prueba el retiro ordenado de un worker
+// Entrada: t - objeto testing
+// Salida: ninguna (void), reporta fallos via t.Error
+// Descripcion: El Master marca el worker DRAINING y le ordena drenar; el
+//
+//	worker rechaza tareas nuevas (503), se desregistra, y el Master
+//	traspasa a otro worker la particion cuya salida sigue disponible y
+//	vuelve a ejecutar la que se perdio.
+func TestWorkerDecommission(t *testing.T) {
+	m := newIdleTestMaster(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/deregister", m.DeregisterHandler)
+	mux.HandleFunc("/task/complete", m.CompleteTaskHandler)
+	mux.HandleFunc("/api/v1/workers/", m.WorkerHandler)
+	masterSrv := httptest.NewServer(mux)
+	defer masterSrv.Close()
+
+	// Worker real que sera retirado
+	wk := worker.NewWorker(0, masterSrv.URL, t.TempDir())
+	wkMux := http.NewServeMux()
+	wkMux.HandleFunc("/task", wk.TaskHandler)
+	wkMux.HandleFunc("/decommission", wk.DecommissionHandler)
+	wkSrv := httptest.NewServer(wkMux)
+	defer wkSrv.Close()
+
+	m.register(wk.ID, 4, nil, wkSrv.URL)
+	// Worker que recibe el traspaso
+	m.addWorker("peer", 4, nil)
+	m.start()
+
+	// Job en curso: las dos particiones de "read" las produjo el worker saliente
+	kept := createTempFile(t, "a")
+	job := &common.Job{ID: "j", Status: "RUNNING", Parallelism: 2, Graph: common.DAG{
+		Nodes: []common.DAGNode{{ID: "read", Op: "read_csv", Path: "x.csv"}, {ID: "up", Op: "map", Fn: "to_upper"}},
+		Edges: [][]string{{"read", "up"}},
+	}}
+	m.Jobs["j"] = job
+	m.InitJobProgress(job)
+	m.TaskProgress["j"]["read"][0] = "COMPLETED"
+	m.TaskProgress["j"]["read"][1] = "COMPLETED"
+	m.JobPartitionOutputs["j"]["read"] = map[int]string{0: kept, 1: kept + ".perdido"}
+	m.JobPartitionOwners["j"]["read"] = map[int]string{0: wk.ID, 1: wk.ID}
+
+	rec := httptest.NewRecorder()
+	m.WorkerHandler(rec, httptest.NewRequest(http.MethodPost, "/api/v1/workers/"+wk.ID+"/decommission", nil))
+	var ws common.WorkerStatusResponse
+	json.NewDecoder(rec.Body).Decode(&ws)
+	if rec.Code != http.StatusAccepted || ws.Status != "DRAINING" {
+		t.Fatalf("Decommission: codigo %d, estado %s", rec.Code, ws.Status)
+	}
+
+	select {
+	case <-wk.Drained():
+	case <-time.After(3 * time.Second):
+		t.Fatal("El worker no termino de drenar")
+	}
+	body, _ := json.Marshal(common.Task{ID: "nueva", JobID: "j", Op: "read_csv"})
+	rec = httptest.NewRecorder()
+	wk.TaskHandler(rec, httptest.NewRequest(http.MethodPost, "/task", strings.NewReader(string(body))))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Worker drenado acepto tarea: codigo %d", rec.Code)
+	}
+
+	// La particion perdida se vuelve a ejecutar en el otro worker
+	if task := m.nextTask(); task.NodeID != "read" || task.PartitionID != 1 {
+		t.Errorf("Tarea reprogramada inesperada: %s/%d", task.NodeID, task.PartitionID)
+	}
+
+	rec = httptest.NewRecorder()
+	m.ListWorkersHandler(rec, httptest.NewRequest(http.MethodGet, "/api/v1/workers", nil))
+	var list []common.WorkerStatusResponse
+	json.NewDecoder(rec.Body).Decode(&list)
+	if len(list) != 1 || list[0].ID != "peer" {
+		t.Errorf("El worker retirado sigue registrado: %+v", list)
+	}
+	rec = httptest.NewRecorder()
+	m.WorkerHandler(rec, httptest.NewRequest(http.MethodPost, "/api/v1/workers/"+wk.ID+"/decommission", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Decommission de worker desregistrado: esperado 404, obtenido %d", rec.Code)
+	}
+}
+
+// recordingScaler Scaler de prueba que registra las acciones pedidas
+type recordingScaler struct {
+	up   []int
+	down [][]string
+}
+
+func (s *recordingScaler) Name() string { return "recording" }
+
+func (s *recordingScaler) ScaleUp(n int) error {
+	s.up = append(s.up, n)
+	return nil
+}
+
+func (s *recordingScaler) ScaleDown(workers []common.WorkerInfo) error {
+	var ids []string
+	for _, w := range workers {
+		ids = append(ids, w.ID)
+	}
+	s.down = append(s.down, ids)
+	return nil
+}
+
+// TestAutoscaling - Prueba las señales y decisiones del escalado dinamico
+// Entrada: t - objeto testing
+// Salida: ninguna (void), reporta fallos via t.Error
+// Descripcion: Con cola y backlog estimado sobre el objetivo el Master pide
+//
+//	workers (limitado por MaxStep); sin cola retira los ociosos por
+//	encima de MinWorkers via decommission; respeta el cooldown.
+func TestAutoscaling(t *testing.T) {
+	if _, err := master.NewScaler("k8s", "", "", "", 0); err == nil {
+		t.Error("Scaler desconocido aceptado")
+	}
+	if s, err := master.NewScaler("none", "", "", "", 0); err != nil || s != nil {
+		t.Errorf("Scaler none: %v, %v", s, err)
+	}
+
+	m := newIdleTestMaster(t)
+	scaler := &recordingScaler{}
+	m.Scaler = scaler
+	m.Scaling = master.ScalingConfig{MinWorkers: 1, MaxWorkers: 4, MaxStep: 2, TargetBacklog: 10 * time.Second}
+
+	drained := make(chan string, 2)
+	fake := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		drained <- r.URL.Path
+	}))
+	defer fake.Close()
+	m.register("a-worker", 2, nil, fake.URL)
+
+	// 4 tareas de 20s con 2 slots: 40s de backlog, se necesitan 8 slots
+	m.StageDurations["j/read"] = []time.Duration{20 * time.Second}
+	for i := 0; i < 4; i++ {
+		m.Pending.Push(common.Task{ID: "t" + string(rune('0'+i)), JobID: "j", NodeID: "read", PartitionID: i}, 0)
+	}
+	m.Autoscale()
+	if len(scaler.up) != 1 || scaler.up[0] != 2 {
+		t.Fatalf("Scale up esperado de 2 workers (MaxStep), obtenido %v", scaler.up)
+	}
+
+	rec := httptest.NewRecorder()
+	m.ScalingHandler(rec, httptest.NewRequest(http.MethodGet, "/api/v1/scaling", nil))
+	var st common.ScalingStatusResponse
+	json.NewDecoder(rec.Body).Decode(&st)
+	if st.Scaler != "recording" || st.Signals.PendingTasks != 4 || st.Signals.Workers != 1 ||
+		st.Signals.TotalSlots != 2 || st.Signals.BacklogSecs != 40 || st.LastAction != "scale_up 2" || st.LastActionAt == nil {
+		t.Errorf("Estado de escalado inesperado: %+v", st)
+	}
+
+	// Con cooldown no se repite la accion
+	m.Scaling.Cooldown = time.Hour
+	m.Autoscale()
+	if len(scaler.up) != 1 {
+		t.Errorf("Accion repetida durante el cooldown: %v", scaler.up)
+	}
+	m.Scaling.Cooldown = 0
+
+	// Sin cola y con dos workers ociosos se retira uno (MinWorkers = 1)
+	m.Pending.RemoveJob("j")
+	m.register("b-worker", 2, nil, fake.URL)
+	m.Autoscale()
+	if len(scaler.down) != 1 || len(scaler.down[0]) != 1 || scaler.down[0][0] != "a-worker" {
+		t.Fatalf("Scale down esperado de a-worker, obtenido %v", scaler.down)
+	}
+	if status := m.Workers["a-worker"].Status; status != "DRAINING" {
+		t.Errorf("Worker retirado en estado %s, esperado DRAINING", status)
+	}
+	select {
+	case path := <-drained:
+		if path != "/decommission" {
+			t.Errorf("Orden al worker retirado: %s", path)
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("El worker retirado no recibio la orden de decommission")
+	}
+
+	m.Autoscale()
+	if len(scaler.down) != 1 || len(scaler.up) != 1 {
+		t.Errorf("Accion con el cluster en el minimo: up=%v down=%v", scaler.up, scaler.down)
+	}
+}
+
+// TestWorkerHandshake - Prueba el registro de workers con capacidades y version
+// Entrada: t - objeto testing
+// Salida: ninguna (void), reporta fallos via t.Error
+// Descripcion: El Master rechaza (409) workers de otro protocolo o sin
+//
+//	operadores/UDFs que el conoce, usa la direccion anunciada y muestra
+//	version, operadores, UDFs y etiquetas en GET /api/v1/workers.
+func TestWorkerHandshake(t *testing.T) {
+	m := master.NewMaster(t.TempDir() + "/state.json")
+	register := func(req common.RegisterRequest) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(req)
+		rec := httptest.NewRecorder()
+		m.RegisterHandler(rec, httptest.NewRequest(http.MethodPost, "/register", strings.NewReader(string(body))))
+		return rec
+	}
+
+	withoutJoin := []string{}
+	for _, op := range common.SupportedOpNames() {
+		if op != "join" {
+			withoutJoin = append(withoutJoin, op)
+		}
+	}
+	rejected := []struct {
+		name    string
+		req     common.RegisterRequest
+		code    int
+		message string
+	}{
+		{"build anterior", common.RegisterRequest{ID: "old", Port: 9001}, http.StatusConflict, "protocolo 0"},
+		{"operador faltante", common.RegisterRequest{ID: "noop", Port: 9001, ProtocolVersion: common.ProtocolVersion, Operators: withoutJoin}, http.StatusConflict, "join"},
+		{"UDF faltante", common.RegisterRequest{ID: "noudf", Port: 9001, ProtocolVersion: common.ProtocolVersion, UDFs: []string{"map:to_lower"}}, http.StatusConflict, "flat_map:tokenize"},
+		{"address invalida", common.RegisterRequest{ID: "bad", Port: 9001, ProtocolVersion: common.ProtocolVersion, Address: "sin-puerto"}, http.StatusBadRequest, "address invalida"},
+	}
+	for _, c := range rejected {
+		rec := register(c.req)
+		if rec.Code != c.code || !strings.Contains(rec.Body.String(), c.message) {
+			t.Errorf("%s: codigo %d, mensaje %q", c.name, rec.Code, rec.Body.String())
+		}
+	}
+
+	rec := register(common.RegisterRequest{
+		ID: "w1", Port: 9001, Slots: 6, Address: "10.1.2.3:9100",
+		Version: "v1.4.0", ProtocolVersion: common.ProtocolVersion,
+		Operators: common.SupportedOpNames(), UDFs: operators.UDFNames(),
+		Labels: map[string]string{"zone": "a"},
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Worker compatible rechazado: %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	m.ListWorkersHandler(rec, httptest.NewRequest(http.MethodGet, "/api/v1/workers", nil))
+	var list []common.WorkerStatusResponse
+	json.NewDecoder(rec.Body).Decode(&list)
+	if len(list) != 1 {
+		t.Fatalf("Workers registrados: %+v", list)
+	}
+	w := list[0]
+	if w.URL != "http://10.1.2.3:9100" || w.Version != "v1.4.0" || w.Slots != 6 || w.Labels["zone"] != "a" ||
+		len(w.Operators) != len(common.SupportedOps) || len(w.UDFs) != len(operators.UDFNames()) {
+		t.Errorf("Atributos del worker inesperados: %+v", w)
+	}
+
+	if labels, err := common.ParseLabels("zone=a, disk=ssd"); err != nil || labels["disk"] != "ssd" || len(labels) != 2 {
+		t.Errorf("ParseLabels: %v, %v", labels, err)
+	}
+	if _, err := common.ParseLabels("zone"); err == nil {
+		t.Error("ParseLabels acepto etiqueta sin valor")
+	}
+}
+
+// TestPlacementConstraints - Prueba las etiquetas requeridas y preferidas de los nodos
+// Entrada: t - objeto testing
+// Salida: ninguna (void), reporta fallos via t.Error
+// Descripcion: Las tareas de un nodo solo van a workers con sus etiquetas
+//
+//	requeridas y, entre ellos, a los que tienen mas etiquetas preferidas.
+//	Un job cuyas restricciones ningun worker cumple se rechaza al enviarlo.
+func TestPlacementConstraints(t *testing.T) {
+	m := newTestMaster(t, 4, nil)
+	m.addWorker("ssd-a", 4, map[string]string{"disk": "ssd", "zone": "a"})
+	m.addWorker("ssd-b", 4, map[string]string{"disk": "ssd", "zone": "b"})
+	m.addWorker("hdd-b", 4, map[string]string{"disk": "hdd", "zone": "b"})
+
+	submit := func(nodeID string, c *common.PlacementConstraints) *httptest.ResponseRecorder {
+		job, _ := json.Marshal(common.JobRequest{
+			Name:        "constraints",
+			DAG:         common.DAG{Nodes: []common.DAGNode{{ID: nodeID, Op: "read_csv", Path: "x.csv", Constraints: c}}},
+			Parallelism: 3,
+		})
+		rec := httptest.NewRecorder()
+		m.SubmitJobHandler(rec, httptest.NewRequest(http.MethodPost, "/api/v1/jobs", strings.NewReader(string(job))))
+		return rec
+	}
+	expect := func(nodeID, workerID string) {
+		for i := 0; i < 3; i++ {
+			if got := m.nextTask(); got.NodeID != nodeID || got.WorkerID != workerID {
+				t.Errorf("Tarea de %s colocada en %s, esperado %s", got.NodeID, got.WorkerID, workerID)
+			}
+		}
+	}
+
+	if rec := submit("hdd", &common.PlacementConstraints{Required: map[string]string{"disk": "hdd"}}); rec.Code != http.StatusOK {
+		t.Fatalf("Job con etiquetas satisfacibles rechazado: %d %s", rec.Code, rec.Body.String())
+	}
+	expect("hdd", "hdd-b")
+
+	// Requerida disk=ssd y preferida zone=b: solo ssd-b cumple ambas
+	submit("ssd", &common.PlacementConstraints{Required: map[string]string{"disk": "ssd"}, Preferred: map[string]string{"zone": "b"}})
+	expect("ssd", "ssd-b")
+
+	// Una preferida que nadie cumple no deja la tarea en espera
+	submit("any", &common.PlacementConstraints{Required: map[string]string{"zone": "a"}, Preferred: map[string]string{"rack": "7"}})
+	expect("any", "ssd-a")
+
+	rec := submit("gpu", &common.PlacementConstraints{Required: map[string]string{"gpu": "yes"}})
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "ningun worker") {
+		t.Errorf("Job sin workers compatibles: codigo %d, %s", rec.Code, rec.Body.String())
+	}
+	rec = submit("bad", &common.PlacementConstraints{Required: map[string]string{"": "x"}})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Etiqueta con clave vacia: esperado 400, obtenido %d", rec.Code)
+	}
+	if n := len(m.Jobs); n != 3 {
+		t.Errorf("Jobs creados: %d, esperados 3", n)
+	}
+}
+
+// TestWorkerIntrospection - Prueba la API de consulta de workers
+// Entrada: t - objeto testing
+// Salida: ninguna (void), reporta fallos via t.Error
+// Descripcion: GET /api/v1/workers lista los workers con sus tareas
+//
+//	asignadas; GET /api/v1/workers/{id} agrega el detalle de esas tareas
+//	y el historial acotado de metricas de sus heartbeats. Se consulta
+//	tambien desde el SDK.
+func TestWorkerIntrospection(t *testing.T) {
+	m := newTestMaster(t, 4, nil, "w1")
+
+	// El historial conserva solo los ultimos heartbeats
+	for i := 1; i <= 65; i++ {
+		hb, _ := json.Marshal(common.HeartbeatRequest{ID: "w1", Metrics: common.SystemMetrics{MemoryUsage: uint64(i)}})
+		m.HeartbeatHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/heartbeat", strings.NewReader(string(hb))))
+	}
+
+	job, _ := json.Marshal(common.JobRequest{Name: "intro", Parallelism: 2,
+		DAG: common.DAG{Nodes: []common.DAGNode{{ID: "read", Op: "read_csv", Path: "x.csv"}}}})
+	m.SubmitJobHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/v1/jobs", strings.NewReader(string(job))))
+	for i := 0; i < 2; i++ {
+		m.nextTask()
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/workers", m.ListWorkersHandler)
+	mux.HandleFunc("/api/v1/workers/", m.WorkerHandler)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	client := minispark.NewClient(srv.URL)
+	ctx := context.Background()
+
+	list, err := client.Workers(ctx)
+	if err != nil || len(list) != 1 || list[0].AssignedTasks != 2 || list[0].Status != "UP" {
+		t.Fatalf("Lista de workers: %+v, %v", list, err)
+	}
+	detail, err := client.Worker(ctx, "w1")
+	if err != nil {
+		t.Fatalf("Detalle de worker: %v", err)
+	}
+	if len(detail.Tasks) != 2 || detail.AssignedTasks != 2 || detail.Tasks[0].NodeID != "read" || detail.Tasks[0].Attempt != 1 {
+		t.Errorf("Tareas del worker inesperadas: %+v", detail.Tasks)
+	}
+	history := detail.MetricsHistory
+	if len(history) != 60 || history[0].MemoryUsage != 6 || history[59].MemoryUsage != 65 || detail.Metrics.MemoryUsage != 65 {
+		t.Errorf("Historial de metricas: %d muestras, primera %+v", len(history), history[0])
+	}
+
+	if _, err := client.Worker(ctx, "nadie"); !errors.Is(err, minispark.ErrWorkerNotFound) || errors.Is(err, minispark.ErrJobNotFound) {
+		t.Errorf("Worker inexistente: esperado solo ErrWorkerNotFound, obtenido %v", err)
+	}
+	rec := httptest.NewRecorder()
+	m.WorkerHandler(rec, httptest.NewRequest(http.MethodDelete, "/api/v1/workers/w1", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("DELETE sobre un worker: esperado 405, obtenido %d", rec.Code)
+	}
+}
+
+// TestWorkerSystemMetrics - Prueba las metricas reales del worker y el uso por tarea
+// Entrada: t - objeto testing
+// Salida: ninguna (void), reporta fallos via t.Error
+// Descripcion: En Linux las metricas del heartbeat vienen de /proc (CPU
+//
+//	entre muestras, RSS, carga media, disco libre). Cada TaskResult
+//	lleva los recursos consumidos por la tarea.
+func TestWorkerSystemMetrics(t *testing.T) {
+	wk := worker.NewWorker(0, "", t.TempDir())
+	wk.SystemMetrics()
+	// Consumir CPU para que la segunda muestra la registre
+	deadline := time.Now().Add(300 * time.Millisecond)
+	for x := 0; time.Now().Before(deadline); x++ {
+		_ = x * x
+	}
+	metrics := wk.SystemMetrics()
+	if metrics.NumCPU < 1 || metrics.Goroutines < 1 || metrics.HeapBytes == 0 || metrics.MemoryUsage == 0 {
+		t.Errorf("Metricas del runtime incompletas: %+v", metrics)
+	}
+	if metrics.CPUPercent < 0 || metrics.CPUPercent > 100 || metrics.HostCPUPercent < 0 || metrics.HostCPUPercent > 100 {
+		t.Errorf("Porcentajes de CPU fuera de rango: %+v", metrics)
+	}
+	if runtime.GOOS == "linux" {
+		if metrics.CPUPercent == 0 || len(metrics.LoadAvg) != 3 || metrics.DiskFreeBytes == 0 {
+			t.Errorf("Metricas de /proc no reportadas: %+v", metrics)
+		}
+	}
+
+	content := "uno\ndos\ntres\n"
+	input := createTempFile(t, content)
+	res := runWorkerTask(t, common.Task{ID: "usage", JobID: "j", NodeID: "read", Op: "read_csv", Args: []string{input}})
+	if res.Status != "COMPLETED" || res.Usage == nil {
+		t.Fatalf("Resultado sin uso de recursos: %+v", res)
+	}
+	if res.Usage.InputBytes != int64(len(content)) || res.Usage.OutputBytes != int64(len(content)) || res.Usage.WallSecs <= 0 || res.Usage.CPUSecs < 0 {
+		t.Errorf("Uso de la tarea inesperado: %+v", res.Usage)
+	}
+}
+
+// scrapeMetrics - Lee GET /metrics y devuelve las muestras (serie -> valor)
+func scrapeMetrics(t *testing.T, h http.Handler) map[string]string {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") {
+		t.Fatalf("GET /metrics: %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	samples := make(map[string]string)
+	for _, line := range strings.Split(rec.Body.String(), "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndexByte(line, ' ')
+		samples[line[:i]] = line[i+1:]
+	}
+	return samples
+}
+
+// TestPrometheusMetrics - Prueba el endpoint /metrics de Master y Worker
+// Entrada: t - objeto testing
+// Salida: ninguna (void), reporta fallos via t.Error
+// Descripcion: Tras un job de una tarea el Master reporta jobs, duracion por
+//
+//	operacion, cola y workers vivos; el Worker reporta tareas, spills y
+//	bytes de shuffle de un reduce_by_key forzado a pasar por disco.
+func TestPrometheusMetrics(t *testing.T) {
+	m := newTestMaster(t, 2, nil, "w1")
+
+	job, _ := json.Marshal(common.JobRequest{Name: "metrics", Parallelism: 1,
+		DAG: common.DAG{Nodes: []common.DAGNode{{ID: "read", Op: "read_csv", Path: "x.csv"}}}})
+	m.SubmitJobHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/v1/jobs", strings.NewReader(string(job))))
+	task := m.nextTask()
+	if got := scrapeMetrics(t, m.Metrics)["minispark_tasks_running"]; got != "1" {
+		t.Errorf("Tareas en ejecucion: esperado 1, obtenido %s", got)
+	}
+	res, _ := json.Marshal(common.TaskResult{ID: task.ID, JobID: task.JobID, NodeID: task.NodeID, PartitionID: task.PartitionID,
+		WorkerID: "w1", Status: "COMPLETED", Result: "out.txt"})
+	m.CompleteTaskHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/task/complete", strings.NewReader(string(res))))
+
+	samples := scrapeMetrics(t, m.Metrics)
+	expected := map[string]string{
+		`minispark_jobs_submitted_total{pool="default"}`:                  "1",
+		`minispark_jobs_finished_total{status="COMPLETED"}`:               "1",
+		`minispark_task_duration_seconds_count{op="read_csv"}`:            "1",
+		`minispark_task_duration_seconds_bucket{op="read_csv",le="+Inf"}`: "1",
+		`minispark_queue_depth`:                                           "0",
+		`minispark_workers_live`:                                          "1",
+		`minispark_tasks_running`:                                         "0",
+		`minispark_jobs_running`:                                          "0",
+	}
+	for series, value := range expected {
+		if samples[series] != value {
+			t.Errorf("Master %s: esperado %s, obtenido %q", series, value, samples[series])
+		}
+	}
+
+	// Worker: reduce_by_key con spill a disco
+	oldThreshold := worker.SpillThreshold
+	worker.SpillThreshold = 2
+	defer func() { worker.SpillThreshold = oldThreshold }()
+	content := "a\nb\nc\na\nd\ne\n"
+	input := createTempFile(t, content)
+	reports := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer reports.Close()
+	wk := worker.NewWorker(0, reports.URL, t.TempDir())
+	wk.ExecuteTask(common.Task{ID: "r1", JobID: "j", NodeID: "reduce", Op: "reduce_by_key", InputFiles: []string{input}})
+
+	samples = scrapeMetrics(t, wk.Metrics)
+	if got := samples[`minispark_worker_tasks_total{op="reduce_by_key",status="COMPLETED"}`]; got != "1" {
+		t.Errorf("Tareas del worker: esperado 1, obtenido %q", got)
+	}
+	if got := samples[`minispark_worker_shuffle_read_bytes_total{op="reduce_by_key"}`]; got != fmt.Sprint(len(content)) {
+		t.Errorf("Bytes de shuffle: esperado %d, obtenido %q", len(content), got)
+	}
+	if files := samples["minispark_worker_spill_files_total"]; files == "" || files == "0" || samples["minispark_worker_spill_bytes_total"] == "0" {
+		t.Errorf("Spills no contabilizados: %v", samples)
+	}
+	if samples["minispark_worker_active_tasks"] != "0" || samples["minispark_worker_slots"] != fmt.Sprint(common.DefaultWorkerSlots) {
+		t.Errorf("Gauges del worker inesperados: %v", samples)
+	}
+}
+
+// TestTaskExecutionMetrics - Prueba las metricas por tarea y su agregacion en el status
+// Entrada: t - objeto testing
+// Salida: ninguna (void), reporta fallos via t.Error
+// Descripcion: El worker reporta registros, spills e intento de cada
+//
+//	tarea; el Master las agrega por nodo y job y detecta la particion
+//	con mas datos (skew = maximo/mediana).
+func TestTaskExecutionMetrics(t *testing.T) {
+	oldThreshold := worker.SpillThreshold
+	worker.SpillThreshold = 2
+	defer func() { worker.SpillThreshold = oldThreshold }()
+	input := createTempFile(t, "a\nb\nc\na\nd\ne")
+	res := runWorkerTask(t, common.Task{ID: "m1", JobID: "j", NodeID: "reduce", Op: "reduce_by_key", InputFiles: []string{input}, Attempt: 2})
+	if res.Status != "COMPLETED" || res.Attempt != 2 || res.Usage == nil {
+		t.Fatalf("Resultado inesperado: %+v", res)
+	}
+	if res.Usage.RecordsIn != 6 || res.Usage.RecordsOut != 5 || res.Usage.SpillCount == 0 {
+		t.Errorf("Metricas del worker inesperadas: %+v", res.Usage)
+	}
+
+	m := newTestMaster(t, 4, nil, "w1")
+
+	job, _ := json.Marshal(common.JobRequest{Name: "skew", Parallelism: 3,
+		DAG: common.DAG{Nodes: []common.DAGNode{{ID: "read", Op: "read_csv", Path: "x.csv"}}}})
+	rec := httptest.NewRecorder()
+	m.SubmitJobHandler(rec, httptest.NewRequest(http.MethodPost, "/api/v1/jobs", strings.NewReader(string(job))))
+	var submitted map[string]string
+	json.NewDecoder(rec.Body).Decode(&submitted)
+
+	// La particion 2 recibe 10 veces mas datos que la mediana
+	sizes := map[int]int64{0: 100, 1: 120, 2: 1200}
+	for i := 0; i < 3; i++ {
+		task := m.nextTask()
+		usage := &common.TaskUsage{WallSecs: float64(sizes[task.PartitionID]) / 100, InputBytes: sizes[task.PartitionID],
+			RecordsIn: sizes[task.PartitionID] / 10, RecordsOut: sizes[task.PartitionID] / 10, SpillCount: 1}
+		done, _ := json.Marshal(common.TaskResult{ID: task.ID, JobID: task.JobID, NodeID: task.NodeID, PartitionID: task.PartitionID,
+			Status: "COMPLETED", Result: "out.txt", WorkerID: "w1", Usage: usage, Attempt: task.Attempt})
+		m.CompleteTaskHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/task/complete", strings.NewReader(string(done))))
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(m.GetJobStatusHandler))
+	defer srv.Close()
+	status, err := minispark.NewClient(srv.URL).Status(context.Background(), submitted["job_id"])
+	if err != nil || status.Metrics == nil {
+		t.Fatalf("Status sin metricas: %+v, %v", status, err)
+	}
+	metrics := status.Metrics
+	if metrics.Tasks != 3 || metrics.InputBytes != 1420 || metrics.RecordsIn != 142 || metrics.SpillCount != 3 || metrics.Retries != 0 {
+		t.Errorf("Totales del job inesperados: %+v", metrics.MetricsTotals)
+	}
+	node := metrics.Nodes["read"]
+	if len(node.Partitions) != 3 || node.Partitions[0].Attempt != 1 || node.Partitions[2].WorkerID != "w1" {
+		t.Errorf("Particiones del nodo inesperadas: %+v", node.Partitions)
+	}
+	if node.MaxInputBytes != 1200 || node.MedianInputBytes != 120 || node.SizeSkew != 10 || node.LargestPartition != 2 || node.SlowestPartition != 2 {
+		t.Errorf("Skew del nodo inesperado: %+v", node)
+	}
+	if metrics.SkewedNode != "read" || metrics.MaxSizeSkew != 10 {
+		t.Errorf("Nodo con skew: esperado read (10), obtenido %s (%v)", metrics.SkewedNode, metrics.MaxSizeSkew)
+	}
+}
+
+// TestDistributedTracing - Prueba que un job se exporte como una sola traza
+// Entrada: t - objeto testing
+// Salida: ninguna (void), reporta fallos via t.Error
+// Descripcion: Master y Worker reales exportan a un archivo OTLP/JSON; los
+//
+//	spans del worker (execute, report_result) cuelgan del span de la
+//	tarea, que cuelga del span raiz del job.
+func TestDistributedTracing(t *testing.T) {
+	traceFile := t.TempDir() + "/traces.jsonl"
+	exporter, err := tracing.NewExporter("file:" + traceFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := newIdleTestMaster(t)
+	m.Tracer = tracing.NewTracer("mini-spark-master", exporter)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/task/complete", m.CompleteTaskHandler)
+	mux.HandleFunc("/api/v1/jobs/", m.GetJobStatusHandler)
+	masterSrv := httptest.NewServer(mux)
+	defer masterSrv.Close()
+
+	wk := worker.NewWorker(0, masterSrv.URL, t.TempDir())
+	wk.Tracer = tracing.NewTracer("mini-spark-worker", exporter)
+	wkSrv := httptest.NewServer(http.HandlerFunc(wk.TaskHandler))
+	defer wkSrv.Close()
+	m.register(wk.ID, 2, nil, wkSrv.URL)
+	m.start()
+
+	input := createTempFile(t, "a\nb\n")
+	job, _ := json.Marshal(common.JobRequest{Name: "traza", Parallelism: 1,
+		DAG: common.DAG{Nodes: []common.DAGNode{{ID: "read", Op: "read_csv", Path: input}}}})
+	rec := httptest.NewRecorder()
+	m.SubmitJobHandler(rec, httptest.NewRequest(http.MethodPost, "/api/v1/jobs", strings.NewReader(string(job))))
+	var submitted map[string]string
+	json.NewDecoder(rec.Body).Decode(&submitted)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	status, err := minispark.NewClient(masterSrv.URL).Wait(ctx, submitted["job_id"], 20*time.Millisecond)
+	if err != nil || status.Status != "COMPLETED" || len(status.TraceID) != 32 {
+		t.Fatalf("Job sin completar o sin traza: %+v, %v", status, err)
+	}
+	// El worker registra report_result despues de que el Master acepta el resultado
+	for deadline := time.Now().Add(2 * time.Second); atomic.LoadInt32(&wk.ActiveTasks) > 0 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := wk.Tracer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Tracer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Spans exportados: nombre -> span
+	type span struct {
+		TraceID      string `json:"traceId"`
+		SpanID       string `json:"spanId"`
+		ParentSpanID string `json:"parentSpanId"`
+		Name         string `json:"name"`
+		Start        int64  `json:"startTimeUnixNano,string"`
+		End          int64  `json:"endTimeUnixNano,string"`
+	}
+	spans := make(map[string]span)
+	services := make(map[string]bool)
+	data, _ := os.ReadFile(traceFile)
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var batch struct {
+			ResourceSpans []struct {
+				Resource struct {
+					Attributes []struct {
+						Value struct {
+							StringValue string `json:"stringValue"`
+						} `json:"value"`
+					} `json:"attributes"`
+				} `json:"resource"`
+				ScopeSpans []struct {
+					Spans []span `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		if err := json.Unmarshal([]byte(line), &batch); err != nil {
+			t.Fatalf("Lote OTLP/JSON invalido: %v", err)
+		}
+		for _, rs := range batch.ResourceSpans {
+			services[rs.Resource.Attributes[0].Value.StringValue] = true
+			for _, ss := range rs.ScopeSpans {
+				for _, s := range ss.Spans {
+					spans[strings.Fields(s.Name)[0]] = s
+				}
+			}
+		}
+	}
+	if !services["mini-spark-master"] || !services["mini-spark-worker"] {
+		t.Errorf("Servicios exportados: %v", services)
+	}
+	for _, name := range []string{"job", "task", "queue_wait", "scheduling_delay", "execute", "report_result"} {
+		s, ok := spans[name]
+		if !ok {
+			t.Errorf("Falta el span %s", name)
+			continue
+		}
+		if s.TraceID != status.TraceID || s.Start > s.End {
+			t.Errorf("Span %s fuera de la traza o con tiempos invalidos: %+v", name, s)
+		}
+	}
+	if spans["job"].ParentSpanID != "" || spans["task"].ParentSpanID != spans["job"].SpanID {
+		t.Errorf("El span de la tarea no cuelga del job: %+v", spans)
+	}
+	for _, child := range []string{"queue_wait", "scheduling_delay", "execute", "report_result"} {
+		if spans[child].ParentSpanID != spans["task"].SpanID {
+			t.Errorf("El span %s no cuelga de la tarea: %+v", child, spans[child])
+		}
+	}
+}
+
+// TestJobEvents - Prueba el historial de eventos de un job
+// Entrada: t - objeto testing
+// Salida: ninguna (void), reporta fallos via t.Error
+// Descripcion: Una particion falla y se reintenta en otro worker; el
+//
+//	historial debe registrar cada transicion en orden, filtrarse con
+//	?after= y sobrevivir a un reinicio del Master.
+func TestJobEvents(t *testing.T) {
+	m := newTestMaster(t, 1, nil, "w1", "w2")
+
+	job, _ := json.Marshal(common.JobRequest{Name: "eventos", Parallelism: 1, Retry: &common.RetryPolicy{BackoffMs: 1},
+		DAG: common.DAG{Nodes: []common.DAGNode{{ID: "read", Op: "read_csv", Path: "x.csv"}}}})
+	rec := httptest.NewRecorder()
+	m.SubmitJobHandler(rec, httptest.NewRequest(http.MethodPost, "/api/v1/jobs", strings.NewReader(string(job))))
+	var submitted map[string]string
+	json.NewDecoder(rec.Body).Decode(&submitted)
+	jobID := submitted["job_id"]
+
+	srv := httptest.NewServer(http.HandlerFunc(m.GetJobStatusHandler))
+	defer srv.Close()
+	client := minispark.NewClient(srv.URL)
+
+	// Primer intento falla, el segundo completa
+	for _, status := range []string{"FAILED", "COMPLETED"} {
+		task := m.nextTask()
+		// El Master registra el inicio al recibir la respuesta del worker
+		for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+			history, err := client.Events(context.Background(), jobID, 0)
+			if err == nil && len(history.Events) > 0 && history.Events[len(history.Events)-1].Type == common.EventTaskStarted {
+				break
+			}
+		}
+		done, _ := json.Marshal(common.TaskResult{ID: task.ID, JobID: task.JobID, NodeID: task.NodeID, PartitionID: task.PartitionID,
+			Status: status, Result: "out.txt", ErrorMsg: "disco lleno", ErrorClass: common.ErrorClassRetryable, WorkerID: task.WorkerID, Attempt: task.Attempt})
+		m.CompleteTaskHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/task/complete", strings.NewReader(string(done))))
+	}
+
+	history, err := client.Events(context.Background(), jobID, 0)
+	if err != nil || history.Status != "COMPLETED" {
+		t.Fatalf("Historial inesperado: %+v, %v", history, err)
+	}
+	want := []string{common.EventJobSubmitted,
+		common.EventTaskQueued, common.EventTaskAssigned, common.EventTaskStarted, common.EventTaskFailed, common.EventTaskRetried,
+		common.EventTaskAssigned, common.EventTaskStarted, common.EventTaskCompleted,
+		common.EventJobCompleted}
+	var got []string
+	for i, e := range history.Events {
+		got = append(got, e.Type)
+		if e.Seq != i+1 || (i > 0 && e.Time.Before(history.Events[i-1].Time)) {
+			t.Errorf("Evento fuera de orden: %+v", e)
+		}
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("Eventos: esperado %v, obtenido %v", want, got)
+	}
+	failed, retried, completed := history.Events[4], history.Events[5], history.Events[8]
+	if failed.Attempt != 1 || failed.WorkerID != "w1" || !strings.Contains(failed.Detail, "disco lleno") {
+		t.Errorf("Evento de fallo inesperado: %+v", failed)
+	}
+	if retried.Attempt != 2 || completed.Attempt != 2 || completed.WorkerID != "w2" || completed.NodeID != "read" {
+		t.Errorf("Eventos del reintento inesperados: %+v %+v", retried, completed)
+	}
+
+	tail, err := client.Events(context.Background(), jobID, 8)
+	if err != nil || len(tail.Events) != 2 || tail.Events[0].Seq != 9 {
+		t.Errorf("?after=8: esperado 2 eventos desde seq 9, obtenido %+v, %v", tail, err)
+	}
+	if _, err := client.Events(context.Background(), "no-existe", 0); !errors.Is(err, minispark.ErrJobNotFound) {
+		t.Errorf("Job inexistente: esperado ErrJobNotFound, obtenido %v", err)
+	}
+
+	// El historial se persiste con el estado
+	m.SaveState()
+	restored := master.NewMaster(m.stateFile)
+	restored.LoadState()
+	rec = httptest.NewRecorder()
+	restored.GetJobStatusHandler(rec, httptest.NewRequest(http.MethodGet, "/api/v1/jobs/"+jobID+"/events", nil))
+	var reloaded common.JobEventsResponse
+	json.NewDecoder(rec.Body).Decode(&reloaded)
+	if len(reloaded.Events) != len(want) {
+		t.Errorf("Historial tras reinicio: esperado %d eventos, obtenido %d", len(want), len(reloaded.Events))
+	}
+	// Los eventos van a su propio archivo, no al snapshot de estado
+	if data, _ := os.ReadFile(m.stateFile); strings.Contains(string(data), common.EventTaskAssigned) {
+		t.Error("El archivo de estado no deberia contener el historial de eventos")
+	}
+}
+
+// TestFinishedJobRetention - Prueba la retencion de jobs terminados
+// Entrada: t - objeto testing
+// Salida: ninguna (void), reporta fallos via t.Error
+// Descripcion: Con MaxFinishedJobs=1, al terminar el segundo job el primero
+//
+//	se olvida por completo (estado y archivo de eventos).
+func TestFinishedJobRetention(t *testing.T) {
+	m := newTestMaster(t, 1, nil, "w1")
+	m.MaxFinishedJobs = 1
+
+	var jobIDs []string
+	for i := 0; i < 2; i++ {
+		job, _ := json.Marshal(common.JobRequest{Name: "retencion", Parallelism: 1,
+			DAG: common.DAG{Nodes: []common.DAGNode{{ID: "read", Op: "read_csv", Path: "x.csv"}}}})
+		rec := httptest.NewRecorder()
+		m.SubmitJobHandler(rec, httptest.NewRequest(http.MethodPost, "/api/v1/jobs", strings.NewReader(string(job))))
+		var submitted map[string]string
+		json.NewDecoder(rec.Body).Decode(&submitted)
+		jobIDs = append(jobIDs, submitted["job_id"])
+
+		task := m.nextTask()
+		done, _ := json.Marshal(common.TaskResult{ID: task.ID, JobID: task.JobID, NodeID: task.NodeID, PartitionID: task.PartitionID,
+			Status: "COMPLETED", Result: "out.txt", WorkerID: "w1", Attempt: task.Attempt})
+		m.CompleteTaskHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/task/complete", strings.NewReader(string(done))))
+	}
+
+	for i, jobID := range jobIDs {
+		rec := httptest.NewRecorder()
+		m.GetJobStatusHandler(rec, httptest.NewRequest(http.MethodGet, "/api/v1/jobs/"+jobID+"/events", nil))
+		_, statErr := os.Stat(m.stateFile + ".events/" + jobID + ".jsonl")
+		if kept := i == 1; kept != (rec.Code == http.StatusOK) || kept != (statErr == nil) {
+			t.Errorf("Job %d: esperado conservado=%v, obtenido HTTP %d, archivo %v", i, kept, rec.Code, statErr)
+		}
+	}
+}
+
+// TestWebUI - Prueba la interfaz web y la API que consume
+// Entrada: t - objeto testing
+// Salida: ninguna (void), reporta fallos via t.Error
+// Descripcion: /ui/ sirve los archivos embebidos sin recursos externos;
+//
+//	GET /api/v1/jobs lista los jobs y el estado de un job trae el DAG y
+//	el estado de cada particion.
+func TestWebUI(t *testing.T) {
+	m := newTestMaster(t, 8, nil, "w1")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/jobs", m.SubmitJobHandler)
+	mux.HandleFunc("/api/v1/jobs/", m.GetJobStatusHandler)
+	mux.Handle("/ui/", master.UIHandler())
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	// Archivos estaticos embebidos, sin CDN ni recursos externos
+	for _, file := range []string{"", "app.js", "style.css"} {
+		resp, err := http.Get(srv.URL + "/ui/" + file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || len(body) == 0 {
+			t.Fatalf("/ui/%s: status %d", file, resp.StatusCode)
+		}
+		for _, external := range []string{`src="http`, `href="http`, `src="//`, `href="//`, "url(http", "@import", "fetch(\"http"} {
+			if strings.Contains(string(body), external) {
+				t.Errorf("/ui/%s referencia un recurso externo (%s)", file, external)
+			}
+		}
+		if file == "" && !strings.Contains(string(body), `<script src="app.js">`) {
+			t.Errorf("index.html no carga app.js")
+		}
+	}
+	if resp, err := http.Get(srv.URL + "/ui/no-existe.js"); err != nil || resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Archivo inexistente: esperado 404, obtenido %v %v", resp.StatusCode, err)
+	}
+
+	client := minispark.NewClient(srv.URL)
+	dag := common.DAG{
+		Nodes: []common.DAGNode{{ID: "read", Op: "read_csv", Path: "x.csv"}, {ID: "up", Op: "map", Fn: "to_upper"}},
+		Edges: [][]string{{"read", "up"}},
+	}
+	first, err := client.Submit(context.Background(), common.JobRequest{Name: "ui", Parallelism: 2, DAG: dag})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		task := m.nextTask()
+		if task.PartitionID != 0 {
+			continue
+		}
+		done, _ := json.Marshal(common.TaskResult{ID: task.ID, JobID: task.JobID, NodeID: task.NodeID, PartitionID: 0,
+			Status: "COMPLETED", Result: "out.txt", WorkerID: "w1", Attempt: task.Attempt})
+		m.CompleteTaskHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/task/complete", strings.NewReader(string(done))))
+	}
+	second, err := client.Submit(context.Background(), common.JobRequest{Name: "ui-2", Parallelism: 1,
+		DAG: common.DAG{Nodes: []common.DAGNode{{ID: "read", Op: "read_csv", Path: "y.csv"}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jobs, err := client.Jobs(context.Background(), "")
+	if err != nil || len(jobs) != 2 || jobs[0].ID != second || jobs[1].ID != first {
+		t.Fatalf("Lista de jobs: esperado [%s %s], obtenido %+v, %v", second, first, jobs, err)
+	}
+	if jobs[1].DAG != nil || jobs[1].Partitions != nil || jobs[1].NodeStatus["read"] != "RUNNING" {
+		t.Errorf("Resumen de job inesperado: %+v", jobs[1])
+	}
+	if done, _ := client.Jobs(context.Background(), "COMPLETED"); len(done) != 0 {
+		t.Errorf("Filtro por estado: esperado 0 jobs COMPLETED, obtenido %d", len(done))
+	}
+
+	status, err := client.Status(context.Background(), first)
+	if err != nil || status.DAG == nil || len(status.DAG.Nodes) != 2 || len(status.DAG.Edges) != 1 {
+		t.Fatalf("Estado sin DAG: %+v, %v", status, err)
+	}
+	if got := strings.Join(status.Partitions["read"], ","); got != "COMPLETED,SCHEDULED" {
+		t.Errorf("Particiones de read: esperado COMPLETED,SCHEDULED, obtenido %s", got)
+	}
+	// Dependencia estrecha: la particion 0 de up se planifica al completar la de read
+	if got := strings.Join(status.Partitions["up"], ","); got != "SCHEDULED,PENDING" {
+		t.Errorf("Particiones de up: esperado SCHEDULED,PENDING, obtenido %s", got)
+	}
+}
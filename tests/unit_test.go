@@ -10,7 +10,14 @@ Descripcion: Suite de pruebas unitarias para operadores individuales.
 package tests
 
 import (
+	"context"
+	"errors"
+	"mini-spark/internal/common"
+	"mini-spark/internal/jobtemplate"
+	"mini-spark/internal/master"
 	"mini-spark/internal/operators"
+	"mini-spark/internal/query"
+	"mini-spark/pkg/minispark"
 	"os"
 	"strings"
 	"testing"
@@ -103,7 +110,7 @@ func TestOperatorMap(t *testing.T) {
 			defer os.Remove(outputFile)
 
 			// Ejecutar operador Map con funcion especificada
-			err := operators.Map([]string{inputFile}, outputFile, tc.function)
+			err := operators.Map(context.Background(), []string{inputFile}, outputFile, tc.function)
 			if err != nil {
 				t.Fatalf("Error ejecutando Map: %v", err)
 			}
@@ -165,7 +172,7 @@ func TestOperatorReduceByKey(t *testing.T) {
 			defer os.Remove(outputFile)
 
 			// Ejecutar ReduceByKey
-			err := operators.ReduceByKey([]string{inputFile}, outputFile)
+			err := operators.ReduceByKey(context.Background(), []string{inputFile}, outputFile)
 			if err != nil {
 				t.Fatalf("Error ejecutando Reduce: %v", err)
 			}
@@ -222,7 +229,7 @@ func TestOperatorFilter(t *testing.T) {
 			defer os.Remove(outputFile)
 
 			// Ejecutar Filter con funcion predicado
-			err := operators.Filter([]string{inputFile}, outputFile, tc.function)
+			err := operators.Filter(context.Background(), []string{inputFile}, outputFile, tc.function)
 			if err != nil {
 				t.Fatalf("Error Filter: %v", err)
 			}
@@ -260,7 +267,7 @@ func TestOperatorJoin(t *testing.T) {
 	defer os.Remove(outputFile)
 
 	// Ejecutar operador Join
-	err := operators.Join(leftFile, rightFile, outputFile)
+	err := operators.Join(context.Background(), leftFile, rightFile, outputFile)
 	if err != nil {
 		t.Fatalf("Join falló: %v", err)
 	}
@@ -281,6 +288,21 @@ func TestOperatorJoin(t *testing.T) {
 	if strings.Contains(result, "Sales") {
 		t.Error("Sales (4) no debería estar (no match en left)")
 	}
+
+	// Claves repetidas en ambos lados: producto cruzado por clave (2x2 + 1x1)
+	dupLeft := createTempFile(t, "1,Carlos\n1,Carla\n2,Maria")
+	defer os.Remove(dupLeft)
+	dupRight := createTempFile(t, "1,IT\n2,HR\n1,Ops")
+	defer os.Remove(dupRight)
+	dupOut := dupLeft + "_join_out"
+	defer os.Remove(dupOut)
+	if err := operators.Join(context.Background(), dupLeft, dupRight, dupOut); err != nil {
+		t.Fatalf("Join falló: %v", err)
+	}
+	expected := "1, Carlos, IT\n1, Carla, IT\n2, Maria, HR\n1, Carlos, Ops\n1, Carla, Ops"
+	if got := readFile(t, dupOut); got != expected {
+		t.Errorf("Join con claves repetidas:\nesperado %q\nobtenido %q", expected, got)
+	}
 }
 
 // --- TEST READ/WRITE (Validar consistencia) ---
@@ -301,7 +323,7 @@ func TestOperatorReadCSV(t *testing.T) {
 	defer os.Remove(out)
 
 	// Ejecutar ReadCSV (actua como copia directa)
-	if err := operators.ReadCSV(in, out); err != nil {
+	if err := operators.ReadCSV(context.Background(), in, out); err != nil {
 		t.Fatal(err)
 	}
 
@@ -311,3 +333,448 @@ func TestOperatorReadCSV(t *testing.T) {
 		t.Errorf("ReadCSV corrompió datos.\nEsp: %s\nObt: %s", content, res)
 	}
 }
+
+// TestOperatorCancelled - Un operador con contexto cancelado deja de leer
+// Entrada: t - objeto testing
+// Salida: ninguna (void), reporta fallos via t.Error
+// Descripcion: Simula una tarea cancelada por el Master (timeout o intento
+//
+//	perdedor): Map debe retornar context.Canceled sin procesar filas.
+func TestOperatorCancelled(t *testing.T) {
+	in := createTempFile(t, "A\nB\nC")
+	defer os.Remove(in)
+	out := in + "_out"
+	defer os.Remove(out)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := operators.Map(ctx, []string{in}, out, "to_lower")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Esperaba context.Canceled, obtuvo %v", err)
+	}
+	if res := readFile(t, out); res != "" {
+		t.Errorf("No deberia escribir filas tras la cancelacion, obtuvo %q", res)
+	}
+}
+
+// TestOperatorRecordCounts - Los operadores cuentan sus registros
+// Entrada: t - objeto testing
+// Salida: ninguna (void), reporta fallos via t.Error
+// Descripcion: Con WithRecordCounts, Filter cuenta las lineas leidas de
+//
+//	todas sus entradas y las escritas en la salida.
+func TestOperatorRecordCounts(t *testing.T) {
+	in1 := createTempFile(t, "casa\nsol\nventana")
+	defer os.Remove(in1)
+	in2 := createTempFile(t, "arbol\nmar")
+	defer os.Remove(in2)
+	out := in1 + "_out"
+	defer os.Remove(out)
+
+	var counts operators.RecordCounts
+	ctx := operators.WithRecordCounts(context.Background(), &counts)
+	if err := operators.Filter(ctx, []string{in1, in2}, out, "long_words"); err != nil {
+		t.Fatalf("Filter fallo: %v", err)
+	}
+	if counts.In != 5 || counts.Out != 2 {
+		t.Errorf("Esperaba 5 leidos y 2 escritos, obtuvo %+v", counts)
+	}
+}
+
+// --- TEST AGGREGATE BY KEY ---
+
+// TestOperatorAggregateByKey - Prueba agregados multiples por clave
+// Entrada: t - objeto testing
+// Salida: ninguna (void), reporta fallos via t.Error
+// Descripcion: Valida group_by sobre una o mas columnas con varios
+//
+//	agregados a la vez (sum, count(*), max, avg) y la configuracion
+//	por defecto de group_by_key (count(*) por grupo).
+func TestOperatorAggregateByKey(t *testing.T) {
+	input := "norte,ana,10,2024-01-02\nsur,luis,5,2024-03-01\nnorte,ana,2.5,2024-05-09\nnorte,eva,7,2024-02-11"
+	columns := []string{"region", "user", "amount", "ts"}
+
+	cases := []struct {
+		name       string
+		op         string
+		groupBy    []string
+		aggregates []string
+		expected   string
+	}{
+		{
+			name:       "Multiples agregados",
+			op:         "aggregate_by_key",
+			groupBy:    []string{"region"},
+			aggregates: []string{"sum(amount)", "count(*)", "max(ts)"},
+			expected:   "norte, 19.5, 3, 2024-05-09\nsur, 5, 1, 2024-03-01",
+		},
+		{
+			name:       "Clave compuesta con indices",
+			op:         "aggregate_by_key",
+			groupBy:    []string{"0", "1"},
+			aggregates: []string{"avg(2)"},
+			expected:   "norte, ana, 6.25\nnorte, eva, 7\nsur, luis, 5",
+		},
+		{
+			name:     "group_by_key por defecto cuenta filas",
+			op:       "group_by_key",
+			groupBy:  []string{"user"},
+			expected: "ana, 2\neva, 1\nluis, 1",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			inputFile := createTempFile(t, input)
+			defer os.Remove(inputFile)
+			outputFile := inputFile + "_out"
+			defer os.Remove(outputFile)
+
+			err := operators.AggregateByKey(context.Background(), []string{inputFile}, outputFile, tc.op, tc.groupBy, tc.aggregates, columns)
+			if err != nil {
+				t.Fatalf("Error ejecutando AggregateByKey: %v", err)
+			}
+
+			result := readFile(t, outputFile)
+			if result != tc.expected {
+				t.Errorf("\nCaso: %s\nEsperado:\n%s\nObtenido:\n%s", tc.name, tc.expected, result)
+			}
+		})
+	}
+
+	// Configuracion invalida debe fallar antes de leer datos
+	if err := operators.AggregateByKey(context.Background(), nil, os.DevNull, "aggregate_by_key", []string{"region"}, []string{"median(amount)"}, columns); err == nil {
+		t.Error("Se esperaba error para agregado desconocido")
+	}
+}
+
+// --- TEST WINDOW / TOP-N ---
+
+// TestOperatorWindowByKey - Prueba ranking por clave (top_n_by_key, window)
+// Entrada: t - objeto testing
+// Salida: ninguna (void), reporta fallos via t.Error
+// Descripcion: Valida top N por particion, rank con empates y
+//
+//	dense_rank, ademas de la validacion de configuracion.
+func TestOperatorWindowByKey(t *testing.T) {
+	input := "s1,tv,300\ns1,radio,50\ns2,tv,120\ns1,phone,300\ns2,radio,80\ns1,cable,10"
+	columns := []string{"store", "product", "sales"}
+
+	cases := []struct {
+		name     string
+		op       string
+		fn       string
+		limit    int
+		expected string
+	}{
+		{
+			name:     "Top 2 por tienda",
+			op:       "top_n_by_key",
+			limit:    2,
+			expected: "s1,tv,300\ns1,phone,300\ns2,tv,120\ns2,radio,80",
+		},
+		{
+			name:     "Rank con empates",
+			op:       "window",
+			fn:       "rank",
+			expected: "s1,tv,300, 1\ns1,phone,300, 1\ns1,radio,50, 3\ns1,cable,10, 4\ns2,tv,120, 1\ns2,radio,80, 2",
+		},
+		{
+			name:     "Dense rank limitado",
+			op:       "window",
+			fn:       "dense_rank",
+			limit:    2,
+			expected: "s1,tv,300, 1\ns1,phone,300, 1\ns1,radio,50, 2\ns2,tv,120, 1\ns2,radio,80, 2",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			inputFile := createTempFile(t, input)
+			defer os.Remove(inputFile)
+			outputFile := inputFile + "_out"
+			defer os.Remove(outputFile)
+
+			spec, err := operators.PrepareWindow(tc.op, tc.fn, []string{"store"}, []string{"sales desc"}, columns, tc.limit)
+			if err != nil {
+				t.Fatalf("Configuracion invalida: %v", err)
+			}
+			if err := operators.WindowByKey(context.Background(), []string{inputFile}, outputFile, spec); err != nil {
+				t.Fatalf("Error ejecutando WindowByKey: %v", err)
+			}
+
+			result := readFile(t, outputFile)
+			if result != tc.expected {
+				t.Errorf("\nCaso: %s\nEsperado:\n%s\nObtenido:\n%s", tc.name, tc.expected, result)
+			}
+		})
+	}
+
+	// top_n_by_key sin limite no es valido
+	if _, err := operators.PrepareWindow("top_n_by_key", "", []string{"store"}, nil, columns, 0); err == nil {
+		t.Error("Se esperaba error para top_n_by_key sin limit")
+	}
+}
+
+// --- TEST SQL FRONT-END ---
+
+// TestCompileSQL - Prueba la compilacion de consultas SQL a DAG
+// Entrada: t - objeto testing
+// Salida: ninguna (void), reporta fallos via t.Error
+// Descripcion: Verifica la secuencia de operadores generada (pushdown de
+//
+//	filtros, reordenamiento de clave antes del join, agregacion) y los
+//	errores de tablas/columnas desconocidas.
+func TestCompileSQL(t *testing.T) {
+	tables := map[string]common.TableDef{
+		"users":     {Name: "users", Path: "data/users2.csv", Columns: []string{"id", "name"}},
+		"purchases": {Name: "purchases", Path: "data/purchases.csv", Columns: []string{"user_id", "item"}},
+	}
+
+	cases := []struct {
+		name     string
+		sql      string
+		expected []string // Secuencia "id:op" de nodos esperada
+		wantErr  bool
+	}{
+		{
+			name:     "Filtro y proyeccion",
+			sql:      "SELECT name FROM users WHERE id > 1",
+			expected: []string{"read_users:read_csv", "filter_users:filter", "select:map"},
+		},
+		{
+			name: "Join con agrupacion",
+			sql:  "SELECT u.name, count(*) AS total FROM users u JOIN purchases p ON p.user_id = u.id WHERE p.item != 'Mouse' GROUP BY u.name",
+			expected: []string{
+				"read_u:read_csv", "read_p:read_csv", "filter_p:filter",
+				"join:join", "aggregate:aggregate_by_key",
+			},
+		},
+		{
+			name:     "Agregados sobre columnas",
+			sql:      "SELECT name, sum(id) AS total, min(id), max(u.id), avg(id), count(id) FROM users u GROUP BY name",
+			expected: []string{"read_u:read_csv", "aggregate:aggregate_by_key"},
+		},
+		{
+			name:     "Agregado antes de la clave",
+			sql:      "SELECT count(id), name FROM users GROUP BY name",
+			expected: []string{"read_users:read_csv", "aggregate:aggregate_by_key", "select:map"},
+		},
+		{name: "Tabla desconocida", sql: "SELECT * FROM ventas", wantErr: true},
+		{name: "Columna fuera de GROUP BY", sql: "SELECT id, count(*) FROM users GROUP BY name", wantErr: true},
+		{name: "Sintaxis invalida", sql: "SELECT FROM users", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := query.CompileSQL(tc.sql, tables, "", 1)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("Se esperaba error para: %s", tc.sql)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error compilando: %v", err)
+			}
+
+			var got []string
+			for _, n := range req.DAG.Nodes {
+				got = append(got, n.ID+":"+n.Op)
+			}
+			if strings.Join(got, " ") != strings.Join(tc.expected, " ") {
+				t.Errorf("\nCaso: %s\nEsperado: %v\nObtenido: %v", tc.name, tc.expected, got)
+			}
+		})
+	}
+
+	// Sin shuffle, join y GROUP BY solo son correctos con 1 particion
+	if _, err := query.CompileSQL("SELECT name FROM users", tables, "", 4); err == nil {
+		t.Error("Se esperaba error para parallelism > 1")
+	}
+	if req, err := query.CompileSQL("SELECT name FROM users", tables, "", 0); err != nil || req.Parallelism != 1 {
+		t.Errorf("Parallelism por defecto: %d %v", req.Parallelism, err)
+	}
+}
+
+// TestSDKDatasetBuilder - Prueba el builder fluido del SDK y la validacion de DAGs
+// Entrada: t - objeto testing
+// Salida: ninguna (void), reporta fallos via t.Error
+// Descripcion: Verifica IDs y aristas generados, la fusion de planes en
+//
+//	un join (izquierda primero), el aislamiento de ramas derivadas de un
+//	mismo Dataset y el rechazo de DAGs invalidos.
+func TestSDKDatasetBuilder(t *testing.T) {
+	req, err := minispark.ReadCSV("data/input.txt").FlatMap("tokenize").ReduceByKey("sum").Job("wordcount", 2)
+	if err != nil {
+		t.Fatalf("Error construyendo job: %v", err)
+	}
+	var ids []string
+	for _, n := range req.DAG.Nodes {
+		ids = append(ids, n.ID)
+	}
+	if strings.Join(ids, " ") != "read_csv_1 flat_map_1 reduce_by_key_1" {
+		t.Errorf("IDs inesperados: %v", ids)
+	}
+	if len(req.DAG.Edges) != 2 || req.DAG.Edges[1][0] != "flat_map_1" || req.DAG.Edges[1][1] != "reduce_by_key_1" {
+		t.Errorf("Aristas inesperadas: %v", req.DAG.Edges)
+	}
+
+	// Join entre dos planes: el lado derecho se renombra y va segundo
+	left := minispark.ReadCSV("data/users.csv")
+	right := minispark.ReadCSV("data/purchases.csv")
+	req, err = left.Join(right).Job("join", 1)
+	if err != nil {
+		t.Fatalf("Error construyendo join: %v", err)
+	}
+	if len(req.DAG.Nodes) != 3 {
+		t.Fatalf("Se esperaban 3 nodos, obtenido %d", len(req.DAG.Nodes))
+	}
+	if req.DAG.Edges[0][0] != "read_csv_1" || req.DAG.Edges[1][0] != "read_csv_2" {
+		t.Errorf("Orden de inputs del join incorrecto: %v", req.DAG.Edges)
+	}
+
+	// Dos ramas del mismo Dataset: ninguna aparece en el plan de la otra
+	// ni en el de la base; al unirlas el origen comun se lee una vez
+	base := minispark.ReadCSV("data/input.txt")
+	lower := base.Map("to_lower")
+	long := base.Filter("long_words")
+	nodeIDs := func(d *minispark.Dataset) string {
+		req, err := d.Job("ramas", 1)
+		if err != nil {
+			t.Fatalf("Error construyendo rama: %v", err)
+		}
+		var ids []string
+		for _, n := range req.DAG.Nodes {
+			ids = append(ids, n.ID)
+		}
+		return strings.Join(ids, " ")
+	}
+	if got := nodeIDs(lower); got != "read_csv_1 map_1" {
+		t.Errorf("Rama map contaminada: %s", got)
+	}
+	if got := nodeIDs(long); got != "read_csv_1 filter_1" {
+		t.Errorf("Rama filter contaminada: %s", got)
+	}
+	if got := nodeIDs(base); got != "read_csv_1" {
+		t.Errorf("Dataset base modificado: %s", got)
+	}
+	if got := nodeIDs(lower.Join(long)); got != "read_csv_1 map_1 filter_1 join_1" {
+		t.Errorf("Join de ramas hermanas: %s", got)
+	}
+
+	// Errores de validacion
+	var valErr *minispark.ValidationError
+	if _, err := minispark.ReadCSV("").Job("", 1); !errors.As(err, &valErr) {
+		t.Errorf("Se esperaba ValidationError por path vacio, obtenido %v", err)
+	}
+	invalid := []common.DAG{
+		{Nodes: []common.DAGNode{{ID: "a", Op: "read_csv", Path: "x"}, {ID: "a", Op: "map", Fn: "to_lower"}}},
+		{Nodes: []common.DAGNode{{ID: "a", Op: "read_csv", Path: "x"}, {ID: "b", Op: "sort"}}, Edges: [][]string{{"a", "b"}}},
+		{Nodes: []common.DAGNode{{ID: "a", Op: "read_csv", Path: "x"}, {ID: "b", Op: "map"}}, Edges: [][]string{{"a", "c"}}},
+		{Nodes: []common.DAGNode{{ID: "a", Op: "read_csv", Path: "x"}, {ID: "b", Op: "join"}}, Edges: [][]string{{"a", "b"}}},
+		{Nodes: []common.DAGNode{{ID: "a", Op: "read_csv", Path: "x"}, {ID: "b", Op: "map"}, {ID: "c", Op: "map"}},
+			Edges: [][]string{{"a", "b"}, {"b", "c"}, {"c", "b"}}},
+	}
+	for i, dag := range invalid {
+		if err := common.ValidateDAG(dag); err == nil {
+			t.Errorf("DAG invalido #%d fue aceptado", i)
+		}
+	}
+}
+
+// TestJobTemplateRender - Prueba la sustitucion y verificacion de parametros
+// Entrada: t - objeto testing
+// Salida: ninguna (void), reporta fallos via t.Error
+// Descripcion: Verifica defaults, conversion de tipos desde strings del CLI,
+//
+//	placeholders dentro de texto y errores de tipo/param desconocido.
+func TestJobTemplateRender(t *testing.T) {
+	tmpl, err := os.ReadFile("../jobs/templates/wordcount.json")
+	if err != nil {
+		t.Fatalf("No se pudo leer plantilla: %v", err)
+	}
+
+	req, rendered, err := jobtemplate.Render(tmpl, map[string]interface{}{"input": "data/x.csv", "parallelism": "8"})
+	if err != nil {
+		t.Fatalf("Error renderizando: %v", err)
+	}
+	if req.Parallelism != 8 || req.DAG.Nodes[0].Path != "data/x.csv" || req.Name != "wordcount-data/x.csv" {
+		t.Errorf("Render inesperado: %+v", req)
+	}
+	if strings.Contains(string(rendered), "${") || strings.Contains(string(rendered), `"params"`) {
+		t.Errorf("JSON renderizado conserva placeholders: %s", rendered)
+	}
+	if err := common.ValidateJobRequest(req); err != nil {
+		t.Errorf("Job renderizado invalido: %v", err)
+	}
+
+	// Defaults
+	req, _, err = jobtemplate.Render(tmpl, nil)
+	if err != nil || req.Parallelism != 1 || req.DAG.Nodes[0].Path != "data/don_quijote.txt" {
+		t.Errorf("Defaults no aplicados: %+v err=%v", req, err)
+	}
+
+	errCases := []struct {
+		name   string
+		tmpl   string
+		params map[string]interface{}
+	}{
+		{"Tipo incorrecto", string(tmpl), map[string]interface{}{"parallelism": "ocho"}},
+		{"Param desconocido", string(tmpl), map[string]interface{}{"output": "x"}},
+		{"Param obligatorio", `{"params": {"input": {"type": "string"}}, "name": "${input}"}`, nil},
+		{"Placeholder sin declarar", `{"name": "${input}"}`, nil},
+		{"Campo desconocido", `{"params": {}, "nmae": "x"}`, nil},
+	}
+	for _, tc := range errCases {
+		if _, _, err := jobtemplate.Render([]byte(tc.tmpl), tc.params); err == nil {
+			t.Errorf("%s: se esperaba error", tc.name)
+		}
+	}
+}
+
+// TestPlacementPolicies - Prueba las politicas de colocacion de tareas
+// Entrada: t - objeto testing
+// Salida: ninguna (void), reporta fallos via t.Error
+// Descripcion: Verifica que resource-aware prefiera slots libres y menor
+//
+//	memoria, respete el limite de memoria, y que round-robin rote.
+func TestPlacementPolicies(t *testing.T) {
+	w1 := &common.WorkerInfo{ID: "w1", Metrics: common.SystemMetrics{MemoryUsage: 500}}
+	w2 := &common.WorkerInfo{ID: "w2", Metrics: common.SystemMetrics{MemoryUsage: 100}}
+	w3 := &common.WorkerInfo{ID: "w3", Metrics: common.SystemMetrics{MemoryUsage: 50}}
+	loads := []master.WorkerLoad{
+		{Worker: w1, FreeSlots: 4},
+		{Worker: w2, FreeSlots: 4},
+		{Worker: w3, FreeSlots: 1},
+	}
+
+	resource, err := master.NewPlacementPolicy("resource", 0)
+	if err != nil {
+		t.Fatalf("Error creando politica: %v", err)
+	}
+	if got := resource.Select(common.Task{}, loads); got != w2 {
+		t.Errorf("resource: esperado w2 (mas slots, menos memoria), obtenido %v", got.ID)
+	}
+
+	limited, _ := master.NewPlacementPolicy("resource", 80)
+	if got := limited.Select(common.Task{}, loads); got != w3 {
+		t.Errorf("resource con limite: esperado w3, obtenido %v", got.ID)
+	}
+	if got := limited.Select(common.Task{}, loads[:2]); got != nil {
+		t.Errorf("resource con limite: workers saturados no deben recibir tareas, obtenido %v", got.ID)
+	}
+
+	rr, _ := master.NewPlacementPolicy("round_robin", 0)
+	var order []string
+	for i := 0; i < 4; i++ {
+		order = append(order, rr.Select(common.Task{}, loads).ID)
+	}
+	if strings.Join(order, ",") != "w1,w2,w3,w1" {
+		t.Errorf("round_robin: orden inesperado %v", order)
+	}
+
+	if _, err := master.NewPlacementPolicy("random", 0); err == nil {
+		t.Error("Se esperaba error por politica desconocida")
+	}
+}